@@ -1,71 +1,614 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/siyul-park/minijs"
 
+	"github.com/siyul-park/minijs/internal/ast"
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/siyul-park/minijs/internal/cache"
 	"github.com/siyul-park/minijs/internal/compiler"
+	"github.com/siyul-park/minijs/internal/format"
 	"github.com/siyul-park/minijs/internal/interpreter"
 	"github.com/siyul-park/minijs/internal/lexer"
+	"github.com/siyul-park/minijs/internal/lint"
 	"github.com/siyul-park/minijs/internal/parser"
+
+	"github.com/fsnotify/fsnotify"
 )
 
+type options struct {
+	printBytecode bool
+	debugInfo     bool
+	strict        bool
+	useCache      bool
+	stream        bool
+	color         bool
+	dumpAST       bool
+	dumpBytecode  bool
+	disasm        string
+	eval          text
+	watch         bool
+	keepGlobals   bool
+}
+
+// text is the value of a string flag that records whether it was given, so
+// that an empty value can be told apart from a missing flag.
+type text struct {
+	value string
+	set   bool
+}
+
+// watchDelay is how long the file must stay unchanged before a watched
+// script runs again, so that an editor saving in several writes runs it once.
+const watchDelay = 100 * time.Millisecond
+
 func main() {
-	printBytecode := flag.Bool("print-bytecode", false, "")
+	opts := &options{debugInfo: true, useCache: true, color: true}
+	opts.register(flag.CommandLine)
 	flag.Parse()
 
 	args := flag.Args()
+	if len(args) > 0 && args[0] == "fmt" {
+		runFmt(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "lint" {
+		runLint(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "build" {
+		runBuild(args[1:], opts)
+		return
+	}
+	if len(args) > 0 && args[0] == "run" {
+		flags := flag.NewFlagSet("run", flag.ExitOnError)
+		opts.register(flags)
+		if args = parse(flags, args[1:]); len(args) == 0 && opts.disasm == "" && !opts.eval.set {
+			log.Fatal("Usage: minijs run [flags] file")
+		}
+	}
+
+	if opts.disasm != "" {
+		runDisasm(opts.disasm)
+		return
+	}
+	if opts.eval.set {
+		runEval([]byte(opts.eval.value), opts)
+		return
+	}
 	if len(args) == 0 {
-		runREPL(*printBytecode)
+		if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice == 0 {
+			source, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				log.Fatal("Error reading input: ", err)
+			}
+			runEval(source, opts)
+			return
+		}
+		runREPL(opts)
+		return
+	}
+	if opts.watch {
+		runWatch(args[0], opts)
 		return
 	}
-	runFile(args[0], *printBytecode)
+	if opts.stream {
+		runStream(args[0], opts)
+		return
+	}
+	runFile(args[0], opts)
 }
 
-func runREPL(printBytecode bool) {
-	r := minijs.NewREPL("> ", minijs.REPLOption{PrintBytecode: printBytecode})
+// register defines the flags on flags, defaulting to the current values of o.
+func (o *options) register(flags *flag.FlagSet) {
+	flags.BoolVar(&o.printBytecode, "print-bytecode", o.printBytecode, "")
+	flags.BoolVar(&o.debugInfo, "debug-info", o.debugInfo, "keep line numbers, global names, and the source in the bytecode")
+	flags.BoolVar(&o.strict, "strict", o.strict, "compile in strict mode, as if the source began with \"use strict\"")
+	flags.BoolVar(&o.useCache, "cache", o.useCache, "reuse the bytecode compiled in an earlier run of the same source")
+	flags.BoolVar(&o.stream, "stream", o.stream, "parse, compile, and run one top-level statement at a time")
+	flags.BoolVar(&o.color, "color", o.color, "highlight input typed in the REPL")
+	flags.BoolVar(&o.dumpAST, "dump-ast", o.dumpAST, "print the syntax tree instead of running")
+	flags.BoolVar(&o.dumpBytecode, "dump-bytecode", o.dumpBytecode, "print the bytecode with offsets instead of running")
+	flags.StringVar(&o.disasm, "disasm", o.disasm, "print the bytecode of a compiled `file` instead of running")
+	flags.Var(&o.eval, "e", "evaluate `source` and print its value")
+	flags.BoolVar(&o.watch, "watch", o.watch, "run the file again each time it changes")
+	flags.BoolVar(&o.keepGlobals, "keep-globals", o.keepGlobals, "keep the global variables between runs in watch mode")
+}
+
+func (t *text) String() string {
+	return t.value
+}
+
+func (t *text) Set(value string) error {
+	t.value = value
+	t.set = true
+	return nil
+}
+
+// parse parses args with flags, allowing flags after the positional
+// arguments, and returns the positional arguments.
+func parse(flags *flag.FlagSet, args []string) []string {
+	var rest []string
+	for {
+		_ = flags.Parse(args)
+		if args = flags.Args(); len(args) == 0 {
+			return rest
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+// dumps reports whether o prints what the toolchain produced instead of
+// running it.
+func (o *options) dumps() bool {
+	return o.printBytecode || o.dumpAST || o.dumpBytecode
+}
+
+func runREPL(opts *options) {
+	var history string
+	if home, err := os.UserHomeDir(); err == nil {
+		history = filepath.Join(home, ".minijs_history")
+	}
+
+	r := minijs.NewREPL("> ", minijs.REPLOption{
+		PrintBytecode: opts.printBytecode,
+		Strict:        opts.strict,
+		History:       history,
+		Color:         opts.color,
+	})
 	if err := r.Start(os.Stdin, os.Stdout); err != nil {
 		log.Fatal("Error starting REPL: ", err)
 	}
 }
 
-func runFile(filePath string, printBytecode bool) {
-	file, err := os.Open(filePath)
+func runFmt(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "write result to source file instead of stdout")
+	minimal := flags.Bool("minimal", false, "keep the layout between statements and only rewrite statements that are not canonical")
+	_ = flags.Parse(args)
+
+	reformat := format.Source
+	if *minimal {
+		reformat = format.Minimal
+	}
+
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatal("Error reading input: ", err)
+		}
+		formatted, err := reformat(source)
+		if err != nil {
+			log.Fatal("Error formatting input: ", err)
+		}
+		_, _ = os.Stdout.Write(formatted)
+		return
+	}
+
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal("Error opening file: ", err)
+		}
+		formatted, err := reformat(source)
+		if err != nil {
+			log.Fatalf("Error formatting %s: %v", path, err)
+		}
+		if !*write {
+			_, _ = os.Stdout.Write(formatted)
+		} else if !bytes.Equal(source, formatted) {
+			if err := os.WriteFile(path, formatted, 0o644); err != nil {
+				log.Fatal("Error writing file: ", err)
+			}
+		}
+	}
+}
+
+func runLint(args []string) {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the issues as a JSON array")
+	args = parse(flags, args)
+
+	type report struct {
+		File string `json:"file"`
+		lint.Issue
+	}
+
+	lintSource := func(name string, source []byte) []report {
+		issues, err := lint.Source(source)
+		if err != nil {
+			log.Fatalf("Error parsing %s: %v", name, err)
+		}
+		reports := make([]report, len(issues))
+		for i, issue := range issues {
+			reports[i] = report{File: name, Issue: issue}
+		}
+		return reports
+	}
+
+	var reports []report
+	if len(args) == 0 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatal("Error reading input: ", err)
+		}
+		reports = lintSource("<stdin>", source)
+	}
+	for _, path := range args {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal("Error opening file: ", err)
+		}
+		reports = append(reports, lintSource(path, source)...)
+	}
+
+	if *asJSON {
+		if reports == nil {
+			reports = []report{}
+		}
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			log.Fatal("Error encoding issues: ", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, r := range reports {
+			fmt.Printf("%s:%s\n", r.File, r.Issue)
+		}
+	}
+	if len(reports) > 0 {
+		os.Exit(1)
+	}
+}
+
+func runBuild(args []string, opts *options) {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	output := flags.String("o", "", "write the bytecode to `file` instead of the source path with a .mjsc extension")
+	flags.BoolVar(&opts.debugInfo, "debug-info", opts.debugInfo, "keep line numbers, global names, and the source in the bytecode")
+	flags.BoolVar(&opts.strict, "strict", opts.strict, "compile in strict mode, as if the source began with \"use strict\"")
+	args = parse(flags, args)
+	if len(args) != 1 {
+		log.Fatal("Usage: minijs build [-o file] file")
+	}
+
+	source, err := os.ReadFile(args[0])
 	if err != nil {
 		log.Fatal("Error opening file: ", err)
 	}
-	defer file.Close()
+	code := compile(source, opts.debugInfo, opts.strict)
+	data, err := code.MarshalBinary()
+	if err != nil {
+		log.Fatal("Error encoding bytecode: ", err)
+	}
 
-	l := lexer.New(file)
-	p := parser.New(l)
+	if *output == "" {
+		*output = strings.TrimSuffix(args[0], filepath.Ext(args[0])) + ".mjsc"
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		log.Fatal("Error writing file: ", err)
+	}
+}
 
-	program, err := p.Parse()
+func runFile(filePath string, opts *options) {
+	source, err := os.ReadFile(filePath)
 	if err != nil {
-		log.Fatal("Error parsing program: ", err)
+		log.Fatal("Error opening file: ", err)
+	}
+
+	// A .mjsc file is decoded even without the magic number, so that a damaged
+	// one is reported as such rather than compiled as source.
+	var code bytecode.Bytecode
+	if filepath.Ext(filePath) == ".mjsc" || bytes.HasPrefix(source, bytecode.Magic[:]) {
+		if opts.dumpAST {
+			log.Fatalf("Error: %s is compiled and has no syntax tree", filePath)
+		}
+		code = load(filePath, source, opts)
+	} else {
+		if opts.dumpAST {
+			if err := ast.Fprint(os.Stdout, parseSource(source)); err != nil {
+				log.Fatal("Error writing output: ", err)
+			}
+		}
+
+		var c *cache.Cache
+		var key string
+		if opts.useCache {
+			if c, err = cache.Default(); err == nil {
+				key = c.Key(source, fmt.Sprint(compiler.O2), fmt.Sprint(opts.debugInfo), fmt.Sprint(opts.strict))
+			}
+		}
+
+		var ok bool
+		if c != nil {
+			code, ok = c.Load(key)
+		}
+		if !ok {
+			code = compile(source, opts.debugInfo, opts.strict)
+			if c != nil {
+				_ = c.Store(key, code)
+			}
+		}
+	}
+
+	if opts.printBytecode {
+		fmt.Println(code.String())
 	}
+	if opts.dumpBytecode {
+		disassemble(code)
+	}
+	if !opts.dumps() {
+		i := interpreter.New()
+		if err := i.Execute(code); err != nil {
+			log.Fatal("Error executing code: ", err)
+		}
+	}
+}
 
-	c := compiler.New()
+// runEval runs source and prints the value of its last expression statement.
+// Strings are printed without quotes so that the output can be piped.
+func runEval(source []byte, opts *options) {
+	program := parseSource(source)
+	if opts.dumpAST {
+		if err := ast.Fprint(os.Stdout, program); err != nil {
+			log.Fatal("Error writing output: ", err)
+		}
+	}
+
+	c := compiler.New(
+		compiler.WithOptimization(compiler.O2),
+		compiler.WithDebugInfo(opts.debugInfo),
+		compiler.WithStrict(opts.strict),
+		compiler.WithSource(string(source)),
+		compiler.WithCompletionValue(true),
+	)
 	code, err := c.Compile(program)
 	if err != nil {
 		log.Fatal("Error compiling program: ", err)
 	}
 
-	o := interpreter.NewOptimizer()
-	code, err = o.Optimize(code)
+	if opts.printBytecode {
+		fmt.Println(code.String())
+	}
+	if opts.dumpBytecode {
+		disassemble(code)
+	}
+	if opts.dumps() {
+		return
+	}
+
+	i := interpreter.New()
+	if err := i.Execute(code); err != nil {
+		log.Fatal("Error executing code: ", err)
+	}
+	val, err := i.Pop()
+	if errors.Is(err, interpreter.ErrStackUnderflow) {
+		return
+	}
+	if s, ok := val.(interpreter.String); ok {
+		fmt.Println(string(s))
+	} else {
+		fmt.Println(val)
+	}
+}
+
+// runWatch runs the file and runs it again each time it changes, until the
+// process is interrupted. Errors are printed and do not stop the watch.
+func runWatch(filePath string, opts *options) {
+	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
-		log.Fatal("Error optimize program: ", err)
+		log.Fatal("Error watching file: ", err)
+	}
+	defer watcher.Close()
+
+	// Editors often save by replacing the file, so the directory is watched.
+	if err := watcher.Add(filepath.Dir(filePath)); err != nil {
+		log.Fatal("Error watching file: ", err)
 	}
 
-	if printBytecode {
+	session := compiler.NewSession()
+	i := interpreter.New()
+	run := func() {
+		if !opts.keepGlobals {
+			session = compiler.NewSession()
+			i = interpreter.New()
+		}
+		if err := runOnce(filePath, session, i, opts); err != nil {
+			log.Print("Error ", err)
+		}
+		log.Printf("Watching %s for changes", filePath)
+	}
+	run()
+
+	name := filepath.Clean(filePath)
+	var timer <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == name && event.Has(fsnotify.Write|fsnotify.Create) {
+				timer = time.After(watchDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Print("Error watching file: ", err)
+		case <-timer:
+			timer = nil
+			run()
+		}
+	}
+}
+
+// runOnce compiles the file in session and runs it on i, returning the first
+// error instead of exiting.
+func runOnce(filePath string, session *compiler.Session, i *interpreter.Interpreter, opts *options) error {
+	source, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	program, err := parser.New(lexer.NewBytes(source)).Parse()
+	if err != nil {
+		return fmt.Errorf("parsing program: %w", err)
+	}
+	if opts.dumpAST {
+		if err := ast.Fprint(os.Stdout, program); err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+	}
+
+	c := compiler.New(
+		compiler.WithSession(session),
+		compiler.WithOptimization(compiler.O1),
+		compiler.WithDebugInfo(opts.debugInfo),
+		compiler.WithStrict(opts.strict),
+		compiler.WithSource(string(source)),
+	)
+	code, err := c.Compile(program)
+	if err != nil {
+		return fmt.Errorf("compiling program: %w", err)
+	}
+
+	if opts.printBytecode {
 		fmt.Println(code.String())
-	} else {
-		i := interpreter.New()
-		if err := i.Execute(code); err != nil {
-			log.Fatal("Error executing code: ", err)
+	}
+	if opts.dumpBytecode {
+		disassemble(code)
+	}
+	if opts.dumps() {
+		return nil
+	}
+	if err := i.Execute(code); err != nil {
+		return fmt.Errorf("executing code: %w", err)
+	}
+	return nil
+}
+
+func runStream(filePath string, opts *options) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Fatal("Error opening file: ", err)
+	}
+	defer file.Close()
+
+	c := compiler.New(
+		compiler.WithSession(compiler.NewSession()),
+		compiler.WithOptimization(compiler.O2),
+		compiler.WithDebugInfo(opts.debugInfo),
+		compiler.WithStrict(opts.strict),
+	)
+	i := interpreter.New()
+
+	p := parser.New(lexer.New(file))
+	for stmt, err := range p.Statements() {
+		if err != nil {
+			log.Fatal("Error parsing program: ", err)
+		}
+		if directive, ok := stmt.(*ast.Directive); ok && directive.Value.Value == "use strict" {
+			compiler.WithStrict(true)(c)
+		}
+		if opts.dumpAST {
+			if err := ast.Fprint(os.Stdout, stmt); err != nil {
+				log.Fatal("Error writing output: ", err)
+			}
+		}
+
+		code, err := c.Compile(ast.NewProgram(stmt))
+		if err != nil {
+			log.Fatal("Error compiling program: ", err)
+		}
+		if opts.printBytecode {
+			fmt.Println(code.String())
+		}
+		if opts.dumpBytecode {
+			disassemble(code)
+		}
+		if !opts.dumps() {
+			if err := i.Execute(code); err != nil {
+				log.Fatal("Error executing code: ", err)
+			}
+		}
+	}
+}
+
+func runDisasm(filePath string) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		log.Fatal("Error opening file: ", err)
+	}
+
+	var code bytecode.Bytecode
+	if err := code.UnmarshalBinary(data); err != nil {
+		log.Fatalf("Error decoding %s: %v", filePath, err)
+	}
+	if err := code.LoadDebug(); err != nil {
+		log.Fatalf("Error decoding %s: %v", filePath, err)
+	}
+	disassemble(code)
+}
+
+// load decodes and verifies the bytecode of a file built with minijs build.
+// Bytecode in a format this runtime does not support is replaced by compiling
+// the source file next to it, if there is one.
+func load(filePath string, data []byte, opts *options) bytecode.Bytecode {
+	var code bytecode.Bytecode
+	err := code.UnmarshalBinary(data)
+	if errors.Is(err, bytecode.ErrUnsupportedVersion) {
+		sourcePath := strings.TrimSuffix(filePath, filepath.Ext(filePath)) + ".js"
+		if source, err2 := os.ReadFile(sourcePath); err2 == nil {
+			log.Printf("Warning: %s: %v; running %s instead", filePath, err, sourcePath)
+			return compile(source, opts.debugInfo, opts.strict)
 		}
+		log.Fatalf("Error loading %s: %v; rebuild it from source with minijs build", filePath, err)
+	}
+	if err == nil {
+		err = interpreter.Verify(code)
+	}
+	if err != nil {
+		log.Fatalf("Error loading %s: %v", filePath, err)
+	}
+	return code
+}
+
+func disassemble(code bytecode.Bytecode) {
+	fmt.Println(code.Format(bytecode.WithOffsets(true)))
+}
+
+func parseSource(source []byte) *ast.Program {
+	program, err := parser.New(lexer.NewBytes(source)).Parse()
+	if err != nil {
+		log.Fatal("Error parsing program: ", err)
+	}
+	return program
+}
+
+func compile(source []byte, debugInfo, strict bool) bytecode.Bytecode {
+	program := parseSource(source)
+
+	c := compiler.New(
+		compiler.WithOptimization(compiler.O2),
+		compiler.WithDebugInfo(debugInfo),
+		compiler.WithStrict(strict),
+		compiler.WithSource(string(source)),
+	)
+	code, err := c.Compile(program)
+	if err != nil {
+		log.Fatal("Error compiling program: ", err)
 	}
+	return code
 }
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/stretchr/testify/assert"
+)
+
+type result struct {
+	stdout string
+	stderr string
+	code   int
+}
+
+// TestMain runs main instead of the tests when the test binary is started by
+// command, so that tests run the command in a process of its own.
+func TestMain(m *testing.M) {
+	if os.Getenv("MINIJS_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestRun_Dump(t *testing.T) {
+	path := write(t, "a.js", "var s = 'b' + 'a';\nvar t = s + s;\nt()\n")
+
+	tests := []struct {
+		args   []string
+		expect string
+	}{
+		{
+			args:   []string{"run", "--dump-ast", path},
+			expect: "Program\n  Statements[0]: VariableStatement var\n",
+		},
+		{
+			args:   []string{"run", "--dump-bytecode", path},
+			expect: "\t0000: str.load 0x0\n\t0002: slot.store 0x0\n",
+		},
+		{
+			args:   []string{"run", path, "--print-bytecode"},
+			expect: "\tstr.load 0x0\n\tslot.store 0x0\n",
+		},
+		{
+			args:   []string{"-print-bytecode", path},
+			expect: "\tstr.load 0x0\n\tslot.store 0x0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.ReplaceAll(strings.Join(tt.args, " "), path, "a.js"), func(t *testing.T) {
+			r := command(t, "", tt.args...)
+			assert.Equal(t, 0, r.code, r.stderr)
+			assert.Contains(t, r.stdout, tt.expect)
+		})
+	}
+}
+
+func TestRun_Error(t *testing.T) {
+	tests := []struct {
+		source string
+		expect string
+	}{
+		{source: "var = 1", expect: "Error parsing program"},
+		{source: "a", expect: "undefined identifier: a"},
+		{source: "var a = 1; a()", expect: "Error executing code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			r := command(t, "", "run", write(t, "a.js", tt.source))
+			assert.Equal(t, 1, r.code)
+			assert.Contains(t, r.stderr, tt.expect)
+		})
+	}
+
+	r := command(t, "", "run")
+	assert.Equal(t, 1, r.code)
+	assert.Contains(t, r.stderr, "Usage: minijs run")
+}
+
+func TestRun_Stream(t *testing.T) {
+	tests := []string{
+		"var a = 1; var b = 2; function g() { return 10 }; var r = 20; g() + g(); var out = r - 1;",
+		"var s = 'a';\nvar t = s + 'b';\nt + s;\n",
+	}
+
+	for _, source := range tests {
+		t.Run(source, func(t *testing.T) {
+			r := command(t, "", "-stream", write(t, "a.js", source))
+			assert.Equal(t, 0, r.code, r.stderr)
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	path := write(t, "a.js", "var a = 1;\na()\n")
+	dir := filepath.Dir(path)
+
+	r := command(t, "", "build", path)
+	assert.Equal(t, 0, r.code, r.stderr)
+
+	data, err := os.ReadFile(filepath.Join(dir, "a.mjsc"))
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, bytecode.Magic[:]))
+
+	output := filepath.Join(dir, "out.mjsc")
+	r = command(t, "", "build", path, "-o", output)
+	assert.Equal(t, 0, r.code, r.stderr)
+
+	r = command(t, "", "run", "--disasm", output)
+	assert.Equal(t, 0, r.code, r.stderr)
+	assert.Contains(t, r.stdout, "\t0000: i32.load 0x00000001\n")
+
+	assert.NoError(t, os.Remove(path))
+
+	r = command(t, "", "run", output)
+	assert.Equal(t, 1, r.code)
+	assert.Contains(t, r.stderr, "Error executing code")
+	assert.Contains(t, r.stderr, "line 2")
+
+	r = command(t, "", "build")
+	assert.Equal(t, 1, r.code)
+	assert.Contains(t, r.stderr, "Usage: minijs build")
+}
+
+func TestRun_Bytecode(t *testing.T) {
+	unsupported := append(bytecode.Magic[:], 99)
+
+	marshal := func(instructions ...bytecode.Instruction) []byte {
+		var code bytecode.Bytecode
+		code.Emit(instructions...)
+		data, err := code.MarshalBinary()
+		assert.NoError(t, err)
+		return data
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		source string
+		code   int
+		expect string
+	}{
+		{
+			name:   "unsupported version with source",
+			data:   unsupported,
+			source: "var a = 1;",
+			code:   0,
+			expect: "unsupported bytecode version: compiled with format v99",
+		},
+		{
+			name:   "unsupported version",
+			data:   unsupported,
+			code:   1,
+			expect: "rebuild it from source with minijs build",
+		},
+		{
+			name:   "bad magic number",
+			data:   []byte("var a = 1;"),
+			code:   1,
+			expect: "bad magic number",
+		},
+		{
+			name:   "truncated",
+			data:   bytecode.Magic[:],
+			code:   1,
+			expect: "Error loading",
+		},
+		{
+			name:   "slot out of range",
+			data:   marshal(bytecode.New(bytecode.UNDEFLOAD), bytecode.New(bytecode.SLTSTORE, 0x3FFFFFFFFFFFFFFF)),
+			code:   1,
+			expect: "invalid bytecode: slot out of range",
+		},
+		{
+			name:   "cell out of range",
+			data:   marshal(bytecode.New(bytecode.CELLLOAD, 0x7FFFFFFFFFFFFFFF)),
+			code:   1,
+			expect: "invalid bytecode: slot out of range",
+		},
+		{
+			name: "division by zero",
+			data: marshal(bytecode.New(bytecode.I32LOAD, 1), bytecode.New(bytecode.I32LOAD, 0), bytecode.New(bytecode.I32DIV), bytecode.New(bytecode.POP)),
+			code: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := write(t, "a.mjsc", string(tt.data))
+			if tt.source != "" {
+				assert.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(path), "a.js"), []byte(tt.source), 0o644))
+			}
+
+			r := command(t, "", "run", path)
+			assert.Equal(t, tt.code, r.code, r.stderr)
+			assert.Contains(t, r.stderr, tt.expect)
+		})
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		args   []string
+		stdin  string
+		stdout string
+		stderr string
+		code   int
+	}{
+		{args: []string{"-e", "'b'+'a'+ +'a'+'a'"}, stdout: "baNaNa\n"},
+		{args: []string{"-e", "1 + 2"}, stdout: "3\n"},
+		{args: []string{"-e", "var a = 1"}, stdout: ""},
+		{args: []string{"run", "-e", "0.5 * 3"}, stdout: "1.5\n"},
+		{args: []string{"-e", "var = 1"}, stderr: "Error parsing program", code: 1},
+		{args: []string{"-e", "a"}, stderr: "undefined identifier: a", code: 1},
+		{args: []string{"-e", ""}, stdin: "1 + 2", stdout: ""},
+		{args: []string{"run", "-e", ""}, stdin: "1 + 2", stdout: ""},
+		{stdin: "var x = 2; x * 21", stdout: "42\n"},
+		{stdin: "var s = 'a';\ns + s\n", stdout: "aa\n"},
+		{stdin: "var = 1", stderr: "Error parsing program", code: 1},
+	}
+
+	for _, tt := range tests {
+		name := tt.stdin
+		if tt.args != nil {
+			name = strings.Join(tt.args, " ")
+		}
+		t.Run(name, func(t *testing.T) {
+			r := command(t, tt.stdin, tt.args...)
+			assert.Equal(t, tt.code, r.code, r.stderr)
+			assert.Equal(t, tt.stdout, r.stdout)
+			assert.Contains(t, r.stderr, tt.stderr)
+		})
+	}
+}
+
+func TestRun_Watch(t *testing.T) {
+	tests := []struct {
+		args   []string
+		expect string
+	}{
+		{args: []string{"run", "--watch"}, expect: "undefined identifier: a"},
+		{args: []string{"run", "--watch", "--keep-globals"}, expect: "Error executing code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			path := write(t, "a.js", "var a = 1;")
+
+			cmd := exec.Command(os.Args[0], append(tt.args, path)...)
+			cmd.Env = environ(t)
+			stderr, err := cmd.StderrPipe()
+			assert.NoError(t, err)
+			assert.NoError(t, cmd.Start())
+
+			lines := make(chan string)
+			go func() {
+				defer close(lines)
+				scanner := bufio.NewScanner(stderr)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+			defer func() {
+				_ = cmd.Process.Kill()
+				for range lines {
+				}
+				_ = cmd.Wait()
+			}()
+
+			wait := func(substr string) {
+				t.Helper()
+				timeout := time.After(10 * time.Second)
+				for {
+					select {
+					case line, ok := <-lines:
+						if !ok {
+							t.Fatalf("exited before printing %q", substr)
+						}
+						if strings.Contains(line, substr) {
+							return
+						}
+					case <-timeout:
+						t.Fatalf("timed out waiting for %q", substr)
+					}
+				}
+			}
+
+			wait("Watching " + path)
+
+			assert.NoError(t, os.WriteFile(path, []byte("var = 1"), 0o644))
+			wait("Error parsing program")
+			wait("Watching " + path)
+
+			assert.NoError(t, os.WriteFile(path, []byte("a()"), 0o644))
+			wait(tt.expect)
+			wait("Watching " + path)
+		})
+	}
+}
+
+// command runs the minijs command with args and stdin.
+func command(t *testing.T, stdin string, args ...string) result {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = environ(t)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	r := result{}
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) {
+			t.Fatal(err)
+		}
+		r.code = exit.ExitCode()
+	}
+	r.stdout = stdout.String()
+	r.stderr = stderr.String()
+	return r
+}
+
+// environ returns the environment that makes the test binary run main, with
+// the cache and home directories in temporary directories.
+func environ(t *testing.T) []string {
+	return append(os.Environ(), "MINIJS_TEST_MAIN=1", "XDG_CACHE_HOME="+t.TempDir(), "HOME="+t.TempDir())
+}
+
+// write creates a file named name with content in a temporary directory and
+// returns its path.
+func write(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
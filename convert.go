@@ -0,0 +1,289 @@
+package minijs
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/siyul-park/minijs/internal/interpreter"
+)
+
+// Value is a value as the interpreter sees it. Use FromGo and ToGo to move
+// between Values and Go types.
+type Value = interpreter.Value
+
+type converter struct {
+	encode func(reflect.Value) (any, error)
+	decode func(any) (reflect.Value, error)
+}
+
+var (
+	converters = map[reflect.Type]converter{}
+	mu         sync.RWMutex
+)
+
+var numberType = reflect.TypeOf(json.Number(""))
+
+// FromGo converts v to a script value.
+//
+// nil becomes null; bools, strings, and numbers become their script
+// counterparts, with integers that fit in 32 bits kept as integers;
+// json.Number is parsed as a number. Structs, maps with string keys, and
+// slices are exposed as objects that read through to v, and functions become
+// callable. Types with a converter registered by RegisterConverter are encoded
+// with it first.
+func FromGo(v any) (Value, error) {
+	return toValue(reflect.ValueOf(v))
+}
+
+// ToGo stores val in the value target points to, converting it to the target's
+// type the same way arguments of a function bound with VM.Set are converted.
+// Decoding into *any yields nil, bool, int32, float64, string, or the Go value
+// behind an object. Objects backed by maps and slices are copied element by
+// element when the target has a different map or slice type.
+func ToGo(val Value, target any) error {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+		return fmt.Errorf("target must be a non-nil pointer, got %T", target)
+	}
+	out, err := fromValue(val, ptr.Elem().Type())
+	if err != nil {
+		return err
+	}
+	ptr.Elem().Set(out)
+	return nil
+}
+
+// RegisterConverter teaches FromGo, ToGo, and bound functions how to handle T.
+// encode maps a T to any value FromGo already understands, and decode maps the
+// value ToGo would store in an *any back to a T. Either may be nil to convert in
+// one direction only. Registering T again replaces its converter.
+func RegisterConverter[T any](encode func(T) (any, error), decode func(any) (T, error)) {
+	var c converter
+	if encode != nil {
+		c.encode = func(v reflect.Value) (any, error) {
+			return encode(v.Interface().(T))
+		}
+	}
+	if decode != nil {
+		c.decode = func(v any) (reflect.Value, error) {
+			t, err := decode(v)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(&t).Elem(), nil
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	converters[reflect.TypeFor[T]()] = c
+}
+
+func lookup(typ reflect.Type) (converter, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	c, ok := converters[typ]
+	return c, ok
+}
+
+func convertible(typ reflect.Type) bool {
+	if _, ok := lookup(typ); ok {
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Interface:
+		return typ.NumMethod() == 0
+	case reflect.Struct:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return convertible(typ.Elem())
+	case reflect.Map:
+		return typ.Key().Kind() == reflect.String && convertible(typ.Elem())
+	case reflect.Func:
+		return true
+	default:
+		return false
+	}
+}
+
+func fromValue(val Value, typ reflect.Type) (reflect.Value, error) {
+	if c, ok := lookup(typ); ok && c.decode != nil {
+		out, err := c.decode(val.Interface())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot convert %v to %v: %w", val, typ, err)
+		}
+		return out, nil
+	}
+
+	out := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Bool:
+		out.SetBool(interpreter.ToBool(val) > 0)
+	case reflect.String:
+		if typ == numberType {
+			f := float64(interpreter.ToNumber(val))
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return out, fmt.Errorf("cannot convert %v to %v", val, typ)
+			}
+			out.SetString(interpreter.Float64(f).String())
+		} else {
+			out.SetString(string(interpreter.ToString(val)))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f := float64(interpreter.ToNumber(val))
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 || out.OverflowInt(int64(f)) {
+			return out, fmt.Errorf("cannot convert %v to %v", val, typ)
+		}
+		out.SetInt(int64(f))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f := float64(interpreter.ToNumber(val))
+		if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 || out.OverflowUint(uint64(f)) {
+			return out, fmt.Errorf("cannot convert %v to %v", val, typ)
+		}
+		out.SetUint(uint64(f))
+	case reflect.Float32, reflect.Float64:
+		out.SetFloat(float64(interpreter.ToNumber(val)))
+	case reflect.Interface:
+		if typ.NumMethod() > 0 {
+			if !reflect.TypeOf(val).AssignableTo(typ) {
+				return out, fmt.Errorf("cannot convert %v to %v", val, typ)
+			}
+			out.Set(reflect.ValueOf(val))
+		} else if v := val.Interface(); v != nil {
+			out.Set(reflect.ValueOf(v))
+		}
+	default:
+		v := reflect.ValueOf(val.Interface())
+		switch {
+		case v.IsValid() && v.Type().AssignableTo(typ):
+			out.Set(v)
+		case typ.Kind() == reflect.Map && v.Kind() == reflect.Map:
+			out.Set(reflect.MakeMapWithSize(typ, v.Len()))
+			for iter := v.MapRange(); iter.Next(); {
+				elem, err := copyValue(iter.Value(), typ.Elem())
+				if err != nil {
+					return out, err
+				}
+				out.SetMapIndex(iter.Key().Convert(typ.Key()), elem)
+			}
+		case (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array):
+			n := v.Len()
+			if typ.Kind() == reflect.Slice {
+				out.Set(reflect.MakeSlice(typ, n, n))
+			} else {
+				n = min(n, typ.Len())
+			}
+			for i := 0; i < n; i++ {
+				elem, err := copyValue(v.Index(i), typ.Elem())
+				if err != nil {
+					return out, err
+				}
+				out.Index(i).Set(elem)
+			}
+		default:
+			return out, fmt.Errorf("cannot convert %v to %v", val, typ)
+		}
+	}
+	return out, nil
+}
+
+func copyValue(v reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	val, err := toValue(v)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return fromValue(val, typ)
+}
+
+func toValue(v reflect.Value) (Value, error) {
+	if v.IsValid() && v.CanInterface() {
+		if val, ok := v.Interface().(Value); ok {
+			return val, nil
+		}
+		if c, ok := lookup(v.Type()); ok && c.encode != nil {
+			x, err := c.encode(v)
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert %v: %w", v.Type(), err)
+			}
+			if x := reflect.ValueOf(x); !x.IsValid() || x.Type() != v.Type() {
+				return toValue(x)
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		return interpreter.Null{}, nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return interpreter.Null{}, nil
+		}
+		return toValue(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			return interpreter.Bool(1), nil
+		}
+		return interpreter.Bool(0), nil
+	case reflect.String:
+		if v.Type() == numberType {
+			n := json.Number(v.String())
+			if i, err := n.Int64(); err == nil && i >= math.MinInt32 && i <= math.MaxInt32 {
+				return interpreter.Int32(i), nil
+			}
+			if f, err := n.Float64(); err == nil {
+				return interpreter.Float64(f), nil
+			}
+			return nil, fmt.Errorf("cannot convert %q to a number", v.String())
+		}
+		return interpreter.String(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := v.Int(); n >= math.MinInt32 && n <= math.MaxInt32 {
+			return interpreter.Int32(n), nil
+		}
+		return interpreter.Float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n := v.Uint(); n <= math.MaxInt32 {
+			return interpreter.Int32(n), nil
+		}
+		return interpreter.Float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return interpreter.Float64(v.Float()), nil
+	case reflect.Pointer:
+		if v.IsNil() {
+			return interpreter.Null{}, nil
+		}
+		if v.Elem().Kind() == reflect.Struct {
+			return &object{value: v}, nil
+		}
+		return toValue(v.Elem())
+	case reflect.Struct:
+		if !v.CanAddr() && v.CanInterface() {
+			ptr := reflect.New(v.Type())
+			ptr.Elem().Set(v)
+			v = ptr.Elem()
+		}
+		return &object{value: v}, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cannot convert %v to a script value", v.Type())
+		}
+		return &object{value: v}, nil
+	case reflect.Slice, reflect.Array:
+		return &array{value: v}, nil
+	case reflect.Func:
+		if v.IsNil() {
+			return interpreter.Null{}, nil
+		}
+		return bind(v.Type().String(), v.Interface())
+	default:
+		return nil, fmt.Errorf("cannot convert %v to a script value", v.Type())
+	}
+}
@@ -0,0 +1,142 @@
+package minijs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/siyul-park/minijs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromGo(t *testing.T) {
+	tests := []struct {
+		value  any
+		expect any
+	}{
+		{value: nil, expect: nil},
+		{value: true, expect: true},
+		{value: 42, expect: int32(42)},
+		{value: int64(1) << 40, expect: float64(int64(1) << 40)},
+		{value: uint8(7), expect: int32(7)},
+		{value: 1.5, expect: 1.5},
+		{value: "hi", expect: "hi"},
+		{value: json.Number("12"), expect: int32(12)},
+		{value: json.Number("1.25"), expect: 1.25},
+		{value: json.Number("1e20"), expect: 1e20},
+		{value: (*int)(nil), expect: nil},
+		{value: []int{1, 2}, expect: []int{1, 2}},
+		{value: map[string]any{"a": 1}, expect: map[string]any{"a": 1}},
+	}
+
+	for _, tt := range tests {
+		val, err := minijs.FromGo(tt.value)
+		assert.NoError(t, err)
+
+		var actual any
+		err = minijs.ToGo(val, &actual)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expect, actual)
+	}
+
+	_, err := minijs.FromGo(make(chan int))
+	assert.Error(t, err)
+
+	_, err = minijs.FromGo(json.Number("x"))
+	assert.Error(t, err)
+
+	_, err = minijs.FromGo(map[int]string{})
+	assert.Error(t, err)
+}
+
+func TestToGo(t *testing.T) {
+	val, err := minijs.FromGo(map[string]any{
+		"name":   "alice",
+		"scores": []any{1, 2.5, json.Number("3")},
+		"nested": map[string]any{"ok": true},
+	})
+	assert.NoError(t, err)
+
+	var m map[string]any
+	assert.NoError(t, minijs.ToGo(val, &m))
+	assert.Equal(t, "alice", m["name"])
+
+	var typed struct{}
+	assert.Error(t, minijs.ToGo(val, &typed))
+
+	scores, err := minijs.FromGo([]any{1, 2.5, json.Number("3")})
+	assert.NoError(t, err)
+
+	var floats []float64
+	assert.NoError(t, minijs.ToGo(scores, &floats))
+	assert.Equal(t, []float64{1, 2.5, 3}, floats)
+
+	var fixed [2]int
+	assert.Error(t, minijs.ToGo(scores, &fixed))
+
+	nested, err := minijs.FromGo(map[string]map[string]int{"a": {"b": 1}})
+	assert.NoError(t, err)
+
+	var generic map[string]map[string]float64
+	assert.NoError(t, minijs.ToGo(nested, &generic))
+	assert.Equal(t, map[string]map[string]float64{"a": {"b": 1}}, generic)
+
+	num, err := minijs.FromGo(3)
+	assert.NoError(t, err)
+
+	var n json.Number
+	assert.NoError(t, minijs.ToGo(num, &n))
+	assert.Equal(t, json.Number("3"), n)
+
+	var s string
+	assert.NoError(t, minijs.ToGo(num, &s))
+	assert.Equal(t, "3", s)
+
+	var v minijs.Value
+	assert.NoError(t, minijs.ToGo(num, &v))
+	assert.Equal(t, num, v)
+
+	assert.Error(t, minijs.ToGo(num, s))
+	assert.Error(t, minijs.ToGo(num, nil))
+}
+
+func TestRegisterConverter(t *testing.T) {
+	minijs.RegisterConverter(
+		func(t time.Time) (any, error) {
+			return t.Format(time.RFC3339), nil
+		},
+		func(v any) (time.Time, error) {
+			s, ok := v.(string)
+			if !ok {
+				return time.Time{}, errors.New("expected a string")
+			}
+			return time.Parse(time.RFC3339, s)
+		},
+	)
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	val, err := minijs.FromGo(at)
+	assert.NoError(t, err)
+
+	var s string
+	assert.NoError(t, minijs.ToGo(val, &s))
+	assert.Equal(t, "2024-01-02T03:04:05Z", s)
+
+	var actual time.Time
+	assert.NoError(t, minijs.ToGo(val, &actual))
+	assert.True(t, at.Equal(actual))
+
+	vm := minijs.New()
+	assert.NoError(t, vm.Set("now", func() time.Time { return at }))
+	assert.NoError(t, vm.Set("year", func(t time.Time) int { return t.Year() }))
+
+	year, err := vm.Eval(`year(now())`)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2024), year)
+
+	_, err = vm.Eval(`year(1)`)
+	assert.ErrorContains(t, err, "expected a string")
+}
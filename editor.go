@@ -0,0 +1,175 @@
+package minijs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// editor reads lines from a terminal in raw mode, with history on the up and
+// down arrows, tab completion, and highlighting of the input as it is typed.
+type editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	prompt   string
+	history  func() []string
+	complete func(prefix string) []string
+	color    bool
+}
+
+const (
+	keyCtrlA     = 1
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyBackspace = 8
+	keyTab       = 9
+	keyEnter     = 13
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+func (e *editor) readLine() (string, error) {
+	var line []rune
+	cursor := 0
+	history := e.history()
+	entry := len(history)
+
+	e.redraw(line, cursor)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case keyEnter, '\n':
+			_, err := fmt.Fprint(e.out, "\r\n")
+			return string(line), err
+		case keyCtrlC:
+			if _, err := fmt.Fprint(e.out, "^C\r\n"); err != nil {
+				return "", err
+			}
+			line, cursor, entry = nil, 0, len(history)
+		case keyCtrlD:
+			if len(line) == 0 {
+				_, _ = fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+		case keyCtrlA:
+			cursor = 0
+		case keyCtrlE:
+			cursor = len(line)
+		case keyBackspace, keyDelete:
+			if cursor > 0 {
+				line = append(line[:cursor-1], line[cursor:]...)
+				cursor--
+			}
+		case keyTab:
+			var err error
+			if line, cursor, err = e.completion(line, cursor); err != nil {
+				return "", err
+			}
+		case keyEscape:
+			seq, err := e.escape()
+			if err != nil {
+				return "", err
+			}
+			switch seq {
+			case "[A":
+				if entry > 0 {
+					entry--
+					line = []rune(history[entry])
+					cursor = len(line)
+				}
+			case "[B":
+				if entry < len(history) {
+					entry++
+					line = nil
+					if entry < len(history) {
+						line = []rune(history[entry])
+					}
+					cursor = len(line)
+				}
+			case "[C":
+				cursor = min(cursor+1, len(line))
+			case "[D":
+				cursor = max(cursor-1, 0)
+			case "[H":
+				cursor = 0
+			case "[F":
+				cursor = len(line)
+			}
+		default:
+			if unicode.IsPrint(r) {
+				line = append(line[:cursor], append([]rune{r}, line[cursor:]...)...)
+				cursor++
+			}
+		}
+		if err := e.redraw(line, cursor); err != nil {
+			return "", err
+		}
+	}
+}
+
+// escape reads the rest of an escape sequence such as "[A" for the up arrow.
+func (e *editor) escape() (string, error) {
+	var seq strings.Builder
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		seq.WriteRune(r)
+		if seq.Len() > 1 && (unicode.IsLetter(r) || r == '~') {
+			return seq.String(), nil
+		}
+	}
+}
+
+// completion extends the identifier before the cursor as far as all of its
+// candidates agree, and lists them when that does not extend it.
+func (e *editor) completion(line []rune, cursor int) ([]rune, int, error) {
+	start := cursor
+	for start > 0 && (unicode.IsLetter(line[start-1]) || unicode.IsDigit(line[start-1]) || line[start-1] == '_' || line[start-1] == '$') {
+		start--
+	}
+	prefix := string(line[start:cursor])
+	candidates := e.complete(prefix)
+	if len(candidates) == 0 {
+		return line, cursor, nil
+	}
+
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if len(candidates) == 1 || len(common) > len(prefix) {
+		insert := []rune(common[len(prefix):])
+		line = append(line[:cursor], append(insert, line[cursor:]...)...)
+		return line, cursor + len(insert), nil
+	}
+
+	_, err := fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	return line, cursor, err
+}
+
+func (e *editor) redraw(line []rune, cursor int) error {
+	text := string(line)
+	if e.color {
+		text = Highlight(text)
+	}
+	if _, err := fmt.Fprintf(e.out, "\r\x1b[K%s%s", e.prompt, text); err != nil {
+		return err
+	}
+	if back := len(line) - cursor; back > 0 {
+		if _, err := fmt.Fprintf(e.out, "\x1b[%dD", back); err != nil {
+			return err
+		}
+	}
+	return nil
+}
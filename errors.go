@@ -0,0 +1,224 @@
+package minijs
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/siyul-park/minijs/internal/ast"
+	"github.com/siyul-park/minijs/internal/compiler"
+	"github.com/siyul-park/minijs/internal/interpreter"
+	"github.com/siyul-park/minijs/internal/parser"
+)
+
+// Kind is the category of a failure.
+type Kind string
+
+const (
+	// KindSyntax is source that does not parse.
+	KindSyntax Kind = "syntax"
+	// KindCompile is a program that parses but cannot be compiled.
+	KindCompile Kind = "compile"
+	// KindModule is a module that cannot be resolved or loaded, or that
+	// requires itself.
+	KindModule Kind = "module"
+	// KindType is an operation on a value of the wrong type, such as calling
+	// something that is not a function or reading a property of null.
+	KindType Kind = "type"
+	// KindLimit is a value larger than the policy allows, calls nested too
+	// deeply, or a run that executes more instructions than allowed.
+	KindLimit Kind = "limit"
+	// KindHost is an error returned or a panic raised by a Go function.
+	KindHost Kind = "host"
+	// KindInternal is any other failure of the interpreter.
+	KindInternal Kind = "internal"
+)
+
+// Position is a place in a script. Line and Column start at 1 and are 0 when
+// unknown.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// SyntaxError reports source that does not parse.
+type SyntaxError struct {
+	Kind     Kind
+	Message  string
+	Position Position
+	err      error
+}
+
+// CompileError reports a program that cannot be compiled or a module that
+// cannot be loaded.
+type CompileError struct {
+	Kind     Kind
+	Message  string
+	Position Position
+	err      error
+}
+
+// RuntimeError reports a script that failed while running. Stack lists the
+// script functions that were active, innermost first, ending with the
+// top-level code.
+type RuntimeError struct {
+	Kind     Kind
+	Message  string
+	Position Position
+	Stack    []StackFrame
+	err      error
+}
+
+// StackFrame is a call in the stack of a RuntimeError. Function is empty for
+// the top-level code.
+type StackFrame struct {
+	Function string
+	Position Position
+}
+
+// hostError is an error returned or a panic raised by a function bound with
+// Set.
+type hostError struct {
+	name string
+	err  error
+}
+
+// Errors that RuntimeErrors of KindType and KindLimit wrap.
+var (
+	ErrTypeMismatch  = interpreter.ErrTypeMismatch
+	ErrLimitExceeded = interpreter.ErrLimitExceeded
+)
+
+func (e *SyntaxError) Error() string {
+	return e.err.Error()
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is a *SyntaxError with the same Kind or no Kind.
+func (e *SyntaxError) Is(target error) bool {
+	t, ok := target.(*SyntaxError)
+	return ok && (t.Kind == "" || t.Kind == e.Kind)
+}
+
+func (e *CompileError) Error() string {
+	return e.err.Error()
+}
+
+func (e *CompileError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is a *CompileError with the same Kind or no Kind.
+func (e *CompileError) Is(target error) bool {
+	t, ok := target.(*CompileError)
+	return ok && (t.Kind == "" || t.Kind == e.Kind)
+}
+
+func (e *RuntimeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is a *RuntimeError with the same Kind or no Kind.
+func (e *RuntimeError) Is(target error) bool {
+	t, ok := target.(*RuntimeError)
+	return ok && (t.Kind == "" || t.Kind == e.Kind)
+}
+
+func (e *hostError) Error() string {
+	return fmt.Sprintf("%s: %v", e.name, e.err)
+}
+
+func (e *hostError) Unwrap() error {
+	return e.err
+}
+
+// syntaxError converts an error from the parser to a *SyntaxError.
+func syntaxError(err error) error {
+	var e *parser.SyntaxError
+	if !errors.As(err, &e) {
+		return err
+	}
+	return &SyntaxError{
+		Kind:     KindSyntax,
+		Message:  e.Message,
+		Position: Position{Line: e.Pos.Line, Column: e.Pos.Column},
+		err:      err,
+	}
+}
+
+// compileError converts an error from the compiler to a *CompileError, or to
+// several joined together if the compiler reported more than one.
+func compileError(err error) error {
+	var errs compiler.Errors
+	if errors.As(err, &errs) {
+		joined := make([]error, len(errs))
+		for i, e := range errs {
+			joined[i] = compileError(e)
+		}
+		return errors.Join(joined...)
+	}
+
+	var e *compiler.Error
+	if !errors.As(err, &e) {
+		return &CompileError{Kind: KindCompile, Message: err.Error(), err: err}
+	}
+	var pos Position
+	if e.Node != nil {
+		pos = position(e.Node)
+	}
+	return &CompileError{Kind: KindCompile, Message: e.Err.Error(), Position: pos, err: err}
+}
+
+// compileErrorf returns a *CompileError of kind about node, which may be nil.
+func compileErrorf(kind Kind, node ast.Node, format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	if node == nil || !node.Pos().IsValid() {
+		return &CompileError{Kind: kind, Message: msg, err: errors.New(msg)}
+	}
+	return &CompileError{
+		Kind:     kind,
+		Message:  msg,
+		Position: position(node),
+		err:      fmt.Errorf("%s: %s", node.Pos(), msg),
+	}
+}
+
+// runtimeError converts an error from the interpreter to a *RuntimeError.
+func runtimeError(err error) error {
+	var e *interpreter.Error
+	if !errors.As(err, &e) {
+		return err
+	}
+
+	kind := KindInternal
+	var host *hostError
+	switch {
+	case errors.As(e.Err, &host):
+		kind = KindHost
+	case errors.Is(e.Err, interpreter.ErrLimitExceeded):
+		kind = KindLimit
+	case errors.Is(e.Err, interpreter.ErrTypeMismatch):
+		kind = KindType
+	}
+
+	stack := make([]StackFrame, len(e.Stack))
+	for i, frame := range e.Stack {
+		stack[i] = StackFrame{Function: frame.Function, Position: Position{Line: frame.Line}}
+	}
+	var pos Position
+	if len(stack) > 0 {
+		pos = stack[0].Position
+	}
+	return &RuntimeError{Kind: kind, Message: e.Err.Error(), Position: pos, Stack: stack, err: err}
+}
+
+func position(node ast.Node) Position {
+	pos := node.Pos()
+	return Position{Line: pos.Line, Column: pos.Column}
+}
@@ -0,0 +1,126 @@
+package minijs_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/siyul-park/minijs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyntaxError(t *testing.T) {
+	_, err := minijs.Eval("var a = 1;\nvar = 2;")
+
+	var e *minijs.SyntaxError
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, minijs.KindSyntax, e.Kind)
+	assert.Equal(t, 2, e.Position.Line)
+	assert.NotEmpty(t, e.Message)
+	assert.ErrorIs(t, err, &minijs.SyntaxError{})
+	assert.NotErrorIs(t, err, &minijs.CompileError{})
+}
+
+func TestCompileError(t *testing.T) {
+	vm := minijs.New()
+	vm.SetResolver(minijs.NewFSResolver(fstest.MapFS{
+		"a.js": {Data: []byte(`var b = require("./b")`)},
+		"b.js": {Data: []byte(`var a = require("./a")`)},
+	}))
+
+	tests := []struct {
+		source string
+		kind   minijs.Kind
+		line   int
+	}{
+		{source: "\nrequire(name)", kind: minijs.KindCompile, line: 2},
+		{source: `require("./missing")`, kind: minijs.KindModule},
+		{source: `require("./a")`, kind: minijs.KindModule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := vm.Eval(tt.source)
+
+			var e *minijs.CompileError
+			assert.ErrorAs(t, err, &e)
+			assert.Equal(t, tt.kind, e.Kind)
+			assert.Equal(t, tt.line, e.Position.Line)
+			assert.ErrorIs(t, err, &minijs.CompileError{Kind: tt.kind})
+		})
+	}
+}
+
+func TestRuntimeError(t *testing.T) {
+	errHost := errors.New("host")
+
+	vm := minijs.New()
+	assert.NoError(t, vm.Set("fail", func() error { return errHost }))
+	assert.NoError(t, vm.Set("crash", func() { panic("boom") }))
+	assert.NoError(t, vm.SetGlobal("long", "abcdef"))
+	vm.SetPolicy(&minijs.Policy{Globals: []string{"long"}, Hosts: []string{"fail", "crash"}, MaxStringLength: 8})
+
+	tests := []struct {
+		source string
+		kind   minijs.Kind
+		err    error
+		stack  []minijs.StackFrame
+	}{
+		{
+			source: "function outer() {\n  return inner()\n}\nfunction inner() {\n  return undefined()\n}\nouter()",
+			kind:   minijs.KindType,
+			err:    minijs.ErrTypeMismatch,
+			stack: []minijs.StackFrame{
+				{Function: "inner", Position: minijs.Position{Line: 5}},
+				{Function: "outer", Position: minijs.Position{Line: 2}},
+				{Position: minijs.Position{Line: 7}},
+			},
+		},
+		{
+			source: "fail()",
+			kind:   minijs.KindHost,
+			err:    errHost,
+			stack:  []minijs.StackFrame{{Position: minijs.Position{Line: 1}}},
+		},
+		{
+			source: "crash()",
+			kind:   minijs.KindHost,
+			stack:  []minijs.StackFrame{{Position: minijs.Position{Line: 1}}},
+		},
+		{
+			source: "long + long",
+			kind:   minijs.KindLimit,
+			err:    minijs.ErrLimitExceeded,
+			stack:  []minijs.StackFrame{{Position: minijs.Position{Line: 1}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := vm.Eval(tt.source)
+
+			var e *minijs.RuntimeError
+			assert.ErrorAs(t, err, &e)
+			assert.Equal(t, tt.kind, e.Kind)
+			assert.Equal(t, tt.stack, e.Stack)
+			assert.Equal(t, tt.stack[0].Position, e.Position)
+			assert.ErrorIs(t, err, &minijs.RuntimeError{Kind: tt.kind})
+			assert.ErrorIs(t, err, &minijs.RuntimeError{})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRuntimeError_Recursion(t *testing.T) {
+	_, err := minijs.Eval("function f() { return f() }\nf()")
+
+	var e *minijs.RuntimeError
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, minijs.KindLimit, e.Kind)
+	assert.ErrorIs(t, err, minijs.ErrLimitExceeded)
+	assert.Equal(t, minijs.StackFrame{Function: "f", Position: minijs.Position{Line: 1}}, e.Stack[0])
+	assert.Equal(t, minijs.StackFrame{Position: minijs.Position{Line: 2}}, e.Stack[len(e.Stack)-1])
+}
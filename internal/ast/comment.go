@@ -0,0 +1,28 @@
+package ast
+
+import "github.com/siyul-park/minijs/internal/token"
+
+type Comment struct {
+	Token token.Token
+}
+
+type Comments struct {
+	Leading  []*Comment
+	Trailing []*Comment
+}
+
+func NewComment(tok token.Token) *Comment {
+	return &Comment{Token: tok}
+}
+
+func (n *Comment) Pos() token.Pos {
+	return n.Token.Start
+}
+
+func (n *Comment) End() token.Pos {
+	return n.Token.End
+}
+
+func (n *Comment) String() string {
+	return n.Token.Literal
+}
@@ -0,0 +1,114 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/siyul-park/minijs/internal/token"
+)
+
+var (
+	posType   = reflect.TypeOf(token.Pos{})
+	tokenType = reflect.TypeOf(token.Token{})
+)
+
+// Equal reports whether a and b have the same structure, ignoring source
+// positions, comments, and trivia.
+func Equal(a, b Node) bool {
+	return Diff(a, b) == ""
+}
+
+// Diff describes every subtree where a and b differ, one entry per path, or
+// returns "" if they are equal. Positions, comments, and trivia are ignored.
+func Diff(a, b Node) string {
+	root := reflect.ValueOf(&a).Elem()
+	if isNil(a) {
+		root = reflect.ValueOf(&b).Elem()
+	}
+
+	var out strings.Builder
+	compare(&out, name(root), reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+	return out.String()
+}
+
+func compare(out *strings.Builder, path string, a, b reflect.Value) {
+	switch {
+	case a.Type().Implements(nodeType):
+		if isNil(a.Interface()) || isNil(b.Interface()) || a.Elem().Type() != b.Elem().Type() {
+			if !isNil(a.Interface()) || !isNil(b.Interface()) {
+				report(out, path, a, b)
+			}
+			return
+		}
+		if a.Kind() == reflect.Interface {
+			a, b = a.Elem(), b.Elem()
+		}
+		a, b = a.Elem(), b.Elem()
+		for i := 0; i < a.NumField(); i++ {
+			field := a.Type().Field(i)
+			if !field.IsExported() || field.Type == posType || field.Type.Kind() == reflect.Map {
+				continue
+			}
+			compare(out, path+"."+field.Name, a.Field(i), b.Field(i))
+		}
+	case a.Type() == tokenType:
+		x, y := a.Interface().(token.Token), b.Interface().(token.Token)
+		if x.Type != y.Type || x.Literal != y.Literal {
+			report(out, path, a, b)
+		}
+	case a.Kind() == reflect.Slice:
+		if a.Len() != b.Len() {
+			report(out, path, a, b)
+			return
+		}
+		for i := 0; i < a.Len(); i++ {
+			compare(out, fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i))
+		}
+	case a.Kind() == reflect.Float64:
+		x, y := a.Float(), b.Float()
+		if x != y && !(math.IsNaN(x) && math.IsNaN(y)) {
+			report(out, path, a, b)
+		}
+	default:
+		if a.Interface() != b.Interface() {
+			report(out, path, a, b)
+		}
+	}
+}
+
+func report(out *strings.Builder, path string, a, b reflect.Value) {
+	fmt.Fprintf(out, "%s:\n\t- %s\n\t+ %s\n", path, describe(a), describe(b))
+}
+
+func describe(v reflect.Value) string {
+	switch {
+	case v.Type().Implements(nodeType):
+		if isNil(v.Interface()) {
+			return "nil"
+		}
+		return fmt.Sprintf("%s %s", name(v), v.Interface().(Node).String())
+	case v.Kind() == reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = describe(v.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case v.Type() == tokenType:
+		tk := v.Interface().(token.Token)
+		return fmt.Sprintf("%s %q", tk.Type, tk.Literal)
+	default:
+		return fmt.Sprintf("%#v", v.Interface())
+	}
+}
+
+func name(v reflect.Value) string {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "nil"
+		}
+		v = v.Elem()
+	}
+	return v.Type().Name()
+}
@@ -0,0 +1,63 @@
+package ast
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/token"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqual(t *testing.T) {
+	number := func(v float64) *NumberLiteral {
+		return NewNumberLiteral(token.New(token.NUMBER, strconv.FormatFloat(v, 'g', -1, 64)), v)
+	}
+	ident := func(name string) *IdentifierLiteral {
+		return NewIdentifierLiteral(token.New(token.IDENTIFIER, name), name)
+	}
+
+	a := NewProgram(NewExpressionStatement(NewInfixExpression(token.New(token.PLUS, "+"), ident("a"), number(1))))
+	b := NewProgram(NewExpressionStatement(NewInfixExpression(token.New(token.PLUS, "+"), ident("a"), number(1))))
+	b.Statements[0].(*ExpressionStatement).Expression.(*InfixExpression).Token.Start = token.Pos{Line: 1, Column: 3, Offset: 2}
+	b.Comments = map[Node]*Comments{b.Statements[0]: {Leading: []*Comment{NewComment(token.New(token.COMMENT, "// a"))}}}
+
+	assert.True(t, Equal(a, b))
+	assert.True(t, Equal(nil, nil))
+	assert.True(t, Equal(number(math.NaN()), number(math.NaN())))
+	assert.False(t, Equal(a, nil))
+	assert.False(t, Equal(number(1), ident("a")))
+	assert.False(t, Equal(NewProgram(), a))
+}
+
+func TestDiff(t *testing.T) {
+	number := func(v float64) *NumberLiteral {
+		return NewNumberLiteral(token.New(token.NUMBER, strconv.FormatFloat(v, 'g', -1, 64)), v)
+	}
+	ident := func(name string) *IdentifierLiteral {
+		return NewIdentifierLiteral(token.New(token.IDENTIFIER, name), name)
+	}
+
+	a := NewProgram(
+		NewExpressionStatement(NewInfixExpression(token.New(token.PLUS, "+"), ident("a"), number(1))),
+		NewReturnStatement(token.New(token.RETURN, "return"), nil),
+	)
+	b := NewProgram(
+		NewExpressionStatement(NewInfixExpression(token.New(token.MINUS, "-"), ident("a"), ident("b"))),
+		NewReturnStatement(token.New(token.RETURN, "return"), nil),
+	)
+
+	assert.Equal(t, "", Diff(a, a))
+	assert.Equal(t, `Program.Statements[0].Expression.Token:
+	- + "+"
+	+ - "-"
+Program.Statements[0].Expression.Right:
+	- NumberLiteral 1
+	+ IdentifierLiteral b
+`, Diff(a, b))
+	assert.Equal(t, `Program.Statements:
+	- [ExpressionStatement (a+1);, ReturnStatement return;]
+	+ []
+`, Diff(a, NewProgram()))
+}
@@ -27,6 +27,14 @@ func NewPrefixExpression(token token.Token, right Expression) *PrefixExpression
 	return &PrefixExpression{Token: token, Right: right}
 }
 
+func (n *PrefixExpression) Pos() token.Pos {
+	return n.Token.Start
+}
+
+func (n *PrefixExpression) End() token.Pos {
+	return n.Right.End()
+}
+
 func (n *PrefixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -47,6 +55,14 @@ func NewInfixExpression(token token.Token, left, right Expression) *InfixExpress
 	return &InfixExpression{Token: token, Left: left, Right: right}
 }
 
+func (n *InfixExpression) Pos() token.Pos {
+	return n.Left.Pos()
+}
+
+func (n *InfixExpression) End() token.Pos {
+	return n.Right.End()
+}
+
 func (n *InfixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -68,6 +84,14 @@ func NewAssignmentExpression(token token.Token, left Expression, right Expressio
 	return &AssignmentExpression{Token: token, Left: left, Right: right}
 }
 
+func (n *AssignmentExpression) Pos() token.Pos {
+	return n.Left.Pos()
+}
+
+func (n *AssignmentExpression) End() token.Pos {
+	return n.Right.End()
+}
+
 func (n *AssignmentExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString(n.Left.String())
@@ -75,3 +99,99 @@ func (n *AssignmentExpression) String() string {
 	out.WriteString(n.Right.String())
 	return out.String()
 }
+
+type CallExpression struct {
+	expression
+	Token     token.Token
+	Function  Expression
+	Arguments []Expression
+	Rparen    token.Pos
+}
+
+func NewCallExpression(token token.Token, function Expression, arguments ...Expression) *CallExpression {
+	return &CallExpression{Token: token, Function: function, Arguments: arguments}
+}
+
+func (n *CallExpression) Pos() token.Pos {
+	return n.Function.Pos()
+}
+
+func (n *CallExpression) End() token.Pos {
+	if !n.Rparen.IsValid() {
+		return n.Rparen
+	}
+	return token.Pos{Line: n.Rparen.Line, Column: n.Rparen.Column + 1, Offset: n.Rparen.Offset + 1}
+}
+
+func (n *CallExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString(n.Function.String())
+	out.WriteString("(")
+	for i, arg := range n.Arguments {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString(arg.String())
+	}
+	out.WriteString(")")
+	return out.String()
+}
+
+type MemberExpression struct {
+	expression
+	Token    token.Token
+	Object   Expression
+	Property *IdentifierLiteral
+}
+
+func NewMemberExpression(token token.Token, object Expression, property *IdentifierLiteral) *MemberExpression {
+	return &MemberExpression{Token: token, Object: object, Property: property}
+}
+
+func (n *MemberExpression) Pos() token.Pos {
+	return n.Object.Pos()
+}
+
+func (n *MemberExpression) End() token.Pos {
+	return n.Property.End()
+}
+
+func (n *MemberExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString(n.Object.String())
+	out.WriteString(".")
+	out.WriteString(n.Property.String())
+	return out.String()
+}
+
+type IndexExpression struct {
+	expression
+	Token    token.Token
+	Left     Expression
+	Index    Expression
+	Rbracket token.Pos
+}
+
+func NewIndexExpression(token token.Token, left, index Expression) *IndexExpression {
+	return &IndexExpression{Token: token, Left: left, Index: index}
+}
+
+func (n *IndexExpression) Pos() token.Pos {
+	return n.Left.Pos()
+}
+
+func (n *IndexExpression) End() token.Pos {
+	if !n.Rbracket.IsValid() {
+		return n.Rbracket
+	}
+	return token.Pos{Line: n.Rbracket.Line, Column: n.Rbracket.Column + 1, Offset: n.Rbracket.Offset + 1}
+}
+
+func (n *IndexExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString(n.Left.String())
+	out.WriteString("[")
+	out.WriteString(n.Index.String())
+	out.WriteString("]")
+	return out.String()
+}
@@ -0,0 +1,227 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type encoder struct {
+	comments map[Node]*Comments
+}
+
+type decoder struct {
+	comments map[Node]*Comments
+}
+
+var kinds = map[string]reflect.Type{}
+
+var nodeType = reflect.TypeOf((*Node)(nil)).Elem()
+
+func init() {
+	for _, n := range []Node{
+		&Program{},
+		&Comment{},
+		&EmptyStatement{},
+		&BlockStatement{},
+		&ExpressionStatement{},
+		&Directive{},
+		&VariableStatement{},
+		&FunctionDeclaration{},
+		&ReturnStatement{},
+		&PrefixExpression{},
+		&InfixExpression{},
+		&AssignmentExpression{},
+		&CallExpression{},
+		&MemberExpression{},
+		&IndexExpression{},
+		&NullLiteral{},
+		&UndefinedLiteral{},
+		&BoolLiteral{},
+		&NumberLiteral{},
+		&StringLiteral{},
+		&IdentifierLiteral{},
+		&FunctionLiteral{},
+	} {
+		typ := reflect.TypeOf(n).Elem()
+		kinds[typ.Name()] = typ
+	}
+}
+
+func Marshal(node Node) ([]byte, error) {
+	e := &encoder{}
+	if p, ok := node.(*Program); ok {
+		e.comments = p.Comments
+	}
+	obj, err := e.encode(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(obj)
+}
+
+func Unmarshal(data []byte) (Node, error) {
+	d := &decoder{comments: map[Node]*Comments{}}
+	node, err := d.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := node.(*Program); ok && len(d.comments) > 0 {
+		p.Comments = d.comments
+	}
+	return node, nil
+}
+
+func (e *encoder) encode(node Node) (any, error) {
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, nil
+	}
+
+	typ := v.Elem().Type()
+	if _, ok := kinds[typ.Name()]; !ok {
+		return nil, fmt.Errorf("unknown node type %s", typ)
+	}
+
+	obj := map[string]any{"type": typ.Name()}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() || (field.Type.Kind() == reflect.Map && field.Type.Key() == nodeType) {
+			continue
+		}
+		val, err := e.value(v.Elem().Field(i))
+		if err != nil {
+			return nil, err
+		}
+		obj[key(field.Name)] = val
+	}
+
+	if group, ok := e.comments[node]; ok {
+		for name, comments := range map[string][]*Comment{"leadingComments": group.Leading, "trailingComments": group.Trailing} {
+			if len(comments) == 0 {
+				continue
+			}
+			val, err := e.value(reflect.ValueOf(comments))
+			if err != nil {
+				return nil, err
+			}
+			obj[name] = val
+		}
+	}
+	return obj, nil
+}
+
+func (e *encoder) value(v reflect.Value) (any, error) {
+	switch {
+	case v.Type().Implements(nodeType):
+		if (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) && v.IsNil() {
+			return nil, nil
+		}
+		return e.encode(v.Interface().(Node))
+	case v.Kind() == reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		items := make([]any, v.Len())
+		for i := range items {
+			item, err := e.value(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return v.Interface(), nil
+	}
+}
+
+func (d *decoder) decode(data []byte) (Node, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, nil
+	}
+
+	var name string
+	if err := json.Unmarshal(obj["type"], &name); err != nil {
+		return nil, fmt.Errorf("missing node type: %w", err)
+	}
+	typ, ok := kinds[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown node type %q", name)
+	}
+
+	v := reflect.New(typ)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		raw, ok := obj[key(field.Name)]
+		if !field.IsExported() || !ok {
+			continue
+		}
+		if err := d.value(raw, v.Elem().Field(i)); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", name, field.Name, err)
+		}
+	}
+
+	node := v.Interface().(Node)
+	for _, name := range []string{"leadingComments", "trailingComments"} {
+		raw, ok := obj[name]
+		if !ok {
+			continue
+		}
+		var comments []*Comment
+		if err := d.value(raw, reflect.ValueOf(&comments).Elem()); err != nil {
+			return nil, err
+		}
+		group, ok := d.comments[node]
+		if !ok {
+			group = &Comments{}
+			d.comments[node] = group
+		}
+		if name == "leadingComments" {
+			group.Leading = comments
+		} else {
+			group.Trailing = comments
+		}
+	}
+	return node, nil
+}
+
+func (d *decoder) value(data json.RawMessage, v reflect.Value) error {
+	switch {
+	case v.Type().Implements(nodeType):
+		node, err := d.decode(data)
+		if err != nil || node == nil {
+			return err
+		}
+		val := reflect.ValueOf(node)
+		if !val.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("unexpected node type %s, expected %s", val.Elem().Type().Name(), v.Type())
+		}
+		v.Set(val)
+		return nil
+	case v.Kind() == reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil || items == nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := d.value(item, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	default:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+}
+
+func key(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
@@ -0,0 +1,104 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/token"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshal(t *testing.T) {
+	node := NewInfixExpression(
+		token.Token{Type: token.PLUS, Literal: "+", Start: token.Pos{Line: 1, Column: 3, Offset: 2}, End: token.Pos{Line: 1, Column: 4, Offset: 3}},
+		NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1", Start: token.Pos{Line: 1, Column: 1}, End: token.Pos{Line: 1, Column: 2, Offset: 1}}, 1),
+		NewIdentifierLiteral(token.Token{Type: token.IDENTIFIER, Literal: "a", Start: token.Pos{Line: 1, Column: 5, Offset: 4}, End: token.Pos{Line: 1, Column: 6, Offset: 5}}, "a"),
+	)
+
+	data, err := Marshal(node)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "InfixExpression",
+		"token": {"type": "+", "literal": "+", "start": {"line": 1, "column": 3, "offset": 2}, "end": {"line": 1, "column": 4, "offset": 3}},
+		"left": {
+			"type": "NumberLiteral",
+			"token": {"type": "NUMBER", "literal": "1", "start": {"line": 1, "column": 1, "offset": 0}, "end": {"line": 1, "column": 2, "offset": 1}},
+			"value": 1
+		},
+		"right": {
+			"type": "IdentifierLiteral",
+			"token": {"type": "IDENTIFIER", "literal": "a", "start": {"line": 1, "column": 5, "offset": 4}, "end": {"line": 1, "column": 6, "offset": 5}},
+			"value": "a"
+		}
+	}`, string(data))
+}
+
+func TestMarshal_Trivia(t *testing.T) {
+	stmt := NewEmptyStatement()
+	node := NewProgram(stmt)
+	node.Trivia = map[Node]*Trivia{stmt: {Leading: []token.Token{token.New(token.WHITESPACE, " ")}}}
+
+	data, err := Marshal(node)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "Program",
+		"statements": [{"type": "EmptyStatement", "semicolon": {"line": 0, "column": 0, "offset": 0}}]
+	}`, string(data))
+}
+
+func TestUnmarshal(t *testing.T) {
+	ret := NewReturnStatement(token.New(token.RETURN, "return"), NewIdentifierLiteral(token.New(token.IDENTIFIER, "a"), "a"))
+	block := NewBlockStatement(ret)
+	block.Lbrace = token.Pos{Line: 1, Column: 16, Offset: 15}
+	block.Rbrace = token.Pos{Line: 3, Column: 1, Offset: 30}
+
+	call := NewCallExpression(token.New(token.OPEN_PAREN, "("), NewIdentifierLiteral(token.New(token.IDENTIFIER, "f"), "f"), NewNumberLiteral(token.New(token.NUMBER, "1"), 1))
+	call.Rparen = token.Pos{Line: 4, Column: 4, Offset: 35}
+
+	program := NewProgram(
+		NewFunctionDeclaration(NewFunctionLiteral(
+			token.New(token.FUNCTION, "function"),
+			NewIdentifierLiteral(token.New(token.IDENTIFIER, "f"), "f"),
+			[]*IdentifierLiteral{NewIdentifierLiteral(token.New(token.IDENTIFIER, "a"), "a")},
+			block,
+		)),
+		NewExpressionStatement(call),
+		NewVariableStatement(token.New(token.VAR, "var"), NewAssignmentExpression(
+			token.New(token.ASSIGN, "="),
+			NewIdentifierLiteral(token.New(token.IDENTIFIER, "b"), "b"),
+			NewPrefixExpression(token.New(token.MINUS, "-"), NewBoolLiteral(token.New(token.TRUE, "true"), true)),
+		)),
+		NewEmptyStatement(),
+	)
+	program.Comments = map[Node]*Comments{
+		ret: {Leading: []*Comment{NewComment(token.New(token.COMMENT, "// a"))}},
+	}
+
+	data, err := Marshal(program)
+	assert.NoError(t, err)
+
+	node, err := Unmarshal(data)
+	assert.NoError(t, err)
+
+	actual := node.(*Program)
+	assert.Equal(t, program.String(), actual.String())
+	assert.Equal(t, program.Statements, actual.Statements)
+
+	body := actual.Statements[0].(*FunctionDeclaration).Function.Body
+	assert.Equal(t, program.Comments[ret], actual.Comments[body.Statements[0]])
+}
+
+func TestUnmarshal_Invalid(t *testing.T) {
+	tests := []string{
+		`{"type": "Unknown"}`,
+		`{"type": "ExpressionStatement", "expression": {"type": "EmptyStatement"}}`,
+		`[]`,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt))
+			assert.Error(t, err)
+		})
+	}
+}
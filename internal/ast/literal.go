@@ -1,6 +1,8 @@
 package ast
 
 import (
+	"strings"
+
 	"github.com/siyul-park/minijs/internal/token"
 )
 
@@ -13,6 +15,14 @@ func NewNullLiteral(tok token.Token) *NullLiteral {
 	return &NullLiteral{Token: tok}
 }
 
+func (n *NullLiteral) Pos() token.Pos {
+	return n.Token.Start
+}
+
+func (n *NullLiteral) End() token.Pos {
+	return n.Token.End
+}
+
 func (n *NullLiteral) String() string {
 	return n.Token.Literal
 }
@@ -26,6 +36,14 @@ func NewUndefinedLiteral(tok token.Token) *UndefinedLiteral {
 	return &UndefinedLiteral{Token: tok}
 }
 
+func (n *UndefinedLiteral) Pos() token.Pos {
+	return n.Token.Start
+}
+
+func (n *UndefinedLiteral) End() token.Pos {
+	return n.Token.End
+}
+
 func (n *UndefinedLiteral) String() string {
 	return n.Token.Literal
 }
@@ -40,6 +58,14 @@ func NewBoolLiteral(tok token.Token, value bool) *BoolLiteral {
 	return &BoolLiteral{Token: tok, Value: value}
 }
 
+func (n *BoolLiteral) Pos() token.Pos {
+	return n.Token.Start
+}
+
+func (n *BoolLiteral) End() token.Pos {
+	return n.Token.End
+}
+
 func (n *BoolLiteral) String() string {
 	return n.Token.Literal
 }
@@ -54,6 +80,14 @@ func NewNumberLiteral(tok token.Token, value float64) *NumberLiteral {
 	return &NumberLiteral{Token: tok, Value: value}
 }
 
+func (n *NumberLiteral) Pos() token.Pos {
+	return n.Token.Start
+}
+
+func (n *NumberLiteral) End() token.Pos {
+	return n.Token.End
+}
+
 func (n *NumberLiteral) String() string {
 	return n.Token.Literal
 }
@@ -68,6 +102,14 @@ func NewStringLiteral(tok token.Token, value string) *StringLiteral {
 	return &StringLiteral{Token: tok, Value: value}
 }
 
+func (n *StringLiteral) Pos() token.Pos {
+	return n.Token.Start
+}
+
+func (n *StringLiteral) End() token.Pos {
+	return n.Token.End
+}
+
 func (n *StringLiteral) String() string {
 	return "\"" + n.Token.Literal + "\""
 }
@@ -82,6 +124,53 @@ func NewIdentifierLiteral(tok token.Token, value string) *IdentifierLiteral {
 	return &IdentifierLiteral{Token: tok, Value: value}
 }
 
+func (n *IdentifierLiteral) Pos() token.Pos {
+	return n.Token.Start
+}
+
+func (n *IdentifierLiteral) End() token.Pos {
+	return n.Token.End
+}
+
 func (n *IdentifierLiteral) String() string {
 	return n.Value
 }
+
+type FunctionLiteral struct {
+	expression
+	Token      token.Token
+	Name       *IdentifierLiteral
+	Parameters []*IdentifierLiteral
+	Body       *BlockStatement
+}
+
+func NewFunctionLiteral(tok token.Token, name *IdentifierLiteral, parameters []*IdentifierLiteral, body *BlockStatement) *FunctionLiteral {
+	return &FunctionLiteral{Token: tok, Name: name, Parameters: parameters, Body: body}
+}
+
+func (n *FunctionLiteral) Pos() token.Pos {
+	return n.Token.Start
+}
+
+func (n *FunctionLiteral) End() token.Pos {
+	return n.Body.End()
+}
+
+func (n *FunctionLiteral) String() string {
+	var out strings.Builder
+	out.WriteString(n.Token.Literal)
+	if n.Name != nil {
+		out.WriteString(" ")
+		out.WriteString(n.Name.String())
+	}
+	out.WriteString("(")
+	for i, param := range n.Parameters {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString(param.String())
+	}
+	out.WriteString(")")
+	out.WriteString(n.Body.String())
+	return out.String()
+}
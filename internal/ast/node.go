@@ -1,5 +1,9 @@
 package ast
 
+import "github.com/siyul-park/minijs/internal/token"
+
 type Node interface {
+	Pos() token.Pos
+	End() token.Pos
 	String() string
 }
@@ -0,0 +1,72 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Fprint writes node to w as an indented tree with one node per line. Each
+// line names the field that holds the node, the type of the node, and its
+// value or operator.
+func Fprint(w io.Writer, node Node) error {
+	return fprint(w, "", node, 0)
+}
+
+func fprint(w io.Writer, field string, node Node, depth int) error {
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+	typ := v.Type()
+
+	line := strings.Repeat("  ", depth)
+	if field != "" {
+		line += field + ": "
+	}
+	line += typ.Name()
+	if val := v.FieldByName("Value"); val.IsValid() && !val.Type().Implements(nodeType) {
+		if val.Kind() == reflect.String {
+			line += fmt.Sprintf(" %q", val.Interface())
+		} else {
+			line += fmt.Sprintf(" %v", val.Interface())
+		}
+	} else if tok := v.FieldByName("Token"); tok.IsValid() {
+		if literal := tok.FieldByName("Literal").String(); literal != "" {
+			line += " " + literal
+		}
+	}
+	if _, err := fmt.Fprintln(w, line); err != nil {
+		return err
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		val := v.Field(i)
+		switch {
+		case f.Type.Implements(nodeType):
+			if val.IsNil() {
+				continue
+			}
+			if err := fprint(w, f.Name, val.Interface().(Node), depth+1); err != nil {
+				return err
+			}
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem().Implements(nodeType):
+			for j := 0; j < val.Len(); j++ {
+				item := val.Index(j)
+				if item.IsNil() {
+					continue
+				}
+				if err := fprint(w, fmt.Sprintf("%s[%d]", f.Name, j), item.Interface().(Node), depth+1); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
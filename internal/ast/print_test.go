@@ -0,0 +1,39 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/token"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFprint(t *testing.T) {
+	node := NewProgram(
+		NewVariableStatement(
+			token.New(token.VAR, "var"),
+			NewAssignmentExpression(
+				token.New(token.ASSIGN, "="),
+				NewIdentifierLiteral(token.New(token.IDENTIFIER, "a"), "a"),
+				NewInfixExpression(
+					token.New(token.PLUS, "+"),
+					NewNumberLiteral(token.New(token.NUMBER, "1"), 1),
+					NewStringLiteral(token.New(token.STRING, `"b"`), "b"),
+				),
+			),
+		),
+	)
+
+	var out strings.Builder
+	err := Fprint(&out, node)
+	assert.NoError(t, err)
+	assert.Equal(t, `Program
+  Statements[0]: VariableStatement var
+    Right[0]: AssignmentExpression =
+      Left: IdentifierLiteral "a"
+      Right: InfixExpression +
+        Left: NumberLiteral 1
+        Right: StringLiteral "b"
+`, out.String())
+}
@@ -1,15 +1,35 @@
 package ast
 
-import "bytes"
+import (
+	"bytes"
+
+	"github.com/siyul-park/minijs/internal/token"
+)
 
 type Program struct {
 	Statements []Statement
+	Comments   map[Node]*Comments
+	Trivia     map[Node]*Trivia
 }
 
 func NewProgram(statements ...Statement) *Program {
 	return &Program{Statements: statements}
 }
 
+func (p *Program) Pos() token.Pos {
+	if len(p.Statements) == 0 {
+		return token.Pos{}
+	}
+	return p.Statements[0].Pos()
+}
+
+func (p *Program) End() token.Pos {
+	if len(p.Statements) == 0 {
+		return token.Pos{}
+	}
+	return p.Statements[len(p.Statements)-1].End()
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 	for _, stmt := range p.Statements {
@@ -0,0 +1,170 @@
+package ast
+
+import (
+	"reflect"
+	"slices"
+)
+
+type Cursor struct {
+	node   Node
+	parent Node
+	name   string
+	index  int
+	set    func(Node)
+	delete func()
+}
+
+type ApplyFunc func(*Cursor) bool
+
+type application struct {
+	pre     ApplyFunc
+	post    ApplyFunc
+	stopped bool
+}
+
+func Apply(root Node, pre, post ApplyFunc) Node {
+	a := &application{pre: pre, post: post}
+	c := &Cursor{
+		node:  root,
+		index: -1,
+		set: func(n Node) {
+			root = n
+		},
+	}
+	c.delete = func() {
+		c.set(nil)
+	}
+	a.apply(c)
+	return root
+}
+
+func (c *Cursor) Node() Node {
+	return c.node
+}
+
+func (c *Cursor) Parent() Node {
+	return c.parent
+}
+
+func (c *Cursor) Name() string {
+	return c.name
+}
+
+func (c *Cursor) Index() int {
+	return c.index
+}
+
+func (c *Cursor) Replace(n Node) {
+	c.set(n)
+	c.node = n
+}
+
+func (c *Cursor) Delete() {
+	c.delete()
+	c.node = nil
+}
+
+func (a *application) apply(c *Cursor) {
+	if a.stopped {
+		return
+	}
+	if a.pre != nil && !a.pre(c) {
+		return
+	}
+
+	switch n := c.node.(type) {
+	case *Program:
+		list(a, n, "Statements", &n.Statements)
+	case *BlockStatement:
+		list(a, n, "Statements", &n.Statements)
+	case *ExpressionStatement:
+		field(a, n, "Expression", &n.Expression)
+	case *Directive:
+		field(a, n, "Value", &n.Value)
+	case *VariableStatement:
+		list(a, n, "Right", &n.Right)
+	case *FunctionDeclaration:
+		field(a, n, "Function", &n.Function)
+	case *ReturnStatement:
+		field(a, n, "Value", &n.Value)
+	case *PrefixExpression:
+		field(a, n, "Right", &n.Right)
+	case *InfixExpression:
+		field(a, n, "Left", &n.Left)
+		field(a, n, "Right", &n.Right)
+	case *AssignmentExpression:
+		field(a, n, "Left", &n.Left)
+		field(a, n, "Right", &n.Right)
+	case *CallExpression:
+		field(a, n, "Function", &n.Function)
+		list(a, n, "Arguments", &n.Arguments)
+	case *MemberExpression:
+		field(a, n, "Object", &n.Object)
+		field(a, n, "Property", &n.Property)
+	case *IndexExpression:
+		field(a, n, "Left", &n.Left)
+		field(a, n, "Index", &n.Index)
+	case *FunctionLiteral:
+		field(a, n, "Name", &n.Name)
+		list(a, n, "Parameters", &n.Parameters)
+		field(a, n, "Body", &n.Body)
+	}
+
+	if a.stopped || c.node == nil {
+		return
+	}
+	if a.post != nil && !a.post(c) {
+		a.stopped = true
+	}
+}
+
+func field[T Node](a *application, parent Node, name string, ptr *T) {
+	if isNil(*ptr) {
+		return
+	}
+	set := func(n Node) {
+		var zero T
+		if n != nil {
+			zero = n.(T)
+		}
+		*ptr = zero
+	}
+	a.apply(&Cursor{
+		node:   *ptr,
+		parent: parent,
+		name:   name,
+		index:  -1,
+		set:    set,
+		delete: func() { set(nil) },
+	})
+}
+
+func list[T Node](a *application, parent Node, name string, ptr *[]T) {
+	for i := 0; i < len(*ptr); {
+		deleted := false
+		a.apply(&Cursor{
+			node:   (*ptr)[i],
+			parent: parent,
+			name:   name,
+			index:  i,
+			set: func(n Node) {
+				(*ptr)[i] = n.(T)
+			},
+			delete: func() {
+				*ptr = slices.Delete(*ptr, i, i+1)
+				deleted = true
+			},
+		})
+		if !deleted {
+			i++
+		}
+	}
+}
+
+func isNil(n any) bool {
+	if n == nil {
+		return true
+	}
+	v := reflect.ValueOf(n)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
@@ -0,0 +1,111 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/token"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	number := func(v float64) *NumberLiteral {
+		return NewNumberLiteral(token.New(token.NUMBER, ""), v)
+	}
+	ident := func(name string) *IdentifierLiteral {
+		return NewIdentifierLiteral(token.New(token.IDENTIFIER, name), name)
+	}
+
+	t.Run("Replace", func(t *testing.T) {
+		program := NewProgram(
+			NewExpressionStatement(NewInfixExpression(token.New(token.PLUS, "+"), number(1), number(2))),
+		)
+
+		result := Apply(program, nil, func(c *Cursor) bool {
+			if n, ok := c.Node().(*InfixExpression); ok {
+				left, lok := n.Left.(*NumberLiteral)
+				right, rok := n.Right.(*NumberLiteral)
+				if lok && rok {
+					c.Replace(number(left.Value + right.Value))
+				}
+			}
+			return true
+		})
+
+		assert.Same(t, program, result)
+		assert.Equal(t, NewExpressionStatement(number(3)), program.Statements[0])
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		program := NewProgram(
+			NewEmptyStatement(),
+			NewExpressionStatement(ident("a")),
+			NewEmptyStatement(),
+			NewEmptyStatement(),
+			NewReturnStatement(token.New(token.RETURN, "return"), ident("b")),
+		)
+
+		Apply(program, func(c *Cursor) bool {
+			switch c.Node().(type) {
+			case *EmptyStatement:
+				c.Delete()
+			case *IdentifierLiteral:
+				if c.Name() == "Value" {
+					c.Delete()
+				}
+			}
+			return true
+		}, nil)
+
+		assert.Equal(t, []Statement{
+			NewExpressionStatement(ident("a")),
+			NewReturnStatement(token.New(token.RETURN, "return"), nil),
+		}, program.Statements)
+	})
+
+	t.Run("Skip", func(t *testing.T) {
+		program := NewProgram(
+			NewFunctionDeclaration(NewFunctionLiteral(token.New(token.FUNCTION, "function"), ident("f"), []*IdentifierLiteral{ident("a")}, NewBlockStatement())),
+			NewExpressionStatement(ident("b")),
+		)
+
+		var names []string
+		Apply(program, func(c *Cursor) bool {
+			if n, ok := c.Node().(*IdentifierLiteral); ok {
+				names = append(names, n.Value)
+			}
+			_, ok := c.Node().(*FunctionLiteral)
+			return !ok
+		}, nil)
+
+		assert.Equal(t, []string{"b"}, names)
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		program := NewProgram(
+			NewExpressionStatement(ident("a")),
+			NewExpressionStatement(ident("b")),
+		)
+
+		var names []string
+		Apply(program, nil, func(c *Cursor) bool {
+			if n, ok := c.Node().(*IdentifierLiteral); ok {
+				names = append(names, n.Value)
+				return false
+			}
+			return true
+		})
+
+		assert.Equal(t, []string{"a"}, names)
+	})
+
+	t.Run("Root", func(t *testing.T) {
+		result := Apply(ident("a"), func(c *Cursor) bool {
+			assert.Nil(t, c.Parent())
+			c.Replace(ident("b"))
+			return true
+		}, nil)
+
+		assert.Equal(t, ident("b"), result)
+	})
+}
@@ -20,18 +20,32 @@ func (statement) _statement() {
 
 type EmptyStatement struct {
 	statement
+	Semicolon token.Pos
 }
 
 func NewEmptyStatement() *EmptyStatement {
 	return &EmptyStatement{}
 }
 
+func (n *EmptyStatement) Pos() token.Pos {
+	return n.Semicolon
+}
+
+func (n *EmptyStatement) End() token.Pos {
+	if !n.Semicolon.IsValid() {
+		return n.Semicolon
+	}
+	return token.Pos{Line: n.Semicolon.Line, Column: n.Semicolon.Column + 1, Offset: n.Semicolon.Offset + 1}
+}
+
 func (n *EmptyStatement) String() string {
 	return ";"
 }
 
 type BlockStatement struct {
 	statement
+	Lbrace     token.Pos
+	Rbrace     token.Pos
 	Statements []Statement
 }
 
@@ -39,6 +53,17 @@ func NewBlockStatement(statements ...Statement) *BlockStatement {
 	return &BlockStatement{Statements: statements}
 }
 
+func (n *BlockStatement) Pos() token.Pos {
+	return n.Lbrace
+}
+
+func (n *BlockStatement) End() token.Pos {
+	if !n.Rbrace.IsValid() {
+		return n.Rbrace
+	}
+	return token.Pos{Line: n.Rbrace.Line, Column: n.Rbrace.Column + 1, Offset: n.Rbrace.Offset + 1}
+}
+
 func (n *BlockStatement) String() string {
 	var out strings.Builder
 	out.WriteString("{\n")
@@ -59,10 +84,39 @@ func NewExpressionStatement(expression Expression) *ExpressionStatement {
 	return &ExpressionStatement{Expression: expression}
 }
 
+func (n *ExpressionStatement) Pos() token.Pos {
+	return n.Expression.Pos()
+}
+
+func (n *ExpressionStatement) End() token.Pos {
+	return n.Expression.End()
+}
+
 func (n *ExpressionStatement) String() string {
 	return n.Expression.String() + ";"
 }
 
+type Directive struct {
+	statement
+	Value *StringLiteral
+}
+
+func NewDirective(value *StringLiteral) *Directive {
+	return &Directive{Value: value}
+}
+
+func (n *Directive) Pos() token.Pos {
+	return n.Value.Pos()
+}
+
+func (n *Directive) End() token.Pos {
+	return n.Value.End()
+}
+
+func (n *Directive) String() string {
+	return n.Value.String() + ";"
+}
+
 type VariableStatement struct {
 	statement
 	Token token.Token
@@ -73,6 +127,17 @@ func NewVariableStatement(token token.Token, right ...*AssignmentExpression) *Va
 	return &VariableStatement{Token: token, Right: right}
 }
 
+func (n *VariableStatement) Pos() token.Pos {
+	return n.Token.Start
+}
+
+func (n *VariableStatement) End() token.Pos {
+	if len(n.Right) == 0 {
+		return n.Token.End
+	}
+	return n.Right[len(n.Right)-1].End()
+}
+
 func (n *VariableStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(n.Token.Literal)
@@ -86,3 +151,52 @@ func (n *VariableStatement) String() string {
 	out.WriteString(";")
 	return out.String()
 }
+
+type FunctionDeclaration struct {
+	statement
+	Function *FunctionLiteral
+}
+
+func NewFunctionDeclaration(function *FunctionLiteral) *FunctionDeclaration {
+	return &FunctionDeclaration{Function: function}
+}
+
+func (n *FunctionDeclaration) Pos() token.Pos {
+	return n.Function.Pos()
+}
+
+func (n *FunctionDeclaration) End() token.Pos {
+	return n.Function.End()
+}
+
+func (n *FunctionDeclaration) String() string {
+	return n.Function.String()
+}
+
+type ReturnStatement struct {
+	statement
+	Token token.Token
+	Value Expression
+}
+
+func NewReturnStatement(token token.Token, value Expression) *ReturnStatement {
+	return &ReturnStatement{Token: token, Value: value}
+}
+
+func (n *ReturnStatement) Pos() token.Pos {
+	return n.Token.Start
+}
+
+func (n *ReturnStatement) End() token.Pos {
+	if n.Value == nil {
+		return n.Token.End
+	}
+	return n.Value.End()
+}
+
+func (n *ReturnStatement) String() string {
+	if n.Value == nil {
+		return n.Token.Literal + ";"
+	}
+	return n.Token.Literal + " " + n.Value.String() + ";"
+}
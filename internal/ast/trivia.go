@@ -0,0 +1,13 @@
+package ast
+
+import "github.com/siyul-park/minijs/internal/token"
+
+// Trivia holds the whitespace and comment tokens around a statement along with
+// the extent of its own tokens, which is wider than Pos and End when the
+// statement is parenthesized or terminated by a semicolon.
+type Trivia struct {
+	Leading  []token.Token
+	Trailing []token.Token
+	Start    token.Pos
+	End      token.Pos
+}
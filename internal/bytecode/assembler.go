@@ -0,0 +1,227 @@
+package bytecode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidAssembly = errors.New("invalid assembly")
+
+type assembler struct {
+	root      *Bytecode
+	code      *Bytecode
+	function  *Function
+	section   string
+	mnemonics map[string]Opcode
+}
+
+func Assemble(r io.Reader) (Bytecode, error) {
+	var root Bytecode
+	a := &assembler{
+		root:      &root,
+		code:      &root,
+		mnemonics: make(map[string]Opcode, len(types)),
+	}
+	each(func(op Opcode, typ *Type) {
+		a.mnemonics[typ.Mnemonic] = op
+	})
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	for line := 1; scanner.Scan(); line++ {
+		if err := a.line(scanner.Text()); err != nil {
+			return Bytecode{}, fmt.Errorf("%w: line %d: %w", ErrInvalidAssembly, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Bytecode{}, err
+	}
+	return root, nil
+}
+
+func (a *assembler) line(text string) error {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return nil
+	}
+	if strings.HasSuffix(trimmed, ":") && (strings.HasPrefix(trimmed, "section ") || strings.HasPrefix(trimmed, ".section ")) {
+		return a.header(strings.TrimSpace(trimmed[strings.Index(trimmed, " ") : len(trimmed)-1]))
+	}
+
+	switch a.section {
+	case ".text":
+		if i := strings.IndexByte(trimmed, ';'); i >= 0 {
+			trimmed = strings.TrimSpace(trimmed[:i])
+		}
+		if trimmed == "" {
+			return nil
+		}
+		return a.instruction(trimmed)
+	case ".data":
+		return a.constant(text)
+	case ".lines":
+		offset, line, err := entry(trimmed)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return err
+		}
+		a.code.Lines = append(a.code.Lines, Line{Offset: offset, Line: n})
+	case ".symbols":
+		index, name, err := entry(trimmed)
+		if err != nil {
+			return err
+		}
+		a.code.Symbols = append(a.code.Symbols, Symbol{Index: index, Name: name})
+	case ".exports":
+		idx, name, err := entry(trimmed)
+		if err != nil {
+			return err
+		}
+		a.code.Exports = append(a.code.Exports, Export{Name: name, Function: idx})
+	case ".functions":
+		return a.attribute(trimmed)
+	default:
+		return fmt.Errorf("unexpected %q outside of a section", trimmed)
+	}
+	return nil
+}
+
+func (a *assembler) header(name string) error {
+	if path, ok := strings.CutPrefix(name, ".functions."); ok {
+		code := a.root
+		indices := strings.Split(path, ".")
+		for i, s := range indices {
+			idx, err := strconv.Atoi(s)
+			if err != nil {
+				return err
+			}
+			if i == len(indices)-1 {
+				if idx != len(code.Functions) {
+					return fmt.Errorf("function %s out of order", path)
+				}
+				code.Functions = append(code.Functions, Function{})
+			}
+			if idx < 0 || idx >= len(code.Functions) {
+				return fmt.Errorf("unknown function %s", path)
+			}
+			a.function = &code.Functions[idx]
+			code = &a.function.Code
+		}
+		a.code = code
+		a.section = ".functions"
+		return nil
+	}
+
+	switch name {
+	case ".text", ".data", ".lines", ".symbols", ".exports":
+		a.section = name
+		return nil
+	default:
+		return fmt.Errorf("unknown section %q", name)
+	}
+}
+
+func (a *assembler) instruction(text string) error {
+	fields := strings.Fields(text)
+	op, ok := a.mnemonics[fields[0]]
+	if !ok {
+		return fmt.Errorf("unknown mnemonic %q", fields[0])
+	}
+	typ := TypeOf(op)
+	if len(fields)-1 != len(typ.Widths) {
+		return fmt.Errorf("%s expects %d operands, got %d", typ.Mnemonic, len(typ.Widths), len(fields)-1)
+	}
+
+	operands := make([]uint64, len(typ.Widths))
+	for i, width := range typ.Widths {
+		operand, err := strconv.ParseUint(fields[i+1], 0, width*8)
+		if err != nil {
+			return err
+		}
+		operands[i] = operand
+	}
+	a.code.Emit(New(op, operands...))
+	return nil
+}
+
+func (a *assembler) constant(text string) error {
+	kind, value, ok := strings.Cut(strings.TrimLeft(text, " \t"), "\t")
+	if !ok {
+		return fmt.Errorf("invalid constant %q", text)
+	}
+
+	switch kind {
+	case STRING.String():
+		var val []byte
+		for i := 0; i < len(value); i++ {
+			if value[i] != '\\' {
+				val = append(val, value[i])
+				continue
+			}
+			if i+3 >= len(value) || value[i+1] != 'x' {
+				return fmt.Errorf("invalid escape in %q", value)
+			}
+			c, err := strconv.ParseUint(value[i+2:i+4], 16, 8)
+			if err != nil {
+				return err
+			}
+			val = append(val, byte(c))
+			i += 3
+		}
+		a.code.Store(String(val))
+	case FLOAT64.String():
+		val, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return err
+		}
+		a.code.Store(Float64(val))
+	default:
+		return fmt.Errorf("unknown constant kind %q", kind)
+	}
+	return nil
+}
+
+func (a *assembler) attribute(text string) error {
+	key, value, ok := strings.Cut(text, "\t")
+	if !ok {
+		return fmt.Errorf("invalid attribute %q", text)
+	}
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "name":
+		a.function.Name = value
+	case "params", "upvalues":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if key == "params" {
+			a.function.Params = n
+		} else {
+			a.function.Upvalues = n
+		}
+	default:
+		return fmt.Errorf("unknown attribute %q", key)
+	}
+	return nil
+}
+
+func entry(text string) (int, string, error) {
+	key, value, ok := strings.Cut(text, "\t")
+	if !ok {
+		return 0, "", fmt.Errorf("invalid entry %q", text)
+	}
+	n, err := strconv.ParseInt(key, 0, 64)
+	if err != nil {
+		return 0, "", err
+	}
+	return int(n), strings.TrimSpace(value), nil
+}
@@ -0,0 +1,79 @@
+package bytecode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssemble(t *testing.T) {
+	tests := []Bytecode{
+		{},
+		{
+			Instructions: append(New(I32LOAD, 1), New(POP)...),
+		},
+		{
+			Instructions: append(New(STRLOAD, 0), New(F64CONST, 3)...),
+			Constants:    []Constant{String("foo"), String(""), String("a\\b\n\xff\x00 "), Float64(1.5), Float64(-0.25)},
+			Lines:        []Line{{Offset: 0, Line: 1}, {Offset: 9, Line: 2}},
+			Symbols:      []Symbol{{Index: 0, Name: "foo"}},
+		},
+		{
+			Instructions: append(New(FUNCLOAD, 0), New(CALL, 0)...),
+			Functions: []Function{
+				{
+					Name:   "foo",
+					Params: 1,
+					Code: Bytecode{
+						Instructions: append(New(FUNCLOAD, 0), New(RET)...),
+						Functions: []Function{
+							{
+								Upvalues: 1,
+								Code: Bytecode{
+									Instructions: append(New(UPVLOAD, 0), New(RET)...),
+								},
+							},
+						},
+					},
+				},
+				{
+					Code: Bytecode{
+						Instructions: append(New(UNDEFLOAD), New(RET)...),
+					},
+				},
+			},
+			Exports: []Export{{Name: "foo", Function: 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.String(), func(t *testing.T) {
+			actual, err := Assemble(strings.NewReader(tt.String()))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.String(), actual.String())
+			assert.Equal(t, tt.Instructions, actual.Instructions)
+			assert.Equal(t, tt.Constants, actual.Constants)
+		})
+	}
+}
+
+func TestAssemble_Error(t *testing.T) {
+	tests := []string{
+		"i32.load 0x01",
+		"section .text:\n\tunknown",
+		"section .text:\n\ti32.load",
+		"section .text:\n\tbool.load 0x100",
+		"section .unknown:",
+		".section .functions.1:",
+		".section .data:\n \tstring\t\\q",
+		".section .data:\n \tint\t1",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			_, err := Assemble(strings.NewReader(tt))
+			assert.ErrorIs(t, err, ErrInvalidAssembly)
+		})
+	}
+}
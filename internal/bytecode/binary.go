@@ -0,0 +1,549 @@
+package bytecode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+const Version = 1
+
+var Magic = [4]byte{'M', 'J', 'S', 'C'}
+
+var (
+	ErrInvalidBytecode    = errors.New("invalid bytecode")
+	ErrInvalidFormat      = errors.New("invalid bytecode format")
+	ErrUnsupportedVersion = errors.New("unsupported bytecode version")
+	ErrChecksumMismatch   = errors.New("checksum mismatch")
+)
+
+const (
+	sectionEnd byte = iota
+	sectionText
+	sectionData
+	sectionFunctions
+	sectionDebug
+	sectionExports
+)
+
+type decoder struct {
+	data []byte
+}
+
+type writer struct {
+	w   io.Writer
+	n   int64
+	err error
+	buf [binary.MaxVarintLen64]byte
+}
+
+// section is an encoded section whose size is known before its payload is
+// written, so the payload streams straight to the output.
+type section struct {
+	id    byte
+	size  int
+	write func(w *writer)
+}
+
+type reader struct {
+	r io.Reader
+	h hash.Hash32
+	n int64
+}
+
+func (b *Bytecode) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := b.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (b *Bytecode) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	if _, err := b.ReadFrom(r); err != nil {
+		return err
+	}
+	if r.Len() > 0 {
+		return fmt.Errorf("%w: trailing data", ErrInvalidFormat)
+	}
+	return nil
+}
+
+func (b *Bytecode) WriteTo(w io.Writer) (int64, error) {
+	h := crc32.NewIEEE()
+	out := &writer{w: io.MultiWriter(w, h)}
+
+	out.write(Magic[:])
+	out.uvarint(Version)
+	for _, s := range sections(b) {
+		out.section(s)
+	}
+	out.byte(sectionEnd)
+
+	out.w = w
+	out.write(binary.BigEndian.AppendUint32(out.buf[:0], h.Sum32()))
+	return out.n, out.err
+}
+
+func (b *Bytecode) ReadFrom(r io.Reader) (int64, error) {
+	in := &reader{r: r, h: crc32.NewIEEE()}
+
+	var magic [len(Magic)]byte
+	if _, err := io.ReadFull(in, magic[:]); err != nil || magic != Magic {
+		return in.n, fmt.Errorf("%w: bad magic number", ErrInvalidFormat)
+	}
+	version, err := binary.ReadUvarint(in)
+	if err != nil {
+		return in.n, unexpected(err)
+	}
+	if version != Version {
+		return in.n, fmt.Errorf("%w: compiled with format v%d, this runtime supports v%d", ErrUnsupportedVersion, version, Version)
+	}
+
+	var code Bytecode
+	for {
+		id, err := in.ReadByte()
+		if err != nil {
+			return in.n, unexpected(err)
+		}
+		if id == sectionEnd {
+			break
+		}
+		size, err := binary.ReadUvarint(in)
+		if err != nil {
+			return in.n, unexpected(err)
+		}
+		payload, err := io.ReadAll(io.LimitReader(in, int64(min(size, math.MaxInt64))))
+		if err != nil {
+			return in.n, err
+		}
+		if uint64(len(payload)) != size {
+			return in.n, unexpected(io.EOF)
+		}
+		if err := code.section(id, payload); err != nil {
+			return in.n, err
+		}
+	}
+
+	checksum := in.h.Sum32()
+	var trailer [4]byte
+	in.h = nil
+	if _, err := io.ReadFull(in, trailer[:]); err != nil {
+		return in.n, unexpected(err)
+	}
+	if binary.BigEndian.Uint32(trailer[:]) != checksum {
+		return in.n, fmt.Errorf("%w: %w", ErrInvalidFormat, ErrChecksumMismatch)
+	}
+
+	*b = code
+	return in.n, nil
+}
+
+func sections(b *Bytecode) []section {
+	var list []section
+	if len(b.Instructions) > 0 {
+		list = append(list, section{
+			id:    sectionText,
+			size:  len(b.Instructions),
+			write: func(w *writer) { w.write(b.Instructions) },
+		})
+	}
+	if len(b.Constants) > 0 {
+		size := uvarintLen(len(b.Constants))
+		for _, val := range b.Constants {
+			size++
+			switch val := val.(type) {
+			case String:
+				size += bytesLen(len(val))
+			case Float64:
+				size += 8
+			}
+		}
+		list = append(list, section{
+			id:   sectionData,
+			size: size,
+			write: func(w *writer) {
+				w.uvarint(len(b.Constants))
+				for _, val := range b.Constants {
+					w.byte(byte(val.Kind()))
+					switch val := val.(type) {
+					case String:
+						w.string(string(val))
+					case Float64:
+						w.write(binary.BigEndian.AppendUint64(w.buf[:0], math.Float64bits(float64(val))))
+					}
+				}
+			},
+		})
+	}
+	if len(b.Functions) > 0 {
+		bodies := make([][]section, len(b.Functions))
+		size := uvarintLen(len(b.Functions))
+		for i := range b.Functions {
+			fn := &b.Functions[i]
+			bodies[i] = sections(&fn.Code)
+			size += bytesLen(len(fn.Name)) + uvarintLen(fn.Params) + uvarintLen(fn.Upvalues) + bytesLen(sectionsLen(bodies[i]))
+		}
+		list = append(list, section{
+			id:   sectionFunctions,
+			size: size,
+			write: func(w *writer) {
+				w.uvarint(len(b.Functions))
+				for i := range b.Functions {
+					fn := &b.Functions[i]
+					w.string(fn.Name)
+					w.uvarint(fn.Params)
+					w.uvarint(fn.Upvalues)
+					w.uvarint(sectionsLen(bodies[i]))
+					for _, s := range bodies[i] {
+						w.section(s)
+					}
+				}
+			},
+		})
+	}
+	if len(b.Exports) > 0 {
+		size := uvarintLen(len(b.Exports))
+		for _, export := range b.Exports {
+			size += bytesLen(len(export.Name)) + uvarintLen(export.Function)
+		}
+		list = append(list, section{
+			id:   sectionExports,
+			size: size,
+			write: func(w *writer) {
+				w.uvarint(len(b.Exports))
+				for _, export := range b.Exports {
+					w.string(export.Name)
+					w.uvarint(export.Function)
+				}
+			},
+		})
+	}
+	if b.debug != nil {
+		list = append(list, section{
+			id:    sectionDebug,
+			size:  len(b.debug.data),
+			write: func(w *writer) { w.write(b.debug.data) },
+		})
+	} else if len(b.Lines) > 0 || len(b.Symbols) > 0 || b.Source != "" || b.SourceHash != ([sha256.Size]byte{}) {
+		size := uvarintLen(len(b.Lines))
+		for _, line := range b.Lines {
+			size += uvarintLen(line.Offset) + uvarintLen(line.Line)
+		}
+		size += uvarintLen(len(b.Symbols))
+		for _, sym := range b.Symbols {
+			size += uvarintLen(sym.Index) + bytesLen(len(sym.Name))
+		}
+		size += sha256.Size + bytesLen(len(b.Source))
+		list = append(list, section{
+			id:   sectionDebug,
+			size: size,
+			write: func(w *writer) {
+				w.uvarint(len(b.Lines))
+				for _, line := range b.Lines {
+					w.uvarint(line.Offset)
+					w.uvarint(line.Line)
+				}
+				w.uvarint(len(b.Symbols))
+				for _, sym := range b.Symbols {
+					w.uvarint(sym.Index)
+					w.string(sym.Name)
+				}
+				w.write(b.SourceHash[:])
+				w.string(b.Source)
+			},
+		})
+	}
+	return list
+}
+
+func sectionsLen(sections []section) int {
+	n := 0
+	for _, s := range sections {
+		n += 1 + uvarintLen(s.size) + s.size
+	}
+	return n
+}
+
+func bytesLen(n int) int {
+	return uvarintLen(n) + n
+}
+
+func uvarintLen(v int) int {
+	var buf [binary.MaxVarintLen64]byte
+	return len(binary.AppendUvarint(buf[:0], uint64(v)))
+}
+
+func (d *decoder) bytecode() (Bytecode, error) {
+	var code Bytecode
+	for len(d.data) > 0 {
+		id := d.data[0]
+		d.data = d.data[1:]
+
+		payload, err := d.bytes()
+		if err != nil {
+			return Bytecode{}, err
+		}
+		if err := code.section(id, payload); err != nil {
+			return Bytecode{}, err
+		}
+	}
+	return code, nil
+}
+
+func (b *Bytecode) section(id byte, payload []byte) error {
+	s := &decoder{data: payload}
+
+	switch id {
+	case sectionText:
+		b.Instructions = bytes.Clone(payload)
+		s.data = nil
+	case sectionData:
+		n, err := s.count(2)
+		if err != nil {
+			return err
+		}
+		b.Constants = make([]Constant, n)
+		for i := range b.Constants {
+			if b.Constants[i], err = s.constant(); err != nil {
+				return err
+			}
+		}
+	case sectionFunctions:
+		n, err := s.count(4)
+		if err != nil {
+			return err
+		}
+		b.Functions = make([]Function, n)
+		for i := range b.Functions {
+			name, err := s.bytes()
+			if err != nil {
+				return err
+			}
+			params, err := s.uvarint()
+			if err != nil {
+				return err
+			}
+			upvalues, err := s.uvarint()
+			if err != nil {
+				return err
+			}
+			body, err := s.bytes()
+			if err != nil {
+				return err
+			}
+			fn, err := (&decoder{data: body}).bytecode()
+			if err != nil {
+				return err
+			}
+			b.Functions[i] = Function{Name: string(name), Params: int(params), Upvalues: int(upvalues), Code: fn}
+		}
+	case sectionExports:
+		n, err := s.count(2)
+		if err != nil {
+			return err
+		}
+		b.Exports = make([]Export, n)
+		for i := range b.Exports {
+			name, err := s.bytes()
+			if err != nil {
+				return err
+			}
+			idx, err := s.uvarint()
+			if err != nil {
+				return err
+			}
+			b.Exports[i] = Export{Name: string(name), Function: int(idx)}
+		}
+	case sectionDebug:
+		b.debug = &lazyDebug{data: bytes.Clone(payload)}
+		s.data = nil
+	default:
+		return nil
+	}
+
+	if len(s.data) > 0 {
+		return fmt.Errorf("%w: trailing data in section %d", ErrInvalidFormat, id)
+	}
+	return nil
+}
+
+func (d *decoder) debug(b *Bytecode) error {
+	n, err := d.count(2)
+	if err != nil {
+		return err
+	}
+	lines := make([]Line, n)
+	for i := range lines {
+		offset, err := d.uvarint()
+		if err != nil {
+			return err
+		}
+		line, err := d.uvarint()
+		if err != nil {
+			return err
+		}
+		lines[i] = Line{Offset: int(offset), Line: int(line)}
+	}
+
+	n, err = d.count(2)
+	if err != nil {
+		return err
+	}
+	symbols := make([]Symbol, n)
+	for i := range symbols {
+		index, err := d.uvarint()
+		if err != nil {
+			return err
+		}
+		name, err := d.bytes()
+		if err != nil {
+			return err
+		}
+		symbols[i] = Symbol{Index: int(index), Name: string(name)}
+	}
+
+	if len(d.data) < sha256.Size {
+		return fmt.Errorf("%w: %w", ErrInvalidFormat, io.ErrUnexpectedEOF)
+	}
+	copy(b.SourceHash[:], d.data)
+	d.data = d.data[sha256.Size:]
+
+	source, err := d.bytes()
+	if err != nil {
+		return err
+	}
+	if len(d.data) > 0 {
+		return fmt.Errorf("%w: trailing data in debug section", ErrInvalidFormat)
+	}
+
+	if len(lines) > 0 {
+		b.Lines = lines
+	}
+	if len(symbols) > 0 {
+		b.Symbols = symbols
+	}
+	b.Source = string(source)
+	return nil
+}
+
+func (d *decoder) constant() (Constant, error) {
+	if len(d.data) == 0 {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, io.ErrUnexpectedEOF)
+	}
+	kind := Kind(d.data[0])
+	d.data = d.data[1:]
+
+	switch kind {
+	case STRING:
+		val, err := d.bytes()
+		if err != nil {
+			return nil, err
+		}
+		return String(val), nil
+	case FLOAT64:
+		if len(d.data) < 8 {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, io.ErrUnexpectedEOF)
+		}
+		val := math.Float64frombits(binary.BigEndian.Uint64(d.data))
+		d.data = d.data[8:]
+		return Float64(val), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown constant kind %d", ErrInvalidFormat, kind)
+	}
+}
+
+func (d *decoder) count(size int) (int, error) {
+	n, err := d.uvarint()
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(d.data)/size) {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidFormat, io.ErrUnexpectedEOF)
+	}
+	return int(n), nil
+}
+
+func (d *decoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidFormat, io.ErrUnexpectedEOF)
+	}
+	d.data = d.data[n:]
+	return v, nil
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.data)) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, io.ErrUnexpectedEOF)
+	}
+	v := d.data[:n]
+	d.data = d.data[n:]
+	return v, nil
+}
+
+func (w *writer) section(s section) {
+	w.byte(s.id)
+	w.uvarint(s.size)
+	s.write(w)
+}
+
+func (w *writer) string(s string) {
+	w.uvarint(len(s))
+	if w.err != nil {
+		return
+	}
+	n, err := io.WriteString(w.w, s)
+	w.n += int64(n)
+	w.err = err
+}
+
+func (w *writer) uvarint(v int) {
+	w.write(binary.AppendUvarint(w.buf[:0], uint64(v)))
+}
+
+func (w *writer) byte(c byte) {
+	w.write(append(w.buf[:0], c))
+}
+
+func (w *writer) write(p []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	w.err = err
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if r.h != nil {
+		_, _ = r.h.Write(p[:n])
+	}
+	return n, err
+}
+
+func (r *reader) ReadByte() (byte, error) {
+	var buf [1]byte
+	_, err := io.ReadFull(r, buf[:])
+	return buf[0], err
+}
+
+func unexpected(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %w", ErrInvalidFormat, io.ErrUnexpectedEOF)
+	}
+	return err
+}
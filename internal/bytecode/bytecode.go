@@ -1,14 +1,61 @@
 package bytecode
 
 import (
+	"crypto/sha256"
 	"fmt"
-	"strings"
-	"unicode"
+	"iter"
+	"sort"
+	"sync"
 )
 
 type Bytecode struct {
 	Instructions []byte
-	Constants    []byte
+	Constants    []Constant
+	Lines        []Line
+	Symbols      []Symbol
+	Functions    []Function
+	Exports      []Export
+	Source       string
+	SourceHash   [sha256.Size]byte
+	debug        *lazyDebug
+}
+
+// lazyDebug is a debug section left encoded until its metadata is first
+// needed, then decoded once for every copy of the Bytecode that shares it.
+type lazyDebug struct {
+	data []byte
+	once sync.Once
+	meta Bytecode
+	err  error
+}
+
+type Function struct {
+	Name     string
+	Params   int
+	Upvalues int
+	Code     Bytecode
+}
+
+type Export struct {
+	Name     string
+	Function int
+}
+
+type DecodedInstruction struct {
+	Offset   int
+	Opcode   Opcode
+	Mnemonic string
+	Operands []uint64
+}
+
+type Line struct {
+	Offset int
+	Line   int
+}
+
+type Symbol struct {
+	Index int
+	Name  string
 }
 
 func (b *Bytecode) Emit(instructions ...Instruction) int {
@@ -23,46 +70,170 @@ func (b *Bytecode) Fetch(offset int) (Instruction, int) {
 	if offset >= len(b.Instructions) {
 		return nil, 0
 	}
-	typ := TypeOf(Opcode(b.Instructions[offset]))
-	if typ == nil {
-		return nil, 0
-	}
-	width := typ.Width()
-	return b.Instructions[offset : offset+width], width
+	return Decode(b.Instructions[offset:])
 }
 
-func (b *Bytecode) Store(constants []byte) int {
-	offset := len(b.Constants)
-	b.Constants = append(b.Constants, constants...)
-	return offset
+func (b *Bytecode) Lookup(name string) (int, bool) {
+	for _, export := range b.Exports {
+		if export.Name == name {
+			return export.Function, true
+		}
+	}
+	return 0, false
 }
 
-func (b *Bytecode) String() string {
-	var out strings.Builder
-
-	out.WriteString("section .text:\n")
-	offset := 0
-	for offset < len(b.Instructions) {
-		bytecode, read := b.Fetch(offset)
-		if read == 0 {
-			break
+func (b *Bytecode) Iter() iter.Seq2[int, Instruction] {
+	return func(yield func(int, Instruction) bool) {
+		for offset := 0; offset < len(b.Instructions); {
+			inst, size := b.Fetch(offset)
+			if size == 0 || !yield(offset, inst) {
+				return
+			}
+			offset += size
 		}
-		fmt.Fprintf(&out, "\t%s\n", bytecode.String())
-		offset += read
 	}
+}
+
+func (b *Bytecode) Disassemble() []DecodedInstruction {
+	var decoded []DecodedInstruction
+	for offset, inst := range b.Iter() {
+		decoded = append(decoded, DecodedInstruction{
+			Offset:   offset,
+			Opcode:   inst.Opcode(),
+			Mnemonic: inst.Type().Mnemonic,
+			Operands: inst.Operands(),
+		})
+	}
+	return decoded
+}
+
+func (b *Bytecode) Validate() error {
+	for offset := 0; offset < len(b.Instructions); {
+		typ := TypeOf(Opcode(b.Instructions[offset]))
+		if typ == nil {
+			return fmt.Errorf("%w: unknown opcode 0x%02X at offset %d", ErrInvalidBytecode, b.Instructions[offset], offset)
+		}
+		inst, width := b.Fetch(offset)
+		if inst == nil {
+			return fmt.Errorf("%w: truncated %s at offset %d", ErrInvalidBytecode, typ.Mnemonic, offset)
+		}
 
-	out.WriteString("\n.section .data:\n")
-	for i := 0; i < len(b.Constants); i++ {
-		fmt.Fprint(&out, " \t")
-		for ; b.Constants[i] != 0 && i < len(b.Constants); i++ {
-			if unicode.IsPrint(rune(b.Constants[i])) {
-				fmt.Fprintf(&out, "%c", rune(b.Constants[i]))
-			} else {
-				fmt.Fprintf(&out, "0x%X", b.Constants[i])
+		switch inst.Opcode() {
+		case F64CONST, STRLOAD, CALLHOST:
+			kind := FLOAT64
+			if inst.Opcode() != F64CONST {
+				kind = STRING
+			}
+			idx := inst.Operands()[0]
+			if idx >= uint64(len(b.Constants)) {
+				return fmt.Errorf("%w: constant out of range in %s at offset %d", ErrInvalidBytecode, inst.String(), offset)
+			}
+			if b.Constants[idx].Kind() != kind {
+				return fmt.Errorf("%w: expected %s constant in %s at offset %d", ErrInvalidBytecode, kind, inst.String(), offset)
 			}
+		case FUNCLOAD, CLOSURE:
+			operands := inst.Operands()
+			if operands[0] >= uint64(len(b.Functions)) {
+				return fmt.Errorf("%w: function out of range in %s at offset %d", ErrInvalidBytecode, inst.String(), offset)
+			}
+			upvalues := uint64(0)
+			if inst.Opcode() == CLOSURE {
+				upvalues = operands[1]
+			}
+			if uint64(b.Functions[operands[0]].Upvalues) != upvalues {
+				return fmt.Errorf("%w: upvalue count mismatch in %s at offset %d", ErrInvalidBytecode, inst.String(), offset)
+			}
+		default:
 		}
-		out.WriteString("\n")
+		offset += width
 	}
 
-	return out.String()
+	for _, export := range b.Exports {
+		if export.Function < 0 || export.Function >= len(b.Functions) {
+			return fmt.Errorf("%w: export %s out of range", ErrInvalidBytecode, export.Name)
+		}
+		if b.Functions[export.Function].Upvalues > 0 {
+			return fmt.Errorf("%w: export %s captures upvalues", ErrInvalidBytecode, export.Name)
+		}
+	}
+	for i := range b.Functions {
+		if err := b.Functions[i].Code.Validate(); err != nil {
+			return fmt.Errorf("function %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (b *Bytecode) Mark(line int) {
+	if line <= 0 {
+		return
+	}
+	offset := len(b.Instructions)
+	if n := len(b.Lines); n > 0 {
+		if b.Lines[n-1].Line == line {
+			return
+		}
+		if b.Lines[n-1].Offset == offset {
+			b.Lines[n-1].Line = line
+			return
+		}
+	}
+	b.Lines = append(b.Lines, Line{Offset: offset, Line: line})
+}
+
+func (b *Bytecode) Line(offset int) int {
+	lines := b.metadata().Lines
+	i := sort.Search(len(lines), func(i int) bool {
+		return lines[i].Offset > offset
+	})
+	if i == 0 {
+		return 0
+	}
+	return lines[i-1].Line
+}
+
+func (b *Bytecode) LoadDebug() error {
+	if b.debug != nil {
+		meta, err := b.debug.decode()
+		if err != nil {
+			return err
+		}
+		b.Lines, b.Symbols = meta.Lines, meta.Symbols
+		b.Source, b.SourceHash = meta.Source, meta.SourceHash
+		b.debug = nil
+	}
+	for i := range b.Functions {
+		if err := b.Functions[i].Code.LoadDebug(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bytecode) metadata() *Bytecode {
+	if b.debug == nil {
+		return b
+	}
+	meta, err := b.debug.decode()
+	if err != nil {
+		return &Bytecode{}
+	}
+	return meta
+}
+
+func (d *lazyDebug) decode() (*Bytecode, error) {
+	d.once.Do(func() {
+		d.err = (&decoder{data: d.data}).debug(&d.meta)
+	})
+	return &d.meta, d.err
+}
+
+func (b *Bytecode) Store(val Constant) int {
+	idx := len(b.Constants)
+	b.Constants = append(b.Constants, val)
+	return idx
+}
+
+func (b *Bytecode) String() string {
+	return b.Format()
 }
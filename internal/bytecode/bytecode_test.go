@@ -0,0 +1,277 @@
+package bytecode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytecode_Disassemble(t *testing.T) {
+	var code Bytecode
+	code.Emit(New(I32LOAD, 1))
+	code.Emit(New(STRLOAD, 0))
+	code.Emit(New(POP))
+
+	assert.Equal(t, []DecodedInstruction{
+		{Offset: 0, Opcode: I32LOAD, Mnemonic: "i32.load", Operands: []uint64{1}},
+		{Offset: 5, Opcode: STRLOAD, Mnemonic: "str.load", Operands: []uint64{0}},
+		{Offset: 7, Opcode: POP, Mnemonic: "pop", Operands: []uint64{}},
+	}, code.Disassemble())
+}
+
+func TestBytecode_Iter(t *testing.T) {
+	var code Bytecode
+	code.Emit(New(I32LOAD, 1))
+	code.Emit(New(SLTSTORE, 0))
+	code.Emit(New(POP))
+
+	var offsets []int
+	var instructions []Instruction
+	for offset, inst := range code.Iter() {
+		offsets = append(offsets, offset)
+		instructions = append(instructions, inst)
+	}
+	assert.Equal(t, []int{0, 5, 7}, offsets)
+	assert.Equal(t, []Instruction{New(I32LOAD, 1), New(SLTSTORE, 0), New(POP)}, instructions)
+
+	for offset := range code.Iter() {
+		assert.Equal(t, 0, offset)
+		break
+	}
+}
+
+func TestBytecode_Validate(t *testing.T) {
+	tests := []struct {
+		code Bytecode
+		err  error
+	}{
+		{
+			code: Bytecode{Instructions: append(New(STRLOAD, 0), New(POP)...), Constants: []Constant{String("foo")}},
+		},
+		{
+			code: Bytecode{Instructions: []byte{0xFF}},
+			err:  ErrInvalidBytecode,
+		},
+		{
+			code: Bytecode{Instructions: New(I32LOAD, 1)[:3]},
+			err:  ErrInvalidBytecode,
+		},
+		{
+			code: Bytecode{Instructions: New(STRLOAD, 1), Constants: []Constant{String("foo")}},
+			err:  ErrInvalidBytecode,
+		},
+		{
+			code: Bytecode{Instructions: New(F64CONST, 0), Constants: []Constant{String("foo")}},
+			err:  ErrInvalidBytecode,
+		},
+		{
+			code: Bytecode{Instructions: New(CLOSURE, 0, 0), Functions: []Function{{Upvalues: 1}}},
+			err:  ErrInvalidBytecode,
+		},
+		{
+			code: Bytecode{Exports: []Export{{Name: "foo", Function: 0}}},
+			err:  ErrInvalidBytecode,
+		},
+		{
+			code: Bytecode{Functions: []Function{{Code: Bytecode{Instructions: New(FUNCLOAD, 0)}}}},
+			err:  ErrInvalidBytecode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			err := tt.code.Validate()
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestBytecode_Lookup(t *testing.T) {
+	code := Bytecode{
+		Functions: []Function{{Name: "foo"}, {Name: "bar"}},
+		Exports:   []Export{{Name: "foo", Function: 0}, {Name: "bar", Function: 1}},
+	}
+
+	idx, ok := code.Lookup("bar")
+	assert.True(t, ok)
+	assert.Equal(t, 1, idx)
+
+	_, ok = code.Lookup("baz")
+	assert.False(t, ok)
+}
+
+func TestBytecode_Line(t *testing.T) {
+	var code Bytecode
+
+	code.Mark(1)
+	code.Emit(New(I32LOAD, 1))
+	code.Mark(1)
+	code.Emit(New(I32LOAD, 2))
+	code.Mark(3)
+	code.Emit(New(I32ADD))
+
+	assert.Equal(t, []Line{{Offset: 0, Line: 1}, {Offset: 10, Line: 3}}, code.Lines)
+	assert.Equal(t, 1, code.Line(0))
+	assert.Equal(t, 1, code.Line(5))
+	assert.Equal(t, 3, code.Line(10))
+}
+
+func TestBytecode_MarshalBinary(t *testing.T) {
+	tests := []Bytecode{
+		{},
+		{
+			Instructions: append(New(I32LOAD, 1), New(POP)...),
+		},
+		{
+			Instructions: append(New(STRLOAD, 0), New(F64CONST, 1)...),
+			Constants:    []Constant{String("foo"), Float64(1.5)},
+			Lines:        []Line{{Offset: 0, Line: 1}, {Offset: 9, Line: 2}},
+			Symbols:      []Symbol{{Index: 0, Name: "foo"}},
+			Source:       "'foo'; 1.5",
+			SourceHash:   sha256.Sum256([]byte("'foo'; 1.5")),
+		},
+		{
+			Instructions: append(New(FUNCLOAD, 0), New(CALL, 0)...),
+			Functions: []Function{
+				{
+					Name:   "foo",
+					Params: 1,
+					Code: Bytecode{
+						Instructions: append(New(SLTLOAD, 0), New(RET)...),
+					},
+				},
+			},
+			Exports: []Export{{Name: "foo", Function: 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.String(), func(t *testing.T) {
+			data, err := tt.MarshalBinary()
+			assert.NoError(t, err)
+
+			var actual Bytecode
+			err = actual.UnmarshalBinary(data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.String(), actual.String())
+
+			err = actual.LoadDebug()
+			assert.NoError(t, err)
+			assert.Equal(t, tt, actual)
+		})
+	}
+}
+
+func TestBytecode_LoadDebug(t *testing.T) {
+	code := Bytecode{
+		Instructions: append(New(I32LOAD, 1), New(FUNCLOAD, 0)...),
+		Lines:        []Line{{Offset: 0, Line: 1}, {Offset: 5, Line: 2}},
+		Functions: []Function{
+			{
+				Code: Bytecode{
+					Instructions: append(New(UNDEFLOAD), New(RET)...),
+					Symbols:      []Symbol{{Index: 0, Name: "foo"}},
+				},
+			},
+		},
+	}
+	data, err := code.MarshalBinary()
+	assert.NoError(t, err)
+
+	var actual Bytecode
+	err = actual.UnmarshalBinary(data)
+	assert.NoError(t, err)
+	assert.Nil(t, actual.Lines)
+	assert.Equal(t, 2, actual.Line(5))
+	assert.Zero(t, testing.AllocsPerRun(100, func() { actual.Line(5) }))
+
+	err = actual.LoadDebug()
+	assert.NoError(t, err)
+	assert.Equal(t, code.Lines, actual.Lines)
+	assert.Equal(t, code.Functions[0].Code.Symbols, actual.Functions[0].Code.Symbols)
+}
+
+func TestBytecode_WriteTo(t *testing.T) {
+	code := Bytecode{
+		Instructions: append(New(STRLOAD, 0), New(FUNCLOAD, 0)...),
+		Constants:    []Constant{String("foo"), Float64(1.5)},
+		Functions: []Function{{
+			Name:   "foo",
+			Params: 1,
+			Code: Bytecode{
+				Instructions: append(New(FUNCLOAD, 0), New(RET)...),
+				Functions:    []Function{{Name: "bar", Upvalues: 1, Code: Bytecode{Instructions: append(New(UNDEFLOAD), New(RET)...)}}},
+				Lines:        []Line{{Offset: 0, Line: 2}},
+			},
+		}},
+		Exports: []Export{{Name: "foo", Function: 0}},
+		Lines:   []Line{{Offset: 0, Line: 1}},
+		Symbols: []Symbol{{Index: 0, Name: "foo"}},
+		Source:  "foo",
+	}
+
+	var buf bytes.Buffer
+	n, err := code.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	data, err := code.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, data, buf.Bytes())
+
+	buf.WriteString("rest")
+
+	var actual Bytecode
+	n, err = actual.ReadFrom(iotest.OneByteReader(&buf))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+	assert.Equal(t, code.String(), actual.String())
+	assert.Equal(t, code.Exports, actual.Exports)
+	assert.Equal(t, "rest", buf.String())
+}
+
+func TestBytecode_UnmarshalBinary(t *testing.T) {
+	code := Bytecode{
+		Instructions: New(I32LOAD, 1),
+		Constants:    []Constant{Float64(1)},
+	}
+	data, err := code.MarshalBinary()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{data: nil, err: ErrInvalidFormat},
+		{data: []byte("MJS"), err: ErrInvalidFormat},
+		{data: []byte("XXXX\x01"), err: ErrInvalidFormat},
+		{data: []byte("MJSC\x02"), err: ErrUnsupportedVersion},
+		{data: data[:len(data)-1], err: ErrInvalidFormat},
+		{data: append(slices.Clone(data[:len(data)-1]), data[len(data)-1]^0xFF), err: ErrChecksumMismatch},
+		{data: append(slices.Concat(data[:7], []byte{data[7] ^ 0xFF}), data[8:]...), err: ErrChecksumMismatch},
+		{data: append(slices.Clone(data), 0), err: ErrInvalidFormat},
+		{data: data[:6], err: ErrInvalidFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.data), func(t *testing.T) {
+			var actual Bytecode
+			err := actual.UnmarshalBinary(tt.data)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	var actual Bytecode
+	err = actual.UnmarshalBinary(slices.Concat(Magic[:], []byte{Version + 1}))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	assert.ErrorContains(t, err, fmt.Sprintf("compiled with format v%d, this runtime supports v%d", Version+1, Version))
+}
@@ -0,0 +1,56 @@
+package bytecode
+
+import (
+	"strconv"
+)
+
+type Constant interface {
+	Kind() Kind
+	Interface() any
+}
+
+type Kind byte
+
+const (
+	STRING Kind = iota + 1
+	FLOAT64
+)
+
+type String string
+
+type Float64 float64
+
+func (k Kind) String() string {
+	switch k {
+	case STRING:
+		return "string"
+	case FLOAT64:
+		return "float64"
+	default:
+		return "<invalid>"
+	}
+}
+
+func (String) Kind() Kind {
+	return STRING
+}
+
+func (s String) Interface() any {
+	return string(s)
+}
+
+func (s String) String() string {
+	return string(s)
+}
+
+func (Float64) Kind() Kind {
+	return FLOAT64
+}
+
+func (f Float64) Interface() any {
+	return float64(f)
+}
+
+func (f Float64) String() string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 64)
+}
@@ -0,0 +1,144 @@
+package bytecode
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type FormatOption func(*formatter)
+
+type formatter struct {
+	sections  bool
+	raw       bool
+	constants bool
+	offsets   bool
+}
+
+func WithSections(enabled bool) FormatOption {
+	return func(f *formatter) {
+		f.sections = enabled
+	}
+}
+
+func WithRawBytes(enabled bool) FormatOption {
+	return func(f *formatter) {
+		f.raw = enabled
+	}
+}
+
+func WithConstants(enabled bool) FormatOption {
+	return func(f *formatter) {
+		f.constants = enabled
+	}
+}
+
+func WithOffsets(enabled bool) FormatOption {
+	return func(f *formatter) {
+		f.offsets = enabled
+	}
+}
+
+func (b *Bytecode) Format(opts ...FormatOption) string {
+	f := &formatter{sections: true}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	var out strings.Builder
+	f.format(&out, b, "")
+	return out.String()
+}
+
+func (f *formatter) format(out *strings.Builder, b *Bytecode, prefix string) {
+	if f.sections {
+		out.WriteString("section .text:\n")
+	}
+	for offset, inst := range b.Iter() {
+		f.instruction(out, b, offset, inst)
+	}
+	if !f.sections {
+		return
+	}
+
+	out.WriteString("\n.section .data:\n")
+	for _, val := range b.Constants {
+		fmt.Fprintf(out, " \t%s\t", val.Kind())
+		switch val := val.(type) {
+		case String:
+			for i := 0; i < len(val); i++ {
+				if c := val[i]; c >= 0x20 && c < 0x7F && c != '\\' {
+					out.WriteByte(c)
+				} else {
+					fmt.Fprintf(out, "\\x%02X", c)
+				}
+			}
+		default:
+			fmt.Fprint(out, val)
+		}
+		out.WriteString("\n")
+	}
+
+	meta := b.metadata()
+	if len(meta.Lines) > 0 {
+		out.WriteString("\n.section .lines:\n")
+		for _, line := range meta.Lines {
+			fmt.Fprintf(out, " \t0x%04X\t%d\n", line.Offset, line.Line)
+		}
+	}
+
+	if len(meta.Symbols) > 0 {
+		out.WriteString("\n.section .symbols:\n")
+		for _, sym := range meta.Symbols {
+			fmt.Fprintf(out, " \t0x%04X\t%s\n", sym.Index, sym.Name)
+		}
+	}
+
+	if len(b.Exports) > 0 {
+		out.WriteString("\n.section .exports:\n")
+		for _, export := range b.Exports {
+			fmt.Fprintf(out, " \t0x%04X\t%s\n", export.Function, export.Name)
+		}
+	}
+
+	for i, fn := range b.Functions {
+		path := fmt.Sprintf("%s%d", prefix, i)
+		fmt.Fprintf(out, "\n.section .functions.%s:\n", path)
+		if fn.Name != "" {
+			fmt.Fprintf(out, " \tname\t%s\n", fn.Name)
+		}
+		fmt.Fprintf(out, " \tparams\t%d\n", fn.Params)
+		if fn.Upvalues > 0 {
+			fmt.Fprintf(out, " \tupvalues\t%d\n", fn.Upvalues)
+		}
+		out.WriteString("\n")
+		f.format(out, &fn.Code, path+".")
+	}
+}
+
+func (f *formatter) instruction(out *strings.Builder, b *Bytecode, offset int, inst Instruction) {
+	out.WriteString("\t")
+	if f.offsets {
+		fmt.Fprintf(out, "%04X: ", offset)
+	}
+	if f.raw {
+		fmt.Fprintf(out, "%-30s", fmt.Sprintf("% X", []byte(inst)))
+	}
+	out.WriteString(inst.String())
+
+	if f.constants {
+		switch inst.Opcode() {
+		case STRLOAD, F64CONST, CALLHOST:
+			if idx := inst.Operands()[0]; idx < uint64(len(b.Constants)) {
+				switch val := b.Constants[idx].(type) {
+				case String:
+					fmt.Fprintf(out, "\t; %s", strconv.Quote(string(val)))
+				default:
+					fmt.Fprintf(out, "\t; %v", val)
+				}
+			}
+		default:
+		}
+	}
+	out.WriteString("\n")
+}
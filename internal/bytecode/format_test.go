@@ -0,0 +1,51 @@
+package bytecode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytecode_Format(t *testing.T) {
+	code := Bytecode{Constants: []Constant{String("foo"), Float64(1.5)}}
+	code.Emit(
+		New(STRLOAD, 0),
+		New(F64CONST, 1),
+		New(POP),
+	)
+
+	tests := []struct {
+		opts   []FormatOption
+		expect string
+	}{
+		{
+			opts:   []FormatOption{WithSections(false)},
+			expect: "\tstr.load 0x0\n\tf64.const 0x1\n\tpop\n",
+		},
+		{
+			opts:   []FormatOption{WithSections(false), WithOffsets(true)},
+			expect: "\t0000: str.load 0x0\n\t0002: f64.const 0x1\n\t0004: pop\n",
+		},
+		{
+			opts:   []FormatOption{WithSections(false), WithRawBytes(true)},
+			expect: "\t2A 00                         str.load 0x0\n\t22 01                         f64.const 0x1\n\t01                            pop\n",
+		},
+		{
+			opts:   []FormatOption{WithSections(false), WithConstants(true)},
+			expect: "\tstr.load 0x0\t; \"foo\"\n\tf64.const 0x1\t; 1.5\n\tpop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expect, func(t *testing.T) {
+			assert.Equal(t, tt.expect, code.Format(tt.opts...))
+		})
+	}
+
+	assert.Equal(t, code.String(), code.Format(WithSections(true)))
+
+	actual, err := Assemble(strings.NewReader(code.Format(WithConstants(true))))
+	assert.NoError(t, err)
+	assert.Equal(t, code.Instructions, actual.Instructions)
+}
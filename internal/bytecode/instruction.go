@@ -2,8 +2,13 @@ package bytecode
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
 )
 
 type Instruction []byte
@@ -13,14 +18,31 @@ type Opcode byte
 type Type struct {
 	Mnemonic string
 	Widths   []int
+	Pop      int
+	Push     int
 }
 
+const Varint = 0
+
+const CustomOpcodes Opcode = 0xC0
+
+var ErrInvalidOpcode = errors.New("invalid opcode")
+
 const (
 	NOP Opcode = iota
 	POP
+	DUP
 
 	SLTLOAD
 	SLTSTORE
+	GLBLOAD
+	GLBSTORE
+	CELLLOAD
+	CELLSTORE
+	CELLREF
+	UPVLOAD
+	UPVSTORE
+	UPVREF
 
 	UNDEFLOAD
 	UNDEFTOF64
@@ -40,11 +62,14 @@ const (
 	I32SUB
 	I32DIV
 	I32MOD
+	I32SHL
+	I32SHR
 	I32TOBOOL
 	I32TOF64
 	I32TOSTR
 
 	F64LOAD
+	F64CONST
 	F64ADD
 	F64SUB
 	F64MUL
@@ -57,124 +82,305 @@ const (
 	STRADD
 	STRTOI32
 	STRTOF64
+
+	TONUM
+	TOSTR
+	TOBOOL
+	ADD
+
+	FUNCLOAD
+	CLOSURE
+	CALL
+	RET
+	CALLHOST
+	PROPLOAD
 )
 
 var types = map[Opcode]*Type{
 	NOP: {Mnemonic: "nop"},
-	POP: {Mnemonic: "pop"},
-
-	SLTLOAD:  {Mnemonic: "slot.load", Widths: []int{2}},
-	SLTSTORE: {Mnemonic: "slot.store", Widths: []int{2}},
-
-	UNDEFLOAD:  {Mnemonic: "undef.load"},
-	UNDEFTOF64: {Mnemonic: "undef.to_f64"},
-	UNDEFTOSTR: {Mnemonic: "undef.to_str"},
-
-	NULLLOAD:  {Mnemonic: "null.load"},
-	NULLTOI32: {Mnemonic: "null.to_i32"},
-	NULLTOSTR: {Mnemonic: "null.to_str"},
-
-	BOOLLOAD:  {Mnemonic: "bool.load", Widths: []int{1}},
-	BOOLTOI32: {Mnemonic: "bool.to_i32"},
-	BOOLTOSTR: {Mnemonic: "bool.to_str"},
-
-	I32LOAD:   {Mnemonic: "i32.load", Widths: []int{4}},
-	I32MUL:    {Mnemonic: "i32.mul"},
-	I32ADD:    {Mnemonic: "i32.add"},
-	I32SUB:    {Mnemonic: "i32.sub"},
-	I32DIV:    {Mnemonic: "i32.div"},
-	I32MOD:    {Mnemonic: "i32.mod"},
-	I32TOBOOL: {Mnemonic: "i32.to_bool"},
-	I32TOF64:  {Mnemonic: "i32.to_f64"},
-	I32TOSTR:  {Mnemonic: "i32.to_str"},
-
-	F64LOAD:  {Mnemonic: "f64.load", Widths: []int{8}},
-	F64ADD:   {Mnemonic: "f64.add"},
-	F64SUB:   {Mnemonic: "f64.sub"},
-	F64MUL:   {Mnemonic: "f64.mul"},
-	F64DIV:   {Mnemonic: "f64.div"},
-	F64MOD:   {Mnemonic: "f64.mod"},
-	F64TOI32: {Mnemonic: "f64.to_i32"},
-	F64TOSTR: {Mnemonic: "f64.to_str"},
-
-	STRLOAD:  {Mnemonic: "str.load", Widths: []int{4, 4}},
-	STRADD:   {Mnemonic: "str.add"},
-	STRTOI32: {Mnemonic: "str.to_i32"},
-	STRTOF64: {Mnemonic: "str.to_f64"},
+	POP: {Mnemonic: "pop", Pop: 1},
+	DUP: {Mnemonic: "dup", Pop: 1, Push: 2},
+
+	SLTLOAD:   {Mnemonic: "slot.load", Widths: []int{Varint}, Push: 1},
+	SLTSTORE:  {Mnemonic: "slot.store", Widths: []int{Varint}, Pop: 1},
+	GLBLOAD:   {Mnemonic: "global.load", Widths: []int{Varint}, Push: 1},
+	GLBSTORE:  {Mnemonic: "global.store", Widths: []int{Varint}, Pop: 1},
+	CELLLOAD:  {Mnemonic: "cell.load", Widths: []int{Varint}, Push: 1},
+	CELLSTORE: {Mnemonic: "cell.store", Widths: []int{Varint}, Pop: 1},
+	CELLREF:   {Mnemonic: "cell.ref", Widths: []int{Varint}, Push: 1},
+	UPVLOAD:   {Mnemonic: "upval.load", Widths: []int{Varint}, Push: 1},
+	UPVSTORE:  {Mnemonic: "upval.store", Widths: []int{Varint}, Pop: 1},
+	UPVREF:    {Mnemonic: "upval.ref", Widths: []int{Varint}, Push: 1},
+
+	UNDEFLOAD:  {Mnemonic: "undef.load", Push: 1},
+	UNDEFTOF64: {Mnemonic: "undef.to_f64", Pop: 1, Push: 1},
+	UNDEFTOSTR: {Mnemonic: "undef.to_str", Pop: 1, Push: 1},
+
+	NULLLOAD:  {Mnemonic: "null.load", Push: 1},
+	NULLTOI32: {Mnemonic: "null.to_i32", Pop: 1, Push: 1},
+	NULLTOSTR: {Mnemonic: "null.to_str", Pop: 1, Push: 1},
+
+	BOOLLOAD:  {Mnemonic: "bool.load", Widths: []int{1}, Push: 1},
+	BOOLTOI32: {Mnemonic: "bool.to_i32", Pop: 1, Push: 1},
+	BOOLTOSTR: {Mnemonic: "bool.to_str", Pop: 1, Push: 1},
+
+	I32LOAD:   {Mnemonic: "i32.load", Widths: []int{4}, Push: 1},
+	I32MUL:    {Mnemonic: "i32.mul", Pop: 2, Push: 1},
+	I32ADD:    {Mnemonic: "i32.add", Pop: 2, Push: 1},
+	I32SUB:    {Mnemonic: "i32.sub", Pop: 2, Push: 1},
+	I32DIV:    {Mnemonic: "i32.div", Pop: 2, Push: 1},
+	I32MOD:    {Mnemonic: "i32.mod", Pop: 2, Push: 1},
+	I32SHL:    {Mnemonic: "i32.shl", Pop: 2, Push: 1},
+	I32SHR:    {Mnemonic: "i32.shr", Pop: 2, Push: 1},
+	I32TOBOOL: {Mnemonic: "i32.to_bool", Pop: 1, Push: 1},
+	I32TOF64:  {Mnemonic: "i32.to_f64", Pop: 1, Push: 1},
+	I32TOSTR:  {Mnemonic: "i32.to_str", Pop: 1, Push: 1},
+
+	F64LOAD:  {Mnemonic: "f64.load", Widths: []int{8}, Push: 1},
+	F64CONST: {Mnemonic: "f64.const", Widths: []int{Varint}, Push: 1},
+	F64ADD:   {Mnemonic: "f64.add", Pop: 2, Push: 1},
+	F64SUB:   {Mnemonic: "f64.sub", Pop: 2, Push: 1},
+	F64MUL:   {Mnemonic: "f64.mul", Pop: 2, Push: 1},
+	F64DIV:   {Mnemonic: "f64.div", Pop: 2, Push: 1},
+	F64MOD:   {Mnemonic: "f64.mod", Pop: 2, Push: 1},
+	F64TOI32: {Mnemonic: "f64.to_i32", Pop: 1, Push: 1},
+	F64TOSTR: {Mnemonic: "f64.to_str", Pop: 1, Push: 1},
+
+	STRLOAD:  {Mnemonic: "str.load", Widths: []int{Varint}, Push: 1},
+	STRADD:   {Mnemonic: "str.add", Pop: 2, Push: 1},
+	STRTOI32: {Mnemonic: "str.to_i32", Pop: 1, Push: 1},
+	STRTOF64: {Mnemonic: "str.to_f64", Pop: 1, Push: 1},
+
+	TONUM:  {Mnemonic: "to_num", Pop: 1, Push: 1},
+	TOSTR:  {Mnemonic: "to_str", Pop: 1, Push: 1},
+	TOBOOL: {Mnemonic: "to_bool", Pop: 1, Push: 1},
+	ADD:    {Mnemonic: "add", Pop: 2, Push: 1},
+
+	FUNCLOAD: {Mnemonic: "func.load", Widths: []int{Varint}, Push: 1},
+	CLOSURE:  {Mnemonic: "closure", Widths: []int{Varint, Varint}, Push: 1},
+	CALL:     {Mnemonic: "call", Widths: []int{1}, Pop: 1, Push: 1},
+	RET:      {Mnemonic: "ret", Pop: 1},
+	CALLHOST: {Mnemonic: "call.host", Widths: []int{Varint, 1}, Push: 1},
+	PROPLOAD: {Mnemonic: "prop.load", Pop: 2, Push: 1},
+}
+
+// customs holds the opcodes added with Register. Writers copy the map under
+// registry and publish the copy, so TypeOf never blocks while code decodes or
+// runs on other goroutines.
+var (
+	customs  atomic.Pointer[map[Opcode]*Type]
+	registry sync.Mutex
+)
+
+// Register adds op, which must be in the custom range, with typ. It may be
+// called while other goroutines decode or run code.
+func Register(op Opcode, typ Type) error {
+	if op < CustomOpcodes {
+		return fmt.Errorf("%w: 0x%02X is outside the custom range", ErrInvalidOpcode, byte(op))
+	}
+	if typ.Mnemonic == "" || strings.ContainsFunc(typ.Mnemonic, unicode.IsSpace) {
+		return fmt.Errorf("%w: invalid mnemonic %q", ErrInvalidOpcode, typ.Mnemonic)
+	}
+	for _, width := range typ.Widths {
+		switch width {
+		case Varint, 1, 2, 4, 8:
+		default:
+			return fmt.Errorf("%w: unsupported operand width %d", ErrInvalidOpcode, width)
+		}
+	}
+	if typ.Pop < 0 || typ.Push < 0 {
+		return fmt.Errorf("%w: negative stack effect", ErrInvalidOpcode)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if TypeOf(op) != nil {
+		return fmt.Errorf("%w: 0x%02X is already registered", ErrInvalidOpcode, byte(op))
+	}
+	registered := false
+	each(func(_ Opcode, t *Type) {
+		registered = registered || t.Mnemonic == typ.Mnemonic
+	})
+	if registered {
+		return fmt.Errorf("%w: %s is already registered", ErrInvalidOpcode, typ.Mnemonic)
+	}
+
+	typ.Widths = slices.Clone(typ.Widths)
+
+	m := map[Opcode]*Type{op: &typ}
+	if old := customs.Load(); old != nil {
+		for k, v := range *old {
+			m[k] = v
+		}
+	}
+	customs.Store(&m)
+	return nil
+}
+
+func Unregister(op Opcode) {
+	if op < CustomOpcodes {
+		return
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	old := customs.Load()
+	if old == nil {
+		return
+	}
+	if _, ok := (*old)[op]; !ok {
+		return
+	}
+	m := make(map[Opcode]*Type, len(*old))
+	for k, v := range *old {
+		if k != op {
+			m[k] = v
+		}
+	}
+	customs.Store(&m)
 }
 
 func TypeOf(op Opcode) *Type {
-	typ, ok := types[op]
-	if !ok {
-		return nil
+	if op < CustomOpcodes {
+		return types[op]
+	}
+	if m := customs.Load(); m != nil {
+		return (*m)[op]
+	}
+	return nil
+}
+
+// each calls fn with every opcode that has a type, built-in or registered.
+func each(fn func(Opcode, *Type)) {
+	for op, typ := range types {
+		fn(op, typ)
+	}
+	if m := customs.Load(); m != nil {
+		for op, typ := range *m {
+			fn(op, typ)
+		}
 	}
-	return typ
 }
 
 func (t *Type) Width() int {
 	width := 1
 	for _, w := range t.Widths {
-		width += w
+		width += max(w, 1)
 	}
 	return width
 }
 
 func New(op Opcode, operands ...uint64) Instruction {
-	typ, ok := types[op]
-	if !ok {
+	typ := TypeOf(op)
+	if typ == nil {
 		return nil
 	}
 
-	width := 1
-	for _, w := range typ.Widths {
-		width += w
-	}
-
-	bytecode := make(Instruction, width)
+	bytecode := make(Instruction, 1, typ.Width())
 	bytecode[0] = byte(op)
 
-	offset := 1
 	for i, o := range operands {
-		width := typ.Widths[i]
-		switch width {
+		switch typ.Widths[i] {
+		case Varint:
+			bytecode = binary.AppendUvarint(bytecode, o)
 		case 1:
-			bytecode[offset] = byte(o)
+			bytecode = append(bytecode, byte(o))
 		case 2:
-			binary.BigEndian.PutUint16(bytecode[offset:], uint16(o))
+			bytecode = binary.BigEndian.AppendUint16(bytecode, uint16(o))
 		case 4:
-			binary.BigEndian.PutUint32(bytecode[offset:], uint32(o))
+			bytecode = binary.BigEndian.AppendUint32(bytecode, uint32(o))
 		case 8:
-			binary.BigEndian.PutUint64(bytecode[offset:], o)
+			bytecode = binary.BigEndian.AppendUint64(bytecode, o)
 		default:
 			return nil
 		}
-		offset += width
+	}
+	for _, width := range typ.Widths[len(operands):] {
+		bytecode = append(bytecode, make([]byte, max(width, 1))...)
 	}
 	return bytecode
 }
 
+func Decode(data []byte) (Instruction, int) {
+	if len(data) == 0 {
+		return nil, 0
+	}
+	typ := TypeOf(Opcode(data[0]))
+	if typ == nil {
+		return nil, 0
+	}
+
+	width := 1
+	for _, w := range typ.Widths {
+		if w == Varint {
+			_, n := binary.Uvarint(data[min(width, len(data)):])
+			if n <= 0 {
+				return nil, 0
+			}
+			w = n
+		}
+		width += w
+	}
+	if width > len(data) {
+		return nil, 0
+	}
+	return Instruction(data[:width]), width
+}
+
 func (i Instruction) Type() *Type {
+	if len(i) == 0 {
+		return nil
+	}
 	return TypeOf(i.Opcode())
 }
 
+func (i Instruction) Effect() (int, int) {
+	typ := i.Type()
+	if typ == nil {
+		return 0, 0
+	}
+	pop := typ.Pop
+	switch i.Opcode() {
+	case CALL:
+		pop += int(i.Operands()[0])
+	case CLOSURE, CALLHOST:
+		pop += int(i.Operands()[1])
+	default:
+	}
+	return pop, typ.Push
+}
+
 func (i Instruction) Opcode() Opcode {
 	return Opcode(i[0])
 }
 
 func (i Instruction) Operands() []uint64 {
 	typ := i.Type()
+	if typ == nil {
+		return nil
+	}
+
 	operands := make([]uint64, len(typ.Widths))
-	offset := 0
+	offset := 1
 	for j, width := range typ.Widths {
+		if offset+max(width, 1) > len(i) {
+			break
+		}
 		switch width {
+		case Varint:
+			if operands[j], width = binary.Uvarint(i[offset:]); width <= 0 {
+				return operands
+			}
 		case 1:
-			operands[j] = uint64(i[1+offset])
+			operands[j] = uint64(i[offset])
 		case 2:
-			operands[j] = uint64(binary.BigEndian.Uint16(i[1+offset:]))
+			operands[j] = uint64(binary.BigEndian.Uint16(i[offset:]))
 		case 4:
-			operands[j] = uint64(binary.BigEndian.Uint32(i[1+offset:]))
+			operands[j] = uint64(binary.BigEndian.Uint32(i[offset:]))
 		case 8:
-			operands[j] = binary.BigEndian.Uint64(i[1+offset:])
+			operands[j] = binary.BigEndian.Uint64(i[offset:])
 		default:
 			continue
 		}
@@ -185,6 +391,12 @@ func (i Instruction) Operands() []uint64 {
 
 func (i Instruction) String() string {
 	typ := i.Type()
+	if typ == nil {
+		if len(i) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("0x%02X", i[0])
+	}
 	if len(typ.Widths) == 0 {
 		return typ.Mnemonic
 	}
@@ -13,9 +13,18 @@ func TestInstruction_String(t *testing.T) {
 	}{
 		{instruction: New(NOP), expect: "nop"},
 		{instruction: New(POP), expect: "pop"},
+		{instruction: New(DUP), expect: "dup"},
 
-		{instruction: New(SLTLOAD, 0x01), expect: "slot.load 0x0001"},
-		{instruction: New(SLTSTORE, 0x01), expect: "slot.store 0x0001"},
+		{instruction: New(SLTLOAD, 0x01), expect: "slot.load 0x1"},
+		{instruction: New(SLTSTORE, 0x01), expect: "slot.store 0x1"},
+		{instruction: New(GLBLOAD, 0x01), expect: "global.load 0x1"},
+		{instruction: New(GLBSTORE, 0x01), expect: "global.store 0x1"},
+		{instruction: New(CELLLOAD, 0x01), expect: "cell.load 0x1"},
+		{instruction: New(CELLSTORE, 0x01), expect: "cell.store 0x1"},
+		{instruction: New(CELLREF, 0x01), expect: "cell.ref 0x1"},
+		{instruction: New(UPVLOAD, 0x01), expect: "upval.load 0x1"},
+		{instruction: New(UPVSTORE, 0x01), expect: "upval.store 0x1"},
+		{instruction: New(UPVREF, 0x01), expect: "upval.ref 0x1"},
 
 		{instruction: New(UNDEFLOAD), expect: "undef.load"},
 		{instruction: New(UNDEFTOF64), expect: "undef.to_f64"},
@@ -35,11 +44,14 @@ func TestInstruction_String(t *testing.T) {
 		{instruction: New(I32SUB), expect: "i32.sub"},
 		{instruction: New(I32DIV), expect: "i32.div"},
 		{instruction: New(I32MOD), expect: "i32.mod"},
+		{instruction: New(I32SHL), expect: "i32.shl"},
+		{instruction: New(I32SHR), expect: "i32.shr"},
 		{instruction: New(I32TOBOOL), expect: "i32.to_bool"},
 		{instruction: New(I32TOF64), expect: "i32.to_f64"},
 		{instruction: New(I32TOSTR), expect: "i32.to_str"},
 
 		{instruction: New(F64LOAD, 0x01), expect: "f64.load 0x0000000000000001"},
+		{instruction: New(F64CONST, 0x01), expect: "f64.const 0x1"},
 		{instruction: New(F64ADD), expect: "f64.add"},
 		{instruction: New(F64SUB), expect: "f64.sub"},
 		{instruction: New(F64MUL), expect: "f64.mul"},
@@ -48,10 +60,20 @@ func TestInstruction_String(t *testing.T) {
 		{instruction: New(F64TOI32), expect: "f64.to_i32"},
 		{instruction: New(F64TOSTR), expect: "f64.to_str"},
 
-		{instruction: New(STRLOAD, 0x01, 0x01), expect: "str.load 0x00000001 0x00000001"},
+		{instruction: New(STRLOAD, 0x01), expect: "str.load 0x1"},
 		{instruction: New(STRADD), expect: "str.add"},
 		{instruction: New(STRTOI32), expect: "str.to_i32"},
 		{instruction: New(STRTOF64), expect: "str.to_f64"},
+
+		{instruction: New(TONUM), expect: "to_num"},
+		{instruction: New(TOSTR), expect: "to_str"},
+		{instruction: New(TOBOOL), expect: "to_bool"},
+		{instruction: New(ADD), expect: "add"},
+
+		{instruction: New(FUNCLOAD, 0x01), expect: "func.load 0x1"},
+		{instruction: New(CLOSURE, 0x01, 0x02), expect: "closure 0x1 0x2"},
+		{instruction: New(CALL, 0x02), expect: "call 0x02"},
+		{instruction: New(RET), expect: "ret"},
 	}
 
 	for _, test := range tests {
@@ -60,3 +82,128 @@ func TestInstruction_String(t *testing.T) {
 		})
 	}
 }
+
+func TestDecode(t *testing.T) {
+	tests := []Instruction{
+		New(NOP),
+		New(SLTLOAD, 0x7F),
+		New(SLTLOAD, 0x80),
+		New(STRLOAD, 1<<32),
+		New(CLOSURE, 300, 2),
+		New(F64LOAD, 0xFF),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.String(), func(t *testing.T) {
+			inst, width := Decode(append(tt, New(POP)...))
+			assert.Equal(t, tt, inst)
+			assert.Equal(t, len(tt), width)
+		})
+	}
+
+	inst, width := Decode(New(SLTLOAD, 0x80)[:2])
+	assert.Nil(t, inst)
+	assert.Equal(t, 0, width)
+}
+
+func TestInstruction_Effect(t *testing.T) {
+	tests := []struct {
+		instruction Instruction
+		pop         int
+		push        int
+	}{
+		{instruction: New(NOP)},
+		{instruction: New(POP), pop: 1},
+		{instruction: New(DUP), pop: 1, push: 2},
+		{instruction: New(I32LOAD, 1), push: 1},
+		{instruction: New(I32ADD), pop: 2, push: 1},
+		{instruction: New(CLOSURE, 0, 2), pop: 2, push: 1},
+		{instruction: New(CALL, 3), pop: 4, push: 1},
+		{instruction: New(RET), pop: 1},
+		{instruction: New(CALLHOST, 0, 2), pop: 2, push: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.instruction.String(), func(t *testing.T) {
+			pop, push := tt.instruction.Effect()
+			assert.Equal(t, tt.pop, pop)
+			assert.Equal(t, tt.push, push)
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	op := CustomOpcodes + 1
+	defer Unregister(op)
+
+	err := Register(op, Type{Mnemonic: "host.sum", Widths: []int{Varint}, Pop: 2, Push: 1})
+	assert.NoError(t, err)
+
+	inst := New(op, 300)
+	assert.Equal(t, "host.sum 0x12C", inst.String())
+
+	pop, push := inst.Effect()
+	assert.Equal(t, 2, pop)
+	assert.Equal(t, 1, push)
+
+	tests := []struct {
+		op  Opcode
+		typ Type
+	}{
+		{op: NOP, typ: Type{Mnemonic: "host.nop"}},
+		{op: op, typ: Type{Mnemonic: "host.other"}},
+		{op: op + 1, typ: Type{Mnemonic: "host.sum"}},
+		{op: op + 1, typ: Type{Mnemonic: "nop"}},
+		{op: op + 1, typ: Type{Mnemonic: ""}},
+		{op: op + 1, typ: Type{Mnemonic: "host.bad", Widths: []int{3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ.Mnemonic, func(t *testing.T) {
+			err := Register(tt.op, tt.typ)
+			assert.ErrorIs(t, err, ErrInvalidOpcode)
+		})
+	}
+}
+
+func TestRegister_Concurrent(t *testing.T) {
+	op := CustomOpcodes + 2
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for j := 0; j < 100; j++ {
+			_ = Register(op, Type{Mnemonic: "host.concurrent"})
+			Unregister(op)
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			assert.Nil(t, TypeOf(op))
+			return
+		default:
+			_ = TypeOf(op)
+			_ = New(I32LOAD, 1).String()
+		}
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte(New(NOP)))
+	f.Add([]byte(New(I32LOAD, 1)))
+	f.Add([]byte(New(SLTLOAD, 300)))
+	f.Add([]byte(New(CLOSURE, 1, 2)))
+	f.Add([]byte{byte(F64LOAD), 0x01})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if inst, n := Decode(data); inst != nil {
+			assert.Equal(t, n, len(inst))
+			_ = inst.String()
+			_, _ = inst.Effect()
+		}
+		_ = Instruction(data).Operands()
+		_ = Instruction(data).String()
+	})
+}
@@ -0,0 +1,169 @@
+package bytecode
+
+import (
+	"fmt"
+	"sort"
+)
+
+type linker struct {
+	code      Bytecode
+	constants map[Constant]int
+	globals   map[string]int
+	slots     int
+}
+
+func Link(units ...Bytecode) (Bytecode, error) {
+	l := &linker{
+		constants: map[Constant]int{},
+		globals:   map[string]int{},
+	}
+	for _, unit := range units {
+		if err := l.link(unit); err != nil {
+			return Bytecode{}, err
+		}
+	}
+
+	for name, idx := range l.globals {
+		l.code.Symbols = append(l.code.Symbols, Symbol{Index: idx, Name: name})
+	}
+	sort.Slice(l.code.Symbols, func(i, j int) bool {
+		return l.code.Symbols[i].Index < l.code.Symbols[j].Index
+	})
+	return l.code, nil
+}
+
+func (l *linker) link(unit Bytecode) error {
+	meta := unit.metadata()
+
+	names := map[int]string{}
+	for _, sym := range meta.Symbols {
+		names[sym.Index] = sym.Name
+	}
+	slots := map[uint64]uint64{}
+	slot := func(idx uint64) (uint64, error) {
+		if s, ok := slots[idx]; ok {
+			return s, nil
+		}
+		if len(names) == 0 {
+			return 0, fmt.Errorf("global slot %d has no name: link units compiled with debug info", idx)
+		}
+		name, ok := names[int(idx)]
+		s, exists := l.globals[name]
+		if !ok || !exists {
+			s = l.slots
+			l.slots++
+			if ok {
+				l.globals[name] = s
+			}
+		}
+		slots[idx] = uint64(s)
+		return uint64(s), nil
+	}
+
+	base := len(l.code.Instructions)
+	functions := len(l.code.Functions)
+	for _, fn := range unit.Functions {
+		fn, err := relocate(fn, slot)
+		if err != nil {
+			return err
+		}
+		l.code.Functions = append(l.code.Functions, fn)
+	}
+
+	for _, export := range unit.Exports {
+		if _, ok := l.code.Lookup(export.Name); ok {
+			return fmt.Errorf("duplicate export %s", export.Name)
+		}
+		l.code.Exports = append(l.code.Exports, Export{Name: export.Name, Function: export.Function + functions})
+	}
+
+	offsets := map[int]int{}
+	for _, inst := range unit.Disassemble() {
+		offsets[inst.Offset] = len(l.code.Instructions) - base
+		operands := inst.Operands
+		switch inst.Opcode {
+		case SLTLOAD, SLTSTORE, CELLLOAD, CELLSTORE, CELLREF:
+			s, err := slot(operands[0])
+			if err != nil {
+				return err
+			}
+			operands = []uint64{s}
+		case STRLOAD, F64CONST, CALLHOST:
+			if operands[0] >= uint64(len(unit.Constants)) {
+				return fmt.Errorf("constant out of range in %s at offset %d", inst.Mnemonic, inst.Offset)
+			}
+			operands = append([]uint64{l.constant(unit.Constants[operands[0]])}, operands[1:]...)
+		case FUNCLOAD, CLOSURE:
+			operands = append([]uint64{operands[0] + uint64(functions)}, operands[1:]...)
+		default:
+		}
+		l.code.Emit(New(inst.Opcode, operands...))
+	}
+	offsets[len(unit.Instructions)] = len(l.code.Instructions) - base
+
+	l.code.Lines = append(l.code.Lines, remap(meta.Lines, offsets, base)...)
+	return nil
+}
+
+func (l *linker) constant(val Constant) uint64 {
+	idx, ok := l.constants[val]
+	if !ok {
+		idx = len(l.code.Constants)
+		l.code.Constants = append(l.code.Constants, val)
+		l.constants[val] = idx
+	}
+	return uint64(idx)
+}
+
+// relocate re-encodes fn with its global slots mapped by slot, moving its line
+// table along with the instructions whose operands changed width.
+func relocate(fn Function, slot func(uint64) (uint64, error)) (Function, error) {
+	meta := fn.Code.metadata()
+
+	code := fn.Code
+	code.Instructions = nil
+	code.Lines, code.Symbols = nil, meta.Symbols
+	code.Source, code.SourceHash = meta.Source, meta.SourceHash
+	code.debug = nil
+
+	offsets := map[int]int{}
+	for _, inst := range fn.Code.Disassemble() {
+		offsets[inst.Offset] = len(code.Instructions)
+		operands := inst.Operands
+		if inst.Opcode == GLBLOAD || inst.Opcode == GLBSTORE {
+			s, err := slot(operands[0])
+			if err != nil {
+				return Function{}, err
+			}
+			operands = []uint64{s}
+		}
+		code.Emit(New(inst.Opcode, operands...))
+	}
+	offsets[len(fn.Code.Instructions)] = len(code.Instructions)
+	code.Lines = remap(meta.Lines, offsets, 0)
+
+	code.Functions = nil
+	for _, f := range fn.Code.Functions {
+		f, err := relocate(f, slot)
+		if err != nil {
+			return Function{}, err
+		}
+		code.Functions = append(code.Functions, f)
+	}
+	fn.Code = code
+	return fn, nil
+}
+
+// remap moves lines to the offsets their instructions were re-encoded at, then
+// shifts them by base.
+func remap(lines []Line, offsets map[int]int, base int) []Line {
+	var remapped []Line
+	for _, line := range lines {
+		offset, ok := offsets[line.Offset]
+		if !ok {
+			offset = line.Offset
+		}
+		remapped = append(remapped, Line{Offset: offset + base, Line: line.Line})
+	}
+	return remapped
+}
@@ -0,0 +1,114 @@
+package bytecode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLink(t *testing.T) {
+	unit1 := Bytecode{
+		Constants: []Constant{String("foo")},
+		Lines:     []Line{{Offset: 0, Line: 1}},
+		Symbols:   []Symbol{{Index: 0, Name: "foo"}},
+	}
+	unit1.Emit(
+		New(STRLOAD, 0),
+		New(SLTSTORE, 0),
+	)
+
+	unit2 := Bytecode{
+		Constants: []Constant{String("bar"), String("foo")},
+		Lines:     []Line{{Offset: 0, Line: 1}},
+		Symbols:   []Symbol{{Index: 0, Name: "bar"}, {Index: 1, Name: "foo"}},
+		Functions: []Function{
+			{
+				Code: Bytecode{
+					Instructions: append(New(GLBLOAD, 1), New(RET)...),
+				},
+			},
+		},
+		Exports: []Export{{Name: "baz", Function: 0}},
+	}
+	unit2.Emit(
+		New(STRLOAD, 1),
+		New(SLTSTORE, 0),
+		New(FUNCLOAD, 0),
+		New(SLTLOAD, 1),
+		New(POP),
+	)
+
+	actual, err := Link(unit1, unit2)
+	assert.NoError(t, err)
+
+	expected := Bytecode{
+		Constants: []Constant{String("foo")},
+		Lines:     []Line{{Offset: 0, Line: 1}, {Offset: 4, Line: 1}},
+		Symbols:   []Symbol{{Index: 0, Name: "foo"}, {Index: 1, Name: "bar"}},
+		Functions: []Function{
+			{
+				Code: Bytecode{
+					Instructions: append(New(GLBLOAD, 0), New(RET)...),
+				},
+			},
+		},
+		Exports: []Export{{Name: "baz", Function: 0}},
+	}
+	expected.Emit(
+		New(STRLOAD, 0),
+		New(SLTSTORE, 0),
+		New(STRLOAD, 0),
+		New(SLTSTORE, 1),
+		New(FUNCLOAD, 0),
+		New(SLTLOAD, 0),
+		New(POP),
+	)
+	assert.Equal(t, expected, actual)
+}
+
+func TestLink_Unnamed(t *testing.T) {
+	unit1 := Bytecode{Constants: []Constant{String("a")}}
+	unit1.Emit(
+		New(STRLOAD, 0),
+		New(SLTSTORE, 0),
+	)
+
+	unit2 := Bytecode{Constants: []Constant{String("b")}}
+	unit2.Emit(
+		New(SLTLOAD, 0),
+		New(STRLOAD, 0),
+		New(ADD),
+	)
+
+	_, err := Link(unit1, unit2)
+	assert.ErrorContains(t, err, "global slot 0 has no name")
+}
+
+func TestLink_FunctionLines(t *testing.T) {
+	var unit1 Bytecode
+	for i := 0; i < 200; i++ {
+		unit1.Symbols = append(unit1.Symbols, Symbol{Index: i, Name: fmt.Sprintf("v%d", i)})
+		unit1.Emit(
+			New(UNDEFLOAD),
+			New(SLTSTORE, uint64(i)),
+		)
+	}
+
+	fn := Bytecode{Lines: []Line{{Offset: 0, Line: 1}}}
+	fn.Emit(New(GLBLOAD, 0))
+	fn.Mark(2)
+	fn.Emit(New(RET))
+
+	unit2 := Bytecode{
+		Symbols:   []Symbol{{Index: 0, Name: "x"}},
+		Functions: []Function{{Code: fn}},
+	}
+
+	actual, err := Link(unit1, unit2)
+	assert.NoError(t, err)
+
+	code := actual.Functions[0].Code
+	assert.Equal(t, New(GLBLOAD, 200), Instruction(code.Instructions[:len(New(GLBLOAD, 200))]))
+	assert.Equal(t, []Line{{Offset: 0, Line: 1}, {Offset: len(New(GLBLOAD, 200)), Line: 2}}, code.Lines)
+}
@@ -0,0 +1,76 @@
+package bytecode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Stats struct {
+	Opcodes      map[Opcode]int
+	Instructions int
+	Size         int
+	Constants    int
+	ConstantSize int
+	Functions    int
+	StackDepth   int
+}
+
+func (b *Bytecode) Stats() Stats {
+	s := Stats{Opcodes: map[Opcode]int{}}
+	s.collect(b)
+	return s
+}
+
+func (s *Stats) collect(b *Bytecode) {
+	depth := 0
+	for _, inst := range b.Iter() {
+		s.Opcodes[inst.Opcode()]++
+		s.Instructions++
+
+		pop, push := inst.Effect()
+		depth = max(depth-pop, 0) + push
+		s.StackDepth = max(s.StackDepth, depth)
+	}
+	s.Size += len(b.Instructions)
+
+	s.Constants += len(b.Constants)
+	for _, val := range b.Constants {
+		switch val := val.(type) {
+		case String:
+			s.ConstantSize += len(val)
+		case Float64:
+			s.ConstantSize += 8
+		}
+	}
+
+	s.Functions += len(b.Functions)
+	for i := range b.Functions {
+		s.collect(&b.Functions[i].Code)
+	}
+}
+
+func (s Stats) String() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "instructions\t%d\n", s.Instructions)
+	fmt.Fprintf(&out, "size\t%d\n", s.Size)
+	fmt.Fprintf(&out, "constants\t%d\n", s.Constants)
+	fmt.Fprintf(&out, "constant size\t%d\n", s.ConstantSize)
+	fmt.Fprintf(&out, "functions\t%d\n", s.Functions)
+	fmt.Fprintf(&out, "stack depth\t%d\n", s.StackDepth)
+
+	opcodes := make([]Opcode, 0, len(s.Opcodes))
+	for op := range s.Opcodes {
+		opcodes = append(opcodes, op)
+	}
+	sort.Slice(opcodes, func(i, j int) bool {
+		if s.Opcodes[opcodes[i]] != s.Opcodes[opcodes[j]] {
+			return s.Opcodes[opcodes[i]] > s.Opcodes[opcodes[j]]
+		}
+		return opcodes[i] < opcodes[j]
+	})
+	for _, op := range opcodes {
+		fmt.Fprintf(&out, "%s\t%d\n", TypeOf(op).Mnemonic, s.Opcodes[op])
+	}
+	return out.String()
+}
@@ -0,0 +1,40 @@
+package bytecode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytecode_Stats(t *testing.T) {
+	fn := Bytecode{}
+	fn.Emit(
+		New(SLTLOAD, 0),
+		New(SLTLOAD, 1),
+		New(SLTLOAD, 2),
+		New(ADD),
+		New(ADD),
+		New(RET),
+	)
+
+	code := Bytecode{
+		Constants: []Constant{String("foo"), Float64(1)},
+		Functions: []Function{{Params: 3, Code: fn}},
+	}
+	code.Emit(
+		New(STRLOAD, 0),
+		New(F64CONST, 1),
+		New(ADD),
+		New(POP),
+	)
+
+	stats := code.Stats()
+	assert.Equal(t, map[Opcode]int{STRLOAD: 1, F64CONST: 1, ADD: 3, POP: 1, SLTLOAD: 3, RET: 1}, stats.Opcodes)
+	assert.Equal(t, 10, stats.Instructions)
+	assert.Equal(t, len(code.Instructions)+len(fn.Instructions), stats.Size)
+	assert.Equal(t, 2, stats.Constants)
+	assert.Equal(t, 11, stats.ConstantSize)
+	assert.Equal(t, 1, stats.Functions)
+	assert.Equal(t, 3, stats.StackDepth)
+	assert.Contains(t, stats.String(), "slot.load\t3\nadd\t3\n")
+}
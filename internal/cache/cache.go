@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/siyul-park/minijs/internal/compiler"
+)
+
+type Cache struct {
+	dir string
+}
+
+const ext = ".mjsc"
+
+const module = "github.com/siyul-park/minijs"
+
+// build identifies the build of this module that compiles the cached code, so
+// that a new release or commit misses the entries of the previous one even if
+// nobody bumped compiler.Version.
+var build = func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	mod := &info.Main
+	for _, dep := range info.Deps {
+		if dep.Path == module {
+			mod = dep
+		}
+	}
+	if mod.Path != module {
+		return ""
+	}
+	id := mod.Version + " " + mod.Sum
+	if mod == &info.Main {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" || setting.Key == "vcs.modified" {
+				id += " " + setting.Value
+			}
+		}
+	}
+	return id
+}()
+
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+func Default() (*Cache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return New(filepath.Join(dir, "minijs")), nil
+}
+
+func (c *Cache) Key(source []byte, options ...string) string {
+	h := sha256.New()
+	_, _ = h.Write(binary.AppendUvarint(nil, bytecode.Version))
+	_, _ = h.Write(binary.AppendUvarint(nil, compiler.Version))
+	_, _ = h.Write([]byte(build))
+	for _, opt := range options {
+		_, _ = h.Write(binary.AppendUvarint(nil, uint64(len(opt))))
+		_, _ = h.Write([]byte(opt))
+	}
+	_, _ = h.Write(source)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) Load(key string) (bytecode.Bytecode, bool) {
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		return bytecode.Bytecode{}, false
+	}
+	defer f.Close()
+
+	var code bytecode.Bytecode
+	if _, err := code.ReadFrom(bufio.NewReader(f)); err != nil {
+		_ = os.Remove(c.path(key))
+		return bytecode.Bytecode{}, false
+	}
+	return code, true
+}
+
+func (c *Cache) Store(key string, code bytecode.Bytecode) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(c.dir, key+"-*")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if _, err = code.WriteTo(w); err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), c.path(key))
+}
+
+func (c *Cache) Clear() error {
+	entries, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) == ext {
+			if err := os.Remove(filepath.Join(c.dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+ext)
+}
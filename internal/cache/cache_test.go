@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_Key(t *testing.T) {
+	c := New(t.TempDir())
+
+	assert.Equal(t, c.Key([]byte("1 + 2")), c.Key([]byte("1 + 2")))
+	assert.NotEqual(t, c.Key([]byte("1 + 2")), c.Key([]byte("1 + 3")))
+	assert.NotEqual(t, c.Key([]byte("1 + 2"), "O1"), c.Key([]byte("1 + 2"), "O2"))
+	assert.NotEqual(t, c.Key([]byte("1 + 2"), "a", "b"), c.Key([]byte("1 + 2"), "ab"))
+
+	key := c.Key([]byte("1 + 2"))
+	defer func(old string) { build = old }(build)
+	build += " next"
+	assert.NotEqual(t, key, c.Key([]byte("1 + 2")))
+}
+
+func TestCache_Load(t *testing.T) {
+	c := New(t.TempDir())
+	key := c.Key([]byte("'foo'"))
+
+	_, ok := c.Load(key)
+	assert.False(t, ok)
+
+	code := bytecode.Bytecode{Constants: []bytecode.Constant{bytecode.String("foo")}}
+	code.Emit(bytecode.New(bytecode.STRLOAD, 0), bytecode.New(bytecode.POP))
+
+	err := c.Store(key, code)
+	assert.NoError(t, err)
+
+	actual, ok := c.Load(key)
+	assert.True(t, ok)
+	assert.Equal(t, code.String(), actual.String())
+}
+
+func TestCache_Load_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	c := New(dir)
+	key := c.Key([]byte("'foo'"))
+
+	err := os.WriteFile(filepath.Join(dir, key+ext), []byte("MJSC\x00"), 0o644)
+	assert.NoError(t, err)
+
+	_, ok := c.Load(key)
+	assert.False(t, ok)
+	assert.NoFileExists(t, filepath.Join(dir, key+ext))
+}
+
+func TestCache_Clear(t *testing.T) {
+	dir := t.TempDir()
+	c := New(dir)
+	key := c.Key([]byte("'foo'"))
+
+	err := c.Store(key, bytecode.Bytecode{})
+	assert.NoError(t, err)
+
+	err = c.Clear()
+	assert.NoError(t, err)
+
+	_, ok := c.Load(key)
+	assert.False(t, ok)
+}
@@ -1,9 +1,11 @@
 package compiler
 
 import (
-	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"math"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/siyul-park/minijs/internal/ast"
@@ -12,10 +14,43 @@ import (
 	"github.com/siyul-park/minijs/internal/token"
 )
 
+// Version identifies the code the compiler generates. Bump it whenever a
+// change makes the same source compile to different bytecode, so that caches
+// keyed by it stop serving code from before the change.
+const Version = 2
+
 type Compiler struct {
-	instructions []bytecode.Instruction
-	constants    [][]byte
-	symbolTable  *SymbolTable
+	level          Level
+	debug          bool
+	strict         bool
+	completion     bool
+	result         ast.Statement
+	transforms     []Transform
+	source         string
+	hosts          map[string]bool
+	hostCalls      map[*ast.CallExpression]string
+	instructions   []bytecode.Instruction
+	lines          []int
+	line           int
+	constants      []bytecode.Constant
+	functions      []bytecode.Function
+	exports        []bytecode.Export
+	session        *Session
+	symbolTable    *SymbolTable
+	symbols        map[*ast.IdentifierLiteral]*Symbol
+	scopes         map[*ast.FunctionLiteral]*SymbolTable
+	upvalues       map[*ast.FunctionLiteral][]*Symbol
+	enclosing      []*ast.FunctionLiteral
+	types          map[ast.Expression]interpreter.Type
+	errors         Errors
+	diagnostics    []Diagnostic
+	declarations   []declaration
+	reads          map[*Symbol]bool
+	bindings       map[*Symbol]*binding
+	propagations   map[*ast.IdentifierLiteral]*binding
+	subexpressions map[string]*subexpression
+	temporaries    []uint64
+	optimizer      *interpreter.Optimizer
 }
 
 var casts = map[interpreter.Type]map[interpreter.Type][]bytecode.Instruction{
@@ -69,20 +104,98 @@ var casts = map[interpreter.Type]map[interpreter.Type][]bytecode.Instruction{
 	},
 }
 
-func New() *Compiler {
-	return &Compiler{
+func New(opts ...Option) *Compiler {
+	c := &Compiler{
 		symbolTable: NewSymbolTable(),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func (c *Compiler) Compile(node ast.Node) (bytecode.Bytecode, error) {
-	if err := c.compile(node); err != nil {
-		return bytecode.Bytecode{}, err
+	for _, transform := range c.transforms {
+		n, err := transform(node)
+		if err != nil {
+			return bytecode.Bytecode{}, err
+		}
+		node = n
+	}
+
+	if c.symbols == nil {
+		c.symbols = make(map[*ast.IdentifierLiteral]*Symbol)
+		c.scopes = make(map[*ast.FunctionLiteral]*SymbolTable)
+		c.upvalues = make(map[*ast.FunctionLiteral][]*Symbol)
+		c.types = make(map[ast.Expression]interpreter.Type)
+		c.reads = make(map[*Symbol]bool)
+		c.bindings = make(map[*Symbol]*binding)
+		c.propagations = make(map[*ast.IdentifierLiteral]*binding)
+		c.hostCalls = make(map[*ast.CallExpression]string)
+	}
+	c.diagnostics = nil
+	if c.completion {
+		c.result = completion(node)
 	}
-	return c.bytecode(), nil
+	defer func() {
+		c.result = nil
+		clear(c.symbols)
+		clear(c.scopes)
+		clear(c.upvalues)
+		clear(c.types)
+		clear(c.reads)
+		clear(c.bindings)
+		clear(c.propagations)
+		clear(c.hostCalls)
+		clear(c.declarations)
+		c.errors = nil
+		c.declarations = c.declarations[:0]
+	}()
+
+	if err := c.resolve(node); err != nil {
+		c.report(node, err)
+	}
+	c.diagnose()
+	if len(c.errors) == 0 {
+		if err := c.compile(node); err != nil {
+			c.report(node, err)
+		}
+	}
+	if len(c.errors) > 0 {
+		c.instructions = c.instructions[:0]
+		c.lines = c.lines[:0]
+		c.constants = nil
+		c.functions = nil
+		c.exports = nil
+		c.temporaries = c.temporaries[:0]
+		return bytecode.Bytecode{}, c.errors
+	}
+	return c.optimize(c.bytecode())
+}
+
+func (c *Compiler) Reset() {
+	if c.session != nil {
+		c.symbolTable = c.session.symbolTable
+	} else {
+		c.symbolTable = c.symbolTable.Global()
+		c.symbolTable.Reset()
+	}
+	c.instructions = c.instructions[:0]
+	c.lines = c.lines[:0]
+	c.constants = nil
+	c.functions = nil
+	c.exports = nil
+	c.temporaries = c.temporaries[:0]
+	c.line = 0
 }
 
 func (c *Compiler) compile(node ast.Node) error {
+	if pos := node.Pos(); pos.IsValid() && pos.Line != c.line {
+		line := c.line
+		c.line = pos.Line
+		defer func() { c.line = line }()
+	}
+
 	switch node := node.(type) {
 	case *ast.Program:
 		return c.compileProgram(node)
@@ -92,14 +205,30 @@ func (c *Compiler) compile(node ast.Node) error {
 		return c.compileBlockStatement(node)
 	case *ast.ExpressionStatement:
 		return c.compileExpressionStatement(node)
+	case *ast.Directive:
+		return c.compileDirective(node)
 	case *ast.VariableStatement:
 		return c.compileVariableStatement(node)
+	case *ast.FunctionDeclaration:
+		return nil
+	case *ast.ReturnStatement:
+		return c.compileReturnStatement(node)
 	case *ast.PrefixExpression:
-		return c.compilePrefixExpression(node)
+		return c.compileSubexpression(node, func() error {
+			return c.compilePrefixExpression(node)
+		})
 	case *ast.InfixExpression:
-		return c.compileInfixExpression(node)
+		return c.compileSubexpression(node, func() error {
+			return c.compileInfixExpression(node)
+		})
 	case *ast.AssignmentExpression:
 		return c.compileAssignmentExpression(node)
+	case *ast.CallExpression:
+		return c.compileCallExpression(node)
+	case *ast.MemberExpression:
+		return c.compileMemberExpression(node)
+	case *ast.IndexExpression:
+		return c.compileIndexExpression(node)
 	case *ast.NullLiteral:
 		return c.compileNullLiteral(node)
 	case *ast.UndefinedLiteral:
@@ -112,32 +241,101 @@ func (c *Compiler) compile(node ast.Node) error {
 		return c.compileStringLiteral(node)
 	case *ast.IdentifierLiteral:
 		return c.compileIdentifierLiteral(node)
+	case *ast.FunctionLiteral:
+		return c.compileFunctionLiteral(node)
 	default:
-		return fmt.Errorf("unsupported operand type: %T", node)
+		return c.errorf(node, "unsupported operand type: %T", node)
 	}
 }
 
 func (c *Compiler) bytecode() bytecode.Bytecode {
-	code := bytecode.Bytecode{}
+	code := c.assemble()
+	code.Exports = c.exports
+	c.exports = nil
+	if c.debug && c.source != "" {
+		code.Source = c.source
+		code.SourceHash = sha256.Sum256([]byte(c.source))
+	}
+	return code
+}
+
+func (c *Compiler) assemble() bytecode.Bytecode {
+	size := 0
 	for _, instruction := range c.instructions {
-		code.Instructions = append(code.Instructions, instruction...)
+		size += len(instruction)
 	}
-	for _, constant := range c.constants {
-		code.Constants = append(code.Constants, constant...)
+
+	code := bytecode.Bytecode{Functions: c.functions}
+	if size > 0 {
+		code.Instructions = make([]byte, 0, size)
+	}
+	if len(c.constants) > 0 {
+		code.Constants = slices.Clone(c.constants)
+	}
+	for i, instruction := range c.instructions {
+		if c.debug {
+			code.Mark(c.lines[i])
+		}
+		code.Emit(instruction)
+	}
+	if c.debug {
+		code.Symbols = c.debugSymbols()
 	}
 
-	c.instructions = nil
+	c.instructions = c.instructions[:0]
+	c.lines = c.lines[:0]
 	c.constants = nil
+	c.functions = nil
 	return code
 }
 
+func (c *Compiler) debugSymbols() []bytecode.Symbol {
+	var symbols []bytecode.Symbol
+	visits := map[*Symbol]bool{}
+	for _, sym := range c.symbols {
+		if visits[sym] || sym.slots != c.symbolTable.slots {
+			continue
+		}
+		visits[sym] = true
+		symbols = append(symbols, bytecode.Symbol{Index: sym.Index, Name: sym.Name})
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i].Index < symbols[j].Index
+	})
+	return symbols
+}
+
+func completion(node ast.Node) ast.Statement {
+	switch node := node.(type) {
+	case *ast.Program:
+		if len(node.Statements) > 0 {
+			return completion(node.Statements[len(node.Statements)-1])
+		}
+	case *ast.ExpressionStatement:
+		return node
+	case *ast.Directive:
+		return node
+	default:
+	}
+	return nil
+}
+
 func (c *Compiler) compileProgram(node *ast.Program) error {
-	for _, n := range node.Statements {
+	c.compileStatements(node.Statements)
+	return nil
+}
+
+func (c *Compiler) compileStatements(statements []ast.Statement) {
+	for _, n := range hoisted(statements) {
+		if err := c.compileFunctionDeclaration(n); err != nil {
+			c.report(n, err)
+		}
+	}
+	for _, n := range statements {
 		if err := c.compile(n); err != nil {
-			return err
+			c.report(n, err)
 		}
 	}
-	return nil
 }
 
 func (c *Compiler) compileEmptyStatement(_ *ast.EmptyStatement) error {
@@ -147,33 +345,79 @@ func (c *Compiler) compileEmptyStatement(_ *ast.EmptyStatement) error {
 func (c *Compiler) compileBlockStatement(node *ast.BlockStatement) error {
 	for _, n := range node.Statements {
 		if err := c.compile(n); err != nil {
-			return err
+			c.report(n, err)
 		}
 	}
 	return nil
 }
 
 func (c *Compiler) compileExpressionStatement(node *ast.ExpressionStatement) error {
+	c.eliminate(node.Expression)
+	defer c.eliminate(nil)
+
 	if err := c.compile(node.Expression); err != nil {
 		return err
 	}
-	c.emit(bytecode.POP)
+	if node != c.result {
+		c.emit(bytecode.POP)
+	}
 	return nil
 }
 
+func (c *Compiler) compileDirective(node *ast.Directive) error {
+	if node != c.result {
+		return nil
+	}
+	return c.compile(node.Value)
+}
+
 func (c *Compiler) compileVariableStatement(node *ast.VariableStatement) error {
 	switch node.Token.Type {
 	case token.VAR:
 		for _, n := range node.Right {
+			if c.redundant(n) {
+				continue
+			}
+			c.eliminate(n)
 			if err := c.compile(n); err != nil {
+				c.eliminate(nil)
 				return err
 			}
+			c.eliminate(nil)
 			c.emit(bytecode.POP)
 		}
 		return nil
 	default:
-		return fmt.Errorf("invalid variable token type: %s", node.Token.Type)
+		return c.errorf(node, "invalid variable token type: %s", node.Token.Type)
+	}
+}
+
+func (c *Compiler) compileFunctionDeclaration(node *ast.FunctionDeclaration) error {
+	if err := c.compile(node.Function); err != nil {
+		return err
+	}
+	if len(c.enclosing) == 0 && len(c.upvalues[node.Function]) == 0 {
+		c.exports = append(c.exports, bytecode.Export{Name: node.Function.Name.Value, Function: len(c.functions) - 1})
+	}
+	c.storeSymbol(c.symbols[node.Function.Name])
+	return nil
+}
+
+func (c *Compiler) compileReturnStatement(node *ast.ReturnStatement) error {
+	if node.Value == nil {
+		c.emit(bytecode.UNDEFLOAD)
+		c.emit(bytecode.RET)
+		return nil
+	}
+
+	c.eliminate(node.Value)
+	defer c.eliminate(nil)
+
+	if err := c.compile(node.Value); err != nil {
+		return err
 	}
+	c.emit(bytecode.RET)
+	return nil
 }
 
 func (c *Compiler) compilePrefixExpression(node *ast.PrefixExpression) error {
@@ -202,10 +446,15 @@ func (c *Compiler) compilePrefixExpression(node *ast.PrefixExpression) error {
 		}
 		return nil
 	}
-	return fmt.Errorf("unsupported operator '%s' for types %v", node.Token.Type, right)
+	return c.errorf(node, "unsupported operator '%s' for types %v", node.Token.Type, right)
 }
 
 func (c *Compiler) compileInfixExpression(node *ast.InfixExpression) error {
+	if val, ok := c.fold(node); ok && c.level >= O1 {
+		c.emit(bytecode.STRLOAD, c.store(bytecode.String(interpreter.ToString(val))))
+		return nil
+	}
+
 	typ := c.getType(node)
 	left := c.getType(node.Left)
 	right := c.getType(node.Right)
@@ -261,9 +510,15 @@ func (c *Compiler) compileInfixExpression(node *ast.InfixExpression) error {
 			c.emit(bytecode.STRADD)
 			return nil
 		}
+	case interpreter.ANY:
+		switch node.Token.Type {
+		case token.PLUS:
+			c.emit(bytecode.ADD)
+			return nil
+		}
 	default:
 	}
-	return fmt.Errorf("unsupported operator '%s' for types %v and %v", node.Token.Type, left, right)
+	return c.errorf(node, "unsupported operator '%s' for types %v and %v", node.Token.Type, left, right)
 }
 
 func (c *Compiler) compileAssignmentExpression(node *ast.AssignmentExpression) error {
@@ -271,14 +526,49 @@ func (c *Compiler) compileAssignmentExpression(node *ast.AssignmentExpression) e
 		return err
 	}
 
-	sym, ok := c.symbolTable.Resolve(node.Left.String())
+	sym := c.symbols[node.Left.(*ast.IdentifierLiteral)]
+	c.storeSymbol(sym)
+	c.loadSymbol(sym)
+	return nil
+}
+
+func (c *Compiler) compileCallExpression(node *ast.CallExpression) error {
+	host, ok := c.hostCalls[node]
 	if !ok {
-		sym = c.symbolTable.Define(node.Left.String())
+		if err := c.compile(node.Function); err != nil {
+			return err
+		}
+	}
+	for _, arg := range node.Arguments {
+		if err := c.compile(arg); err != nil {
+			return err
+		}
+	}
+	if ok {
+		c.emit(bytecode.CALLHOST, c.store(bytecode.String(host)), uint64(len(node.Arguments)))
+	} else {
+		c.emit(bytecode.CALL, uint64(len(node.Arguments)))
 	}
-	sym.Type = c.getType(node.Right)
+	return nil
+}
 
-	c.emit(bytecode.SLTSTORE, uint64(sym.Index))
-	c.emit(bytecode.SLTLOAD, uint64(sym.Index))
+func (c *Compiler) compileMemberExpression(node *ast.MemberExpression) error {
+	if err := c.compile(node.Object); err != nil {
+		return err
+	}
+	c.emit(bytecode.STRLOAD, c.store(bytecode.String(node.Property.Value)))
+	c.emit(bytecode.PROPLOAD)
+	return nil
+}
+
+func (c *Compiler) compileIndexExpression(node *ast.IndexExpression) error {
+	if err := c.compile(node.Left); err != nil {
+		return err
+	}
+	if err := c.compile(node.Index); err != nil {
+		return err
+	}
+	c.emit(bytecode.PROPLOAD)
 	return nil
 }
 
@@ -318,53 +608,114 @@ func (c *Compiler) compileNumberLiteral(node *ast.NumberLiteral) error {
 }
 
 func (c *Compiler) compileStringLiteral(node *ast.StringLiteral) error {
-	offset, size := c.store([]byte(node.Value))
-	c.emit(bytecode.STRLOAD, offset, size)
+	c.emit(bytecode.STRLOAD, c.store(bytecode.String(node.Value)))
 	return nil
 }
 
 func (c *Compiler) compileIdentifierLiteral(node *ast.IdentifierLiteral) error {
-	sym, ok := c.symbolTable.Resolve(node.Value)
-	if !ok {
-		return fmt.Errorf("undefined identifier: %s", node.Value)
+	if value, ok := c.propagate(node); ok {
+		return c.compile(value)
 	}
-	c.emit(bytecode.SLTLOAD, uint64(sym.Index))
+	c.loadSymbol(c.symbols[node])
 	return nil
 }
 
-func (c *Compiler) getType(node ast.Expression) interpreter.Type {
-	switch node := node.(type) {
-	case *ast.PrefixExpression:
-		return c.getPrefixExpressionType(node)
-	case *ast.InfixExpression:
-		return c.getInfixExpressionType(node)
-	case *ast.AssignmentExpression:
-		return c.getAssignmentExpression(node)
-	case *ast.NullLiteral:
-		return c.getNullLiteralType(node)
-	case *ast.UndefinedLiteral:
-		return c.getUndefinedLiteralType(node)
-	case *ast.BoolLiteral:
-		return c.getBoolLiteralType(node)
-	case *ast.NumberLiteral:
-		return c.getNumberLiteralType(node)
-	case *ast.StringLiteral:
-		return c.getStringLiteralType(node)
-	case *ast.IdentifierLiteral:
-		return c.getIdentifierLiteralType(node)
+func (c *Compiler) compileFunctionLiteral(node *ast.FunctionLiteral) error {
+	instructions, lines, constants, functions := c.instructions, c.lines, c.constants, c.functions
+	symbolTable, subexpressions, temporaries, result := c.symbolTable, c.subexpressions, c.temporaries, c.result
+	c.instructions, c.lines, c.constants, c.functions = nil, nil, nil, nil
+	c.symbolTable, c.subexpressions, c.temporaries, c.result = c.scopes[node], nil, nil, nil
+	c.enclosing = append(c.enclosing, node)
+
+	for _, param := range node.Parameters {
+		if sym := c.symbols[param]; sym.captured {
+			c.emit(bytecode.SLTLOAD, uint64(sym.Index))
+			c.emit(bytecode.CELLSTORE, uint64(sym.Index))
+		}
+	}
+	c.compileStatements(node.Body.Statements)
+	c.emit(bytecode.UNDEFLOAD)
+	c.emit(bytecode.RET)
+
+	code := c.assemble()
+	c.eliminate(nil)
+	scratch, slots := c.temporaries, c.symbolTable.Size()
+
+	c.instructions, c.lines, c.constants, c.functions = instructions, lines, constants, functions
+	c.symbolTable, c.subexpressions, c.temporaries, c.result = symbolTable, subexpressions, temporaries, result
+	c.enclosing = c.enclosing[:len(c.enclosing)-1]
+
+	upvalues := c.upvalues[node]
+	fn := bytecode.Function{Params: len(node.Parameters), Upvalues: len(upvalues), Code: code}
+	if node.Name != nil {
+		fn.Name = node.Name.Value
+	}
+	fn, err := c.optimizeFunction(fn, slots, scratch)
+	if err != nil {
+		return err
+	}
+
+	if len(upvalues) == 0 {
+		c.emit(bytecode.FUNCLOAD, uint64(len(c.functions)))
+	} else {
+		for _, sym := range upvalues {
+			if sym.Depth == c.symbolTable.depth {
+				c.emit(bytecode.CELLREF, uint64(sym.Index))
+			} else {
+				c.emit(bytecode.UPVREF, uint64(c.upvalue(sym)))
+			}
+		}
+		c.emit(bytecode.CLOSURE, uint64(len(c.functions)), uint64(len(upvalues)))
+	}
+	c.functions = append(c.functions, fn)
+	return nil
+}
+
+func (c *Compiler) loadSymbol(sym *Symbol) {
+	switch {
+	case sym.Depth == c.symbolTable.depth && sym.captured:
+		c.emit(bytecode.CELLLOAD, uint64(sym.Index))
+	case sym.Depth == c.symbolTable.depth:
+		c.emit(bytecode.SLTLOAD, uint64(sym.Index))
+	case sym.Depth == 0:
+		c.emit(bytecode.GLBLOAD, uint64(sym.Index))
 	default:
-		return interpreter.UNKNOWN
+		c.emit(bytecode.UPVLOAD, uint64(c.upvalue(sym)))
+	}
+}
+
+func (c *Compiler) storeSymbol(sym *Symbol) {
+	switch {
+	case sym.Depth == c.symbolTable.depth && sym.captured:
+		c.emit(bytecode.CELLSTORE, uint64(sym.Index))
+	case sym.Depth == c.symbolTable.depth:
+		c.emit(bytecode.SLTSTORE, uint64(sym.Index))
+	case sym.Depth == 0:
+		c.emit(bytecode.GLBSTORE, uint64(sym.Index))
+	default:
+		c.emit(bytecode.UPVSTORE, uint64(c.upvalue(sym)))
 	}
 }
 
+func (c *Compiler) upvalue(sym *Symbol) int {
+	return slices.Index(c.upvalues[c.enclosing[len(c.enclosing)-1]], sym)
+}
+
+func (c *Compiler) getType(node ast.Expression) interpreter.Type {
+	if typ, ok := c.types[node]; ok {
+		return typ
+	}
+	return interpreter.UNKNOWN
+}
+
 func (c *Compiler) getPrefixExpressionType(node *ast.PrefixExpression) interpreter.Type {
 	right := c.getType(node.Right)
 	switch node.Token.Type {
 	case token.PLUS, token.MINUS:
 		switch right {
-		case interpreter.BOOL:
+		case interpreter.NULL, interpreter.BOOL:
 			return interpreter.INT32
-		case interpreter.STRING:
+		case interpreter.UNDEFINED, interpreter.STRING, interpreter.ANY:
 			return interpreter.FLOAT64
 		case interpreter.INT32, interpreter.FLOAT64:
 			return right
@@ -385,7 +736,9 @@ func (c *Compiler) getInfixExpressionType(node *ast.InfixExpression) interpreter
 
 	switch node.Token.Type {
 	case token.PLUS:
-		if left == interpreter.STRING || right == interpreter.STRING {
+		if left == interpreter.ANY || right == interpreter.ANY {
+			return interpreter.ANY
+		} else if left == interpreter.STRING || right == interpreter.STRING || left == interpreter.FUNCTION || right == interpreter.FUNCTION {
 			return interpreter.STRING
 		} else if left == interpreter.FLOAT64 || right == interpreter.FLOAT64 {
 			return interpreter.FLOAT64
@@ -405,22 +758,6 @@ func (c *Compiler) getInfixExpressionType(node *ast.InfixExpression) interpreter
 	}
 }
 
-func (c *Compiler) getAssignmentExpression(node *ast.AssignmentExpression) interpreter.Type {
-	return c.getType(node.Right)
-}
-
-func (c *Compiler) getNullLiteralType(_ *ast.NullLiteral) interpreter.Type {
-	return interpreter.NULL
-}
-
-func (c *Compiler) getUndefinedLiteralType(_ *ast.UndefinedLiteral) interpreter.Type {
-	return interpreter.UNDEFINED
-}
-
-func (c *Compiler) getBoolLiteralType(_ *ast.BoolLiteral) interpreter.Type {
-	return interpreter.BOOL
-}
-
 func (c *Compiler) getNumberLiteralType(node *ast.NumberLiteral) interpreter.Type {
 	if strings.Contains(node.Token.Literal, ".") || strings.Contains(node.Token.Literal, "e") {
 		return interpreter.FLOAT64
@@ -430,42 +767,43 @@ func (c *Compiler) getNumberLiteralType(node *ast.NumberLiteral) interpreter.Typ
 	return interpreter.INT32
 }
 
-func (c *Compiler) getStringLiteralType(_ *ast.StringLiteral) interpreter.Type {
-	return interpreter.STRING
-}
-
-func (c *Compiler) getIdentifierLiteralType(node *ast.IdentifierLiteral) interpreter.Type {
-	sym, ok := c.symbolTable.Resolve(node.Value)
-	if !ok {
-		return interpreter.UNDEFINED
-	}
-	return sym.Type
-}
-
 func (c *Compiler) cast(from, to interpreter.Type) error {
-	if from == to {
+	if from == to || to == interpreter.ANY {
 		return nil
 	}
 	if instructions := casts[from][to]; len(instructions) > 0 {
-		c.instructions = append(c.instructions, instructions...)
+		for _, inst := range instructions {
+			c.instructions = append(c.instructions, inst)
+			c.lines = append(c.lines, c.line)
+		}
 		return nil
 	}
-	// TODO: dynamic cast
-	return fmt.Errorf("no cast path found from %v to %v", from, to)
+
+	switch to {
+	case interpreter.BOOL:
+		c.emit(bytecode.TOBOOL)
+	case interpreter.INT32:
+		c.emit(bytecode.TONUM)
+		c.emit(bytecode.F64TOI32)
+	case interpreter.FLOAT64:
+		c.emit(bytecode.TONUM)
+	case interpreter.STRING:
+		c.emit(bytecode.TOSTR)
+	default:
+		return fmt.Errorf("no cast path found from %v to %v", from, to)
+	}
+	return nil
 }
 
 func (c *Compiler) emit(op bytecode.Opcode, operands ...uint64) {
 	c.instructions = append(c.instructions, bytecode.New(op, operands...))
+	c.lines = append(c.lines, c.line)
 }
 
-func (c *Compiler) store(val []byte) (uint64, uint64) {
-	offset := 0
-	for _, v := range c.constants {
-		if bytes.Equal(v[:len(v)-1], val) {
-			return uint64(offset), uint64(len(v) - 1)
-		}
-		offset += len(v)
+func (c *Compiler) store(val bytecode.Constant) uint64 {
+	if idx := slices.Index(c.constants, val); idx >= 0 {
+		return uint64(idx)
 	}
-	c.constants = append(c.constants, append(val, 0))
-	return uint64(offset), uint64(len(val))
+	c.constants = append(c.constants, val)
+	return uint64(len(c.constants) - 1)
 }
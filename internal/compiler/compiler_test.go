@@ -1,11 +1,17 @@
 package compiler
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"math"
+	"strings"
 	"testing"
 
 	"github.com/siyul-park/minijs/internal/ast"
 	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/siyul-park/minijs/internal/interpreter"
+	"github.com/siyul-park/minijs/internal/lexer"
+	"github.com/siyul-park/minijs/internal/parser"
 	"github.com/siyul-park/minijs/internal/token"
 
 	"github.com/stretchr/testify/assert"
@@ -13,6 +19,8 @@ import (
 
 func TestCompiler_Compile(t *testing.T) {
 	tests := []struct {
+		level        Level
+		globals      []string
 		node         ast.Node
 		instructions []bytecode.Instruction
 		literals     []string
@@ -159,7 +167,7 @@ func TestCompiler_Compile(t *testing.T) {
 			instructions: []bytecode.Instruction{
 				bytecode.New(bytecode.I32LOAD, 1),
 				bytecode.New(bytecode.I32TOSTR),
-				bytecode.New(bytecode.STRLOAD, 0, 1),
+				bytecode.New(bytecode.STRLOAD, 0),
 				bytecode.New(bytecode.STRADD),
 			},
 			literals: []string{"2"},
@@ -250,7 +258,7 @@ func TestCompiler_Compile(t *testing.T) {
 			instructions: []bytecode.Instruction{
 				bytecode.New(bytecode.F64LOAD, math.Float64bits(1)),
 				bytecode.New(bytecode.F64TOSTR),
-				bytecode.New(bytecode.STRLOAD, 0, 1),
+				bytecode.New(bytecode.STRLOAD, 0),
 				bytecode.New(bytecode.STRADD),
 			},
 			literals: []string{"2"},
@@ -307,7 +315,7 @@ func TestCompiler_Compile(t *testing.T) {
 		{
 			node: ast.NewStringLiteral(token.Token{Type: token.STRING, Literal: "abc"}, "abc"),
 			instructions: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 3),
+				bytecode.New(bytecode.STRLOAD, 0),
 			},
 			literals: []string{"abc"},
 		},
@@ -318,13 +326,14 @@ func TestCompiler_Compile(t *testing.T) {
 				ast.NewStringLiteral(token.Token{Type: token.STRING, Literal: "bar"}, "bar"),
 			),
 			instructions: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 3),
-				bytecode.New(bytecode.STRLOAD, 4, 3),
+				bytecode.New(bytecode.STRLOAD, 0),
+				bytecode.New(bytecode.STRLOAD, 1),
 				bytecode.New(bytecode.STRADD),
 			},
 			literals: []string{"foo", "bar"},
 		},
 		{
+			globals: []string{"foo"},
 			node: ast.NewExpressionStatement(
 				ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
 			),
@@ -393,16 +402,162 @@ func TestCompiler_Compile(t *testing.T) {
 				bytecode.New(bytecode.POP),
 			},
 		},
+		{
+			level:   O1,
+			globals: []string{"a", "b"},
+			node: ast.NewExpressionStatement(
+				ast.NewInfixExpression(
+					token.New(token.PLUS, "+"),
+					ast.NewInfixExpression(
+						token.New(token.MULTIPLY, "*"),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "a"), "a"),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "b"), "b"),
+					),
+					ast.NewInfixExpression(
+						token.New(token.MULTIPLY, "*"),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "a"), "a"),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "b"), "b"),
+					),
+				),
+			),
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.UNDEFTOF64),
+				bytecode.New(bytecode.SLTLOAD, 1),
+				bytecode.New(bytecode.UNDEFTOF64),
+				bytecode.New(bytecode.F64MUL),
+				bytecode.New(bytecode.DUP),
+				bytecode.New(bytecode.SLTSTORE, 2),
+				bytecode.New(bytecode.SLTLOAD, 2),
+				bytecode.New(bytecode.F64ADD),
+				bytecode.New(bytecode.POP),
+			},
+		},
+		{
+			level:   O1,
+			globals: []string{"foo"},
+			node: ast.NewExpressionStatement(
+				ast.NewInfixExpression(
+					token.New(token.PLUS, "+"),
+					ast.NewInfixExpression(
+						token.New(token.PLUS, "+"),
+						ast.NewStringLiteral(token.New(token.STRING, "a"), "a"),
+						ast.NewInfixExpression(
+							token.New(token.PLUS, "+"),
+							ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+							ast.NewStringLiteral(token.New(token.STRING, "a"), "a"),
+						),
+					),
+					ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+				),
+			),
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.STRLOAD, 0),
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.UNDEFTOSTR),
+				bytecode.New(bytecode.STRADD),
+				bytecode.New(bytecode.POP),
+			},
+			literals: []string{"a1a"},
+		},
+		{
+			level: O2,
+			node: ast.NewProgram(
+				ast.NewVariableStatement(
+					token.New(token.VAR, "var"),
+					ast.NewAssignmentExpression(
+						token.New(token.ASSIGN, "="),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+						ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+					),
+				),
+				ast.NewExpressionStatement(
+					ast.NewInfixExpression(
+						token.New(token.PLUS, "+"),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+						ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "2"}, 2),
+					),
+				),
+			),
+			instructions: nil,
+		},
+		{
+			level: O1,
+			node: ast.NewProgram(
+				ast.NewVariableStatement(
+					token.New(token.VAR, "var"),
+					ast.NewAssignmentExpression(
+						token.New(token.ASSIGN, "="),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+						ast.NewStringLiteral(token.New(token.STRING, "a"), "a"),
+					),
+				),
+				ast.NewExpressionStatement(
+					ast.NewInfixExpression(
+						token.New(token.PLUS, "+"),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+						ast.NewStringLiteral(token.New(token.STRING, "b"), "b"),
+					),
+				),
+			),
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.STRLOAD, 0),
+				bytecode.New(bytecode.POP),
+			},
+			literals: []string{"ab"},
+		},
+		{
+			level: O1,
+			node: ast.NewProgram(
+				ast.NewVariableStatement(
+					token.New(token.VAR, "var"),
+					ast.NewAssignmentExpression(
+						token.New(token.ASSIGN, "="),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+						ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+					),
+				),
+				ast.NewExpressionStatement(
+					ast.NewAssignmentExpression(
+						token.New(token.ASSIGN, "="),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+						ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "2"}, 2),
+					),
+				),
+				ast.NewExpressionStatement(
+					ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+				),
+			),
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.SLTSTORE, 0),
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.POP),
+				bytecode.New(bytecode.I32LOAD, 2),
+				bytecode.New(bytecode.SLTSTORE, 0),
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.POP),
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.POP),
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.node.String(), func(t *testing.T) {
-			compiler := New()
+			compiler := New(WithOptimization(tt.level))
+			if len(tt.globals) > 0 {
+				session := NewSession()
+				for _, name := range tt.globals {
+					session.SymbolTable().Define(name).Type = interpreter.UNDEFINED
+				}
+				WithSession(session)(compiler)
+			}
 
 			expected := bytecode.Bytecode{}
 			expected.Emit(tt.instructions...)
 			for _, c := range tt.literals {
-				expected.Store([]byte(c + "\x00"))
+				expected.Store(bytecode.String(c))
 			}
 
 			actual, err := compiler.Compile(tt.node)
@@ -411,3 +566,858 @@ func TestCompiler_Compile(t *testing.T) {
 		})
 	}
 }
+
+func TestCompiler_Cast(t *testing.T) {
+	tests := []struct {
+		from         interpreter.Type
+		to           interpreter.Type
+		instructions []bytecode.Instruction
+	}{
+		{
+			from:         interpreter.FLOAT64,
+			to:           interpreter.BOOL,
+			instructions: []bytecode.Instruction{bytecode.New(bytecode.TOBOOL)},
+		},
+		{
+			from:         interpreter.UNDEFINED,
+			to:           interpreter.INT32,
+			instructions: []bytecode.Instruction{bytecode.New(bytecode.TONUM), bytecode.New(bytecode.F64TOI32)},
+		},
+		{
+			from:         interpreter.BOOL,
+			to:           interpreter.FLOAT64,
+			instructions: []bytecode.Instruction{bytecode.New(bytecode.BOOLTOI32), bytecode.New(bytecode.I32TOF64)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from.String()+"->"+tt.to.String(), func(t *testing.T) {
+			compiler := New()
+
+			err := compiler.cast(tt.from, tt.to)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.instructions, compiler.instructions)
+		})
+	}
+}
+
+func TestCompiler_Compile_Any(t *testing.T) {
+	compiler := New()
+	compiler.symbolTable.Define("x").Type = interpreter.ANY
+
+	node := ast.NewExpressionStatement(
+		ast.NewInfixExpression(
+			token.New(token.MINUS, "-"),
+			ast.NewInfixExpression(
+				token.New(token.PLUS, "+"),
+				ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "x"), "x"),
+				ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+			),
+			ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+		),
+	)
+
+	expected := bytecode.Bytecode{}
+	expected.Emit(
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.ADD),
+		bytecode.New(bytecode.TONUM),
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32TOF64),
+		bytecode.New(bytecode.F64SUB),
+		bytecode.New(bytecode.POP),
+	)
+
+	actual, err := compiler.Compile(node)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), actual.String())
+}
+
+func TestCompiler_Compile_Hoist(t *testing.T) {
+	compiler := New()
+
+	node := ast.NewProgram(
+		ast.NewExpressionStatement(
+			ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+		),
+		ast.NewBlockStatement(
+			ast.NewVariableStatement(
+				token.New(token.VAR, "var"),
+				ast.NewAssignmentExpression(
+					token.New(token.ASSIGN, "="),
+					ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+					ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+				),
+			),
+		),
+	)
+
+	expected := bytecode.Bytecode{}
+	expected.Emit(
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.POP),
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.SLTSTORE, 0),
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.POP),
+	)
+
+	actual, err := compiler.Compile(node)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), actual.String())
+}
+
+func TestCompiler_Compile_InvalidAssignment(t *testing.T) {
+	compiler := New()
+
+	node := ast.NewProgram(
+		ast.NewExpressionStatement(
+			ast.NewAssignmentExpression(
+				token.New(token.ASSIGN, "="),
+				ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+				ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "2"}, 2),
+			),
+		),
+		ast.NewExpressionStatement(
+			ast.NewAssignmentExpression(
+				token.New(token.ASSIGN, "="),
+				ast.NewStringLiteral(token.Token{Type: token.STRING, Literal: "\"foo\""}, "foo"),
+				ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "2"}, 2),
+			),
+		),
+	)
+
+	_, err := compiler.Compile(node)
+
+	var errs Errors
+	assert.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 2)
+}
+
+func TestCompiler_Compile_Undefined(t *testing.T) {
+	tests := []string{
+		"foo",
+		"var a = foo + 1",
+		"function f() { return foo } f()",
+	}
+
+	for _, source := range tests {
+		t.Run(source, func(t *testing.T) {
+			program, err := parser.New(lexer.New(strings.NewReader(source))).Parse()
+			assert.NoError(t, err)
+
+			_, err = New().Compile(program)
+			assert.ErrorContains(t, err, "undefined identifier: foo")
+			assert.NotContains(t, err.Error(), "foo: foo")
+		})
+	}
+
+	program, err := parser.New(lexer.New(strings.NewReader("foo = 1; foo"))).Parse()
+	assert.NoError(t, err)
+
+	_, err = New().Compile(program)
+	assert.NoError(t, err)
+}
+
+func TestCompiler_Compile_DebugInfo(t *testing.T) {
+	session := NewSession()
+	session.SymbolTable().Define("foo")
+	compiler := New(WithSession(session), WithDebugInfo(true), WithSource("1;\n\nfoo;"))
+
+	node := ast.NewProgram(
+		ast.NewExpressionStatement(
+			ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1", Start: token.Pos{Line: 1, Column: 1}}, 1),
+		),
+		ast.NewExpressionStatement(
+			ast.NewIdentifierLiteral(token.Token{Type: token.IDENTIFIER, Literal: "foo", Start: token.Pos{Line: 3, Column: 1}}, "foo"),
+		),
+	)
+
+	code, err := compiler.Compile(node)
+	assert.NoError(t, err)
+	assert.Equal(t, []bytecode.Line{{Offset: 0, Line: 1}, {Offset: 6, Line: 3}}, code.Lines)
+	assert.Equal(t, []bytecode.Symbol{{Index: 0, Name: "foo"}}, code.Symbols)
+	assert.Equal(t, "1;\n\nfoo;", code.Source)
+	assert.Equal(t, sha256.Sum256([]byte("1;\n\nfoo;")), code.SourceHash)
+
+	compiler = New(WithSession(session))
+
+	code, err = compiler.Compile(node)
+	assert.NoError(t, err)
+	assert.Empty(t, code.Lines)
+	assert.Empty(t, code.Symbols)
+	assert.Empty(t, code.Source)
+}
+
+func TestCompiler_Diagnostics(t *testing.T) {
+	compiler := New()
+
+	node := ast.NewProgram(
+		ast.NewVariableStatement(
+			token.New(token.VAR, "var"),
+			ast.NewAssignmentExpression(
+				token.New(token.ASSIGN, "="),
+				ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+				ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+			),
+			ast.NewAssignmentExpression(
+				token.New(token.ASSIGN, "="),
+				ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "bar"), "bar"),
+				ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "2"}, 2),
+			),
+		),
+		ast.NewExpressionStatement(
+			ast.NewAssignmentExpression(
+				token.New(token.ASSIGN, "="),
+				ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "baz"), "baz"),
+				ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "bar"), "bar"),
+			),
+		),
+		ast.NewExpressionStatement(
+			ast.NewInfixExpression(
+				token.New(token.PLUS, "+"),
+				ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+				ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "2"}, 2),
+			),
+		),
+	)
+
+	_, err := compiler.Compile(node)
+	assert.NoError(t, err)
+
+	var messages []string
+	for _, d := range compiler.Diagnostics() {
+		messages = append(messages, d.String())
+	}
+	assert.Equal(t, []string{
+		"expression statement has no effect",
+		"'foo' is declared but its value is never read",
+	}, messages)
+}
+
+func TestCompiler_Compile_Strict(t *testing.T) {
+	compiler := New(WithStrict(true))
+
+	node := ast.NewProgram(
+		ast.NewVariableStatement(
+			token.New(token.VAR, "var"),
+			ast.NewAssignmentExpression(
+				token.New(token.ASSIGN, "="),
+				ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+				ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+			),
+		),
+		ast.NewExpressionStatement(
+			ast.NewAssignmentExpression(
+				token.New(token.ASSIGN, "="),
+				ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+				ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "2"}, 2),
+			),
+		),
+	)
+
+	_, err := compiler.Compile(node)
+	assert.NoError(t, err)
+
+	node = ast.NewProgram(
+		ast.NewExpressionStatement(
+			ast.NewAssignmentExpression(
+				token.New(token.ASSIGN, "="),
+				ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "bar"), "bar"),
+				ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+			),
+		),
+	)
+
+	_, err = compiler.Compile(node)
+	assert.ErrorContains(t, err, "assignment to undeclared variable 'bar'")
+	node = ast.NewProgram(
+		ast.NewExpressionStatement(
+			ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "010"}, 8),
+		),
+	)
+
+	_, err = compiler.Compile(node)
+	assert.ErrorContains(t, err, "octal literals are not allowed in strict mode, use 0o10 instead")
+
+	node = ast.NewProgram(
+		ast.NewExpressionStatement(
+			ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "09"}, 9),
+		),
+	)
+
+	_, err = compiler.Compile(node)
+	assert.ErrorContains(t, err, "decimals with leading zeros are not allowed in strict mode")
+}
+
+func TestCompiler_Compile_Directive(t *testing.T) {
+	tests := []struct {
+		source string
+		err    string
+	}{
+		{source: `"use strict"; bar = 1`, err: "assignment to undeclared variable 'bar'"},
+		{source: `"foo"; "use strict"; 010`, err: "octal literals are not allowed in strict mode"},
+		{source: `function f() { "use strict"; bar = 1 }`, err: "assignment to undeclared variable 'bar'"},
+		{source: `function f() { "use strict" } bar = 1`},
+		{source: `bar = 1; "use strict"; baz = 2`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			program, err := parser.New(lexer.New(strings.NewReader(tt.source))).Parse()
+			assert.NoError(t, err)
+
+			_, err = New().Compile(program)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	node := ast.NewProgram(
+		ast.NewDirective(
+			ast.NewStringLiteral(token.New(token.STRING, "use strict"), "use strict"),
+		),
+	)
+
+	code, err := New().Compile(node)
+	assert.NoError(t, err)
+	assert.Empty(t, code.Instructions)
+
+	code, err = New(WithCompletionValue(true)).Compile(node)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, code.Instructions)
+}
+
+func TestCompiler_Compile_CompletionValue(t *testing.T) {
+	compiler := New(WithCompletionValue(true))
+
+	node := ast.NewProgram(
+		ast.NewExpressionStatement(
+			ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+		),
+		ast.NewExpressionStatement(
+			ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "2"}, 2),
+		),
+	)
+
+	expected := bytecode.Bytecode{}
+	expected.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.POP),
+		bytecode.New(bytecode.I32LOAD, 2),
+	)
+
+	actual, err := compiler.Compile(node)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), actual.String())
+}
+
+func TestCompiler_Compile_Transform(t *testing.T) {
+	compiler := New(WithTransform(func(node ast.Node) (ast.Node, error) {
+		if n, ok := node.(*ast.ExpressionStatement); ok {
+			return ast.NewExpressionStatement(
+				ast.NewPrefixExpression(token.New(token.MINUS, "-"), n.Expression),
+			), nil
+		}
+		return node, nil
+	}))
+
+	node := ast.NewExpressionStatement(
+		ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+	)
+
+	expected := bytecode.Bytecode{}
+	expected.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32LOAD, uint64(0xFFFFFFFFFFFFFFFF)),
+		bytecode.New(bytecode.I32MUL),
+		bytecode.New(bytecode.POP),
+	)
+
+	actual, err := compiler.Compile(node)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), actual.String())
+}
+
+func TestCompiler_Compile_Function(t *testing.T) {
+	compiler := New()
+
+	node := ast.NewProgram(
+		ast.NewExpressionStatement(
+			ast.NewCallExpression(
+				token.New(token.OPEN_PAREN, "("),
+				ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+				ast.NewNumberLiteral(token.Token{Type: token.NUMBER, Literal: "1"}, 1),
+			),
+		),
+		ast.NewFunctionDeclaration(
+			ast.NewFunctionLiteral(
+				token.New(token.FUNCTION, "function"),
+				ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+				[]*ast.IdentifierLiteral{
+					ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "bar"), "bar"),
+				},
+				ast.NewBlockStatement(
+					ast.NewReturnStatement(
+						token.New(token.RETURN, "return"),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "bar"), "bar"),
+					),
+				),
+			),
+		),
+	)
+
+	fn := bytecode.Bytecode{}
+	fn.Emit(
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.RET),
+		bytecode.New(bytecode.UNDEFLOAD),
+		bytecode.New(bytecode.RET),
+	)
+
+	expected := bytecode.Bytecode{
+		Functions: []bytecode.Function{{Name: "foo", Params: 1, Code: fn}},
+		Exports:   []bytecode.Export{{Name: "foo", Function: 0}},
+	}
+	expected.Emit(
+		bytecode.New(bytecode.FUNCLOAD, 0),
+		bytecode.New(bytecode.SLTSTORE, 0),
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.CALL, 1),
+		bytecode.New(bytecode.POP),
+	)
+
+	actual, err := compiler.Compile(node)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), actual.String())
+}
+
+func TestCompiler_Compile_Closure(t *testing.T) {
+	program, err := parser.New(lexer.New(strings.NewReader("function foo(bar) { return function() { return bar; }; }"))).Parse()
+	assert.NoError(t, err)
+
+	inner := bytecode.Bytecode{}
+	inner.Emit(
+		bytecode.New(bytecode.UPVLOAD, 0),
+		bytecode.New(bytecode.RET),
+		bytecode.New(bytecode.UNDEFLOAD),
+		bytecode.New(bytecode.RET),
+	)
+
+	outer := bytecode.Bytecode{
+		Functions: []bytecode.Function{{Upvalues: 1, Code: inner}},
+	}
+	outer.Emit(
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.CELLSTORE, 0),
+		bytecode.New(bytecode.CELLREF, 0),
+		bytecode.New(bytecode.CLOSURE, 0, 1),
+		bytecode.New(bytecode.RET),
+		bytecode.New(bytecode.UNDEFLOAD),
+		bytecode.New(bytecode.RET),
+	)
+
+	expected := bytecode.Bytecode{
+		Functions: []bytecode.Function{{Name: "foo", Params: 1, Code: outer}},
+		Exports:   []bytecode.Export{{Name: "foo", Function: 0}},
+	}
+	expected.Emit(
+		bytecode.New(bytecode.FUNCLOAD, 0),
+		bytecode.New(bytecode.SLTSTORE, 0),
+	)
+
+	actual, err := New().Compile(program)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), actual.String())
+}
+
+func TestCompiler_Compile_Call(t *testing.T) {
+	tests := []struct {
+		source string
+		expect interpreter.Value
+	}{
+		{
+			source: "function add(a, b) { return a + b; } add(1, 2)",
+			expect: interpreter.Int32(3),
+		},
+		{
+			source: "var f = function(a) { var b = a * 2; return b; }; f(2) + 1",
+			expect: interpreter.Float64(5),
+		},
+		{
+			source: "function f() { return; } f()",
+			expect: interpreter.Undefined{},
+		},
+		{
+			source: "function f(a) { return a; } f()",
+			expect: interpreter.Undefined{},
+		},
+		{
+			source: "var x = 1; function f() { return x; } x = 2; f()",
+			expect: interpreter.Int32(2),
+		},
+		{
+			source: "var x = 1; function f() { x = \"a\"; } f(); x + 1",
+			expect: interpreter.String("a1"),
+		},
+		{
+			source: "function f(n) { return g(n) + 1; } function g(n) { return n * 2; } f(3)",
+			expect: interpreter.Float64(7),
+		},
+		{
+			source: "function counter() { var n = 0; return function() { n = n + 1; return n; }; } var c = counter(); c(); c()",
+			expect: interpreter.Int32(2),
+		},
+		{
+			source: "function outer(a) { function inner() { return function() { return a; }; } return inner()(); } outer(5)",
+			expect: interpreter.Int32(5),
+		},
+	}
+
+	for _, tt := range tests {
+		for _, level := range []Level{O0, O1, O2} {
+			t.Run(tt.source, func(t *testing.T) {
+				program, err := parser.New(lexer.New(strings.NewReader(tt.source))).Parse()
+				assert.NoError(t, err)
+
+				code, err := New(WithOptimization(level), WithCompletionValue(true)).Compile(program)
+				assert.NoError(t, err)
+
+				i := interpreter.New()
+				err = i.Execute(code)
+				assert.NoError(t, err)
+
+				val, err := i.Pop()
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, val)
+			})
+		}
+	}
+}
+
+func TestCompiler_Compile_Fold(t *testing.T) {
+	tests := []struct {
+		source string
+		expect interpreter.Value
+	}{
+		{
+			source: "'b'+'a'+ +'a'+'a'",
+			expect: interpreter.String("baNaNa"),
+		},
+		{
+			source: ".0%0",
+			expect: interpreter.Float64(math.NaN()),
+		},
+	}
+
+	for _, tt := range tests {
+		for _, level := range []Level{O0, O1, O2} {
+			t.Run(tt.source, func(t *testing.T) {
+				program, err := parser.New(lexer.New(strings.NewReader(tt.source))).Parse()
+				assert.NoError(t, err)
+
+				code, err := New(WithOptimization(level), WithCompletionValue(true)).Compile(program)
+				assert.NoError(t, err)
+
+				i := interpreter.New()
+				err = i.Execute(code)
+				assert.NoError(t, err)
+
+				val, err := i.Pop()
+				assert.NoError(t, err)
+				assert.Equal(t, fmt.Sprint(tt.expect), fmt.Sprint(val))
+			})
+		}
+	}
+}
+
+func TestCompiler_Compile_Subexpression(t *testing.T) {
+	tests := []struct {
+		source string
+		expect interpreter.Value
+	}{
+		{
+			source: "var x = 3; x * x + x * x",
+			expect: interpreter.Int32(18),
+		},
+		{
+			source: "function f(a) { return a * a + a * a }; f(3)",
+			expect: interpreter.Float64(18),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			program, err := parser.New(lexer.New(strings.NewReader(tt.source))).Parse()
+			assert.NoError(t, err)
+
+			code, err := New(WithOptimization(O2), WithCompletionValue(true)).Compile(program)
+			assert.NoError(t, err)
+
+			segments := []bytecode.Bytecode{code}
+			for _, fn := range code.Functions {
+				segments = append(segments, fn.Code)
+			}
+			for _, segment := range segments {
+				stores := map[int]bool{}
+				loads := map[int]bool{}
+				for _, inst := range segment.Iter() {
+					switch inst.Opcode() {
+					case bytecode.SLTSTORE:
+						stores[int(inst.Operands()[0])] = true
+					case bytecode.SLTLOAD:
+						loads[int(inst.Operands()[0])] = true
+					default:
+					}
+				}
+				// Slot 0 holds the variable each program declares.
+				for slot := range stores {
+					if slot > 0 {
+						assert.True(t, loads[slot], "slot %d is stored but never loaded", slot)
+					}
+				}
+			}
+
+			i := interpreter.New()
+			err = i.Execute(code)
+			assert.NoError(t, err)
+
+			val, err := i.Pop()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, val)
+		})
+	}
+}
+
+func TestCompiler_Compile_Host(t *testing.T) {
+	tests := []struct {
+		source string
+		expect interpreter.Value
+	}{
+		{source: "double(21)", expect: interpreter.Int32(42)},
+		{source: "function f(n) { return double(n) + 1; } f(2)", expect: interpreter.Int32(5)},
+		{source: "function double(n) { return n; } double(1)", expect: interpreter.Int32(1)},
+		{source: "var double = function(n) { return -n; }; double(1)", expect: interpreter.Float64(-1)},
+		{source: "double(1); double = 0; double", expect: interpreter.Int32(0)},
+	}
+
+	host := interpreter.WithHost("double", func(args []interpreter.Value) (interpreter.Value, error) {
+		return args[0].(interpreter.Int32) * 2, nil
+	})
+
+	for _, tt := range tests {
+		for _, level := range []Level{O0, O1, O2} {
+			t.Run(tt.source, func(t *testing.T) {
+				program, err := parser.New(lexer.New(strings.NewReader(tt.source))).Parse()
+				assert.NoError(t, err)
+
+				code, err := New(WithOptimization(level), WithCompletionValue(true), WithHosts("double")).Compile(program)
+				assert.NoError(t, err)
+
+				i := interpreter.New(host)
+				err = i.Execute(code)
+				assert.NoError(t, err)
+
+				val, err := i.Pop()
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, val)
+			})
+		}
+	}
+}
+
+func TestCompiler_Compile_Return(t *testing.T) {
+	compiler := New()
+
+	node := ast.NewProgram(
+		ast.NewReturnStatement(token.New(token.RETURN, "return"), nil),
+	)
+
+	_, err := compiler.Compile(node)
+	assert.ErrorContains(t, err, "illegal return statement")
+}
+
+func TestCompiler_Reset(t *testing.T) {
+	compiler := New()
+
+	node, err := parser.New(lexer.New(strings.NewReader("var foo = 1; foo"))).Parse()
+	assert.NoError(t, err)
+
+	expected, err := compiler.Compile(node)
+	assert.NoError(t, err)
+
+	compiler.Reset()
+
+	actual, err := compiler.Compile(node)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, 1, compiler.symbolTable.Size())
+}
+
+func TestCompiler_Compile_Allocs(t *testing.T) {
+	compiler := New(WithOptimization(O1))
+
+	node, err := parser.New(lexer.New(strings.NewReader("var foo = 1; var bar = foo + 2; function baz(x) { return x * bar }; baz(foo)"))).Parse()
+	assert.NoError(t, err)
+
+	compile := func() {
+		_, _ = compiler.Compile(node)
+		compiler.Reset()
+	}
+	compile()
+
+	expected := testing.AllocsPerRun(10, compile)
+	for i := 0; i < 100; i++ {
+		compile()
+	}
+	actual := testing.AllocsPerRun(10, compile)
+	assert.Equal(t, expected, actual)
+}
+
+// programs are the small programs compiled again and again by the benchmarks
+// and the allocation tests.
+var programs = []string{
+	"1 + 2",
+	"var foo = 1; foo + 2",
+	"var foo = 'a'; foo + 'b' + 1",
+	"var foo = 1; foo = foo * 2; foo - 1",
+	"function foo(x, y) { return x + y }; foo(1, 2)",
+	"var foo = 0; function bar() { foo = foo + 1; return foo }; bar()",
+}
+
+func TestCompiler_Compile_SteadyAllocs(t *testing.T) {
+	for _, level := range []Level{O0, O1, O2} {
+		for _, source := range programs {
+			node, err := parser.New(lexer.New(strings.NewReader(source))).Parse()
+			assert.NoError(t, err)
+
+			t.Run(fmt.Sprintf("O%d/%s", level, source), func(t *testing.T) {
+				compiler := New(WithOptimization(level))
+
+				compile := func() {
+					_, _ = compiler.Compile(node)
+					compiler.Reset()
+				}
+				for i := 0; i < 10; i++ {
+					compile()
+				}
+
+				expected := testing.AllocsPerRun(100, compile)
+				for i := 0; i < 1000; i++ {
+					compile()
+				}
+				actual := testing.AllocsPerRun(100, compile)
+				assert.Equal(t, expected, actual)
+			})
+		}
+	}
+}
+
+func BenchmarkCompiler_Compile(b *testing.B) {
+	for _, level := range []Level{O0, O1, O2} {
+		for _, source := range programs {
+			node, err := parser.New(lexer.New(strings.NewReader(source))).Parse()
+			assert.NoError(b, err)
+
+			b.Run(fmt.Sprintf("O%d/%s", level, source), func(b *testing.B) {
+				compiler := New(WithOptimization(level))
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					_, _ = compiler.Compile(node)
+					compiler.Reset()
+				}
+			})
+		}
+	}
+}
+
+func TestCompiler_Compile_Link(t *testing.T) {
+	session := NewSession()
+
+	var units []bytecode.Bytecode
+	for _, source := range []string{
+		"var foo = 'a'; function bar() { return foo + 'c' }",
+		"var baz = foo + 'b'; baz + bar()",
+	} {
+		program, err := parser.New(lexer.New(strings.NewReader(source))).Parse()
+		assert.NoError(t, err)
+
+		compiler := New(WithSession(session), WithDebugInfo(true), WithCompletionValue(true))
+		code, err := compiler.Compile(program)
+		assert.NoError(t, err)
+		units = append(units, code)
+	}
+
+	code, err := bytecode.Link(units...)
+	assert.NoError(t, err)
+
+	i := interpreter.New()
+	err = i.Execute(code)
+	assert.NoError(t, err)
+
+	val, err := i.Pop()
+	assert.NoError(t, err)
+	assert.Equal(t, interpreter.String("abac"), val)
+}
+
+func TestCompiler_Compile_Exports(t *testing.T) {
+	for _, level := range []Level{O0, O1, O2} {
+		t.Run(fmt.Sprint(level), func(t *testing.T) {
+			program, err := parser.New(lexer.New(strings.NewReader("var base = 10; function add(a, b) { return a + b + base }"))).Parse()
+			assert.NoError(t, err)
+
+			compiler := New(WithOptimization(level))
+			code, err := compiler.Compile(program)
+			assert.NoError(t, err)
+			assert.Equal(t, []bytecode.Export{{Name: "add", Function: 0}}, code.Exports)
+
+			i := interpreter.New()
+			err = i.Execute(code)
+			assert.NoError(t, err)
+
+			val, err := i.Call(code, "add", interpreter.Int32(1), interpreter.Int32(2))
+			assert.NoError(t, err)
+			assert.Equal(t, interpreter.Int32(13), val)
+		})
+	}
+}
+
+func FuzzCompileAndRun(f *testing.F) {
+	for _, source := range []string{
+		"var foo = 1 + 2 * 3; foo",
+		"function add(a, b) { return a + b; } add(1, 'a')",
+		"var foo = 0; function bar() { foo = foo + 1; return foo }; bar()",
+		"function f(n) { return n < 2 ? n : f(n - 1) + f(n - 2) } f(10)",
+		"var s = 'a' + `b${1}c`; s + s",
+		"-(1 >>> 2) % 0 / undefined",
+		"'b'+'a'+ +'a'+'a'",
+		".0%0",
+	} {
+		f.Add(source)
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		program, err := parser.New(lexer.NewBytes([]byte(source))).Parse()
+		if err != nil {
+			return
+		}
+
+		for _, level := range []Level{O0, O1, O2} {
+			code, err := New(WithOptimization(level)).Compile(program)
+			if err != nil {
+				continue
+			}
+			assert.NoError(t, code.Validate())
+
+			_, _ = interpreter.New().ExecuteN(code, 10_000)
+		}
+	})
+}
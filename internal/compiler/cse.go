@@ -0,0 +1,107 @@
+package compiler
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/siyul-park/minijs/internal/ast"
+	"github.com/siyul-park/minijs/internal/bytecode"
+)
+
+type subexpression struct {
+	count  int
+	symbol *Symbol
+}
+
+func (c *Compiler) eliminate(node ast.Expression) {
+	var temporaries []*Symbol
+	for _, sub := range c.subexpressions {
+		if sub.symbol != nil {
+			temporaries = append(temporaries, sub.symbol)
+		}
+	}
+	sort.Slice(temporaries, func(i, j int) bool {
+		return temporaries[i].Index > temporaries[j].Index
+	})
+	for _, sym := range temporaries {
+		c.symbolTable.Release(sym)
+	}
+	c.subexpressions = nil
+	if node == nil || c.level < O1 {
+		return
+	}
+
+	if n, ok := node.(*ast.AssignmentExpression); ok {
+		node = n.Right
+	}
+	if !pure(node) {
+		return
+	}
+
+	c.subexpressions = make(map[string]*subexpression)
+	c.count(node)
+}
+
+func (c *Compiler) count(node ast.Expression) {
+	switch node := node.(type) {
+	case *ast.PrefixExpression:
+		if c.visit(node) {
+			c.count(node.Right)
+		}
+	case *ast.InfixExpression:
+		if c.visit(node) {
+			c.count(node.Left)
+			c.count(node.Right)
+		}
+	default:
+	}
+}
+
+func (c *Compiler) visit(node ast.Expression) bool {
+	key := node.String()
+	sub, ok := c.subexpressions[key]
+	if !ok {
+		sub = &subexpression{}
+		c.subexpressions[key] = sub
+	}
+	sub.count++
+	return sub.count == 1
+}
+
+func (c *Compiler) compileSubexpression(node ast.Expression, compile func() error) error {
+	sub, ok := c.subexpressions[node.String()]
+	if !ok || sub.count < 2 {
+		return compile()
+	}
+	if sub.symbol != nil {
+		c.emit(bytecode.SLTLOAD, uint64(sub.symbol.Index))
+		return nil
+	}
+
+	if err := compile(); err != nil {
+		return err
+	}
+
+	sub.symbol = c.symbolTable.Temporary()
+	sub.symbol.Type = c.getType(node)
+	if idx := uint64(sub.symbol.Index); !slices.Contains(c.temporaries, idx) {
+		c.temporaries = append(c.temporaries, idx)
+	}
+
+	c.emit(bytecode.DUP)
+	c.emit(bytecode.SLTSTORE, uint64(sub.symbol.Index))
+	return nil
+}
+
+func pure(node ast.Expression) bool {
+	switch node := node.(type) {
+	case *ast.PrefixExpression:
+		return pure(node.Right)
+	case *ast.InfixExpression:
+		return pure(node.Left) && pure(node.Right)
+	case *ast.AssignmentExpression, *ast.CallExpression, *ast.MemberExpression, *ast.IndexExpression:
+		return false
+	default:
+		return true
+	}
+}
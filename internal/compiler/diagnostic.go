@@ -0,0 +1,40 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/siyul-park/minijs/internal/ast"
+)
+
+type Diagnostic struct {
+	Node    ast.Node
+	Message string
+}
+
+type declaration struct {
+	node   *ast.IdentifierLiteral
+	symbol *Symbol
+}
+
+func (c *Compiler) Diagnostics() []Diagnostic {
+	return c.diagnostics
+}
+
+func (c *Compiler) warnf(node ast.Node, format string, args ...any) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{Node: node, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *Compiler) diagnose() {
+	for _, decl := range c.declarations {
+		if !c.reads[decl.symbol] {
+			c.warnf(decl.node, "'%s' is declared but its value is never read", decl.node.Value)
+		}
+	}
+}
+
+func (d Diagnostic) String() string {
+	if pos := d.Node.Pos(); pos.IsValid() {
+		return fmt.Sprintf("%s: %s", pos, d.Message)
+	}
+	return d.Message
+}
@@ -0,0 +1,58 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/siyul-park/minijs/internal/ast"
+)
+
+type Error struct {
+	Node ast.Node
+	Err  error
+}
+
+type Errors []*Error
+
+func (c *Compiler) errorf(node ast.Node, format string, args ...any) error {
+	return &Error{Node: node, Err: fmt.Errorf(format, args...)}
+}
+
+func (c *Compiler) report(node ast.Node, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = &Error{Node: node, Err: err}
+	}
+	c.errors = append(c.errors, e)
+}
+
+func (e *Error) Error() string {
+	if e.Node == nil {
+		return e.Err.Error()
+	}
+	if pos := e.Node.Pos(); pos.IsValid() {
+		return fmt.Sprintf("%s: %s: %s", pos, e.Err.Error(), e.Node.String())
+	}
+	return fmt.Sprintf("%s: %s", e.Err.Error(), e.Node.String())
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e Errors) Error() string {
+	var msgs []string
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
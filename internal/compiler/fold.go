@@ -0,0 +1,48 @@
+package compiler
+
+import (
+	"github.com/siyul-park/minijs/internal/ast"
+	"github.com/siyul-park/minijs/internal/interpreter"
+	"github.com/siyul-park/minijs/internal/token"
+)
+
+func (c *Compiler) fold(node ast.Expression) (interpreter.Value, bool) {
+	switch node := node.(type) {
+	case *ast.InfixExpression:
+		if node.Token.Type != token.PLUS || c.getType(node) != interpreter.STRING {
+			return nil, false
+		}
+		left, ok := c.fold(node.Left)
+		if !ok {
+			return nil, false
+		}
+		right, ok := c.fold(node.Right)
+		if !ok {
+			return nil, false
+		}
+		return interpreter.Add(left, right), true
+	case *ast.NullLiteral:
+		return interpreter.Null{}, true
+	case *ast.UndefinedLiteral:
+		return interpreter.Undefined{}, true
+	case *ast.BoolLiteral:
+		if node.Value {
+			return interpreter.Bool(1), true
+		}
+		return interpreter.Bool(0), true
+	case *ast.NumberLiteral:
+		if c.getType(node) == interpreter.INT32 {
+			return interpreter.Int32(node.Value), true
+		}
+		return interpreter.Float64(node.Value), true
+	case *ast.StringLiteral:
+		return interpreter.String(node.Value), true
+	case *ast.IdentifierLiteral:
+		if value, ok := c.propagate(node); ok {
+			return c.fold(value)
+		}
+		return nil, false
+	default:
+		return nil, false
+	}
+}
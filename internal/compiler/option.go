@@ -0,0 +1,137 @@
+package compiler
+
+import (
+	"github.com/siyul-park/minijs/internal/ast"
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/siyul-park/minijs/internal/interpreter"
+	"github.com/siyul-park/minijs/internal/ir"
+)
+
+type Option func(*Compiler)
+
+type Transform func(ast.Node) (ast.Node, error)
+
+type Level int
+
+const (
+	O0 Level = iota
+	O1
+	O2
+)
+
+func WithOptimization(level Level) Option {
+	return func(c *Compiler) {
+		c.level = level
+	}
+}
+
+func WithDebugInfo(enabled bool) Option {
+	return func(c *Compiler) {
+		c.debug = enabled
+	}
+}
+
+func WithSession(session *Session) Option {
+	return func(c *Compiler) {
+		c.session = session
+		c.symbolTable = session.symbolTable
+	}
+}
+
+func WithCompletionValue(enabled bool) Option {
+	return func(c *Compiler) {
+		c.completion = enabled
+	}
+}
+
+func WithTransform(transforms ...Transform) Option {
+	return func(c *Compiler) {
+		c.transforms = append(c.transforms, transforms...)
+	}
+}
+
+func WithStrict(enabled bool) Option {
+	return func(c *Compiler) {
+		c.strict = enabled
+	}
+}
+
+// WithHosts names the functions the embedder provides. A call to one of them
+// that no script binding shadows compiles to call.host.
+func WithHosts(names ...string) Option {
+	return func(c *Compiler) {
+		if c.hosts == nil {
+			c.hosts = map[string]bool{}
+		}
+		for _, name := range names {
+			c.hosts[name] = true
+		}
+	}
+}
+
+func WithSource(source string) Option {
+	return func(c *Compiler) {
+		c.source = source
+	}
+}
+
+func (c *Compiler) optimize(code bytecode.Bytecode) (bytecode.Bytecode, error) {
+	temporaries := c.temporaries
+	c.temporaries = c.temporaries[:0]
+	if c.level >= O2 {
+		f, err := ir.Build(code)
+		if err != nil {
+			return bytecode.Bytecode{}, err
+		}
+		f.Reserve(c.symbolTable.Global().Size())
+		if code, err = c.lower(code, f, temporaries); err != nil {
+			return bytecode.Bytecode{}, err
+		}
+	}
+	if c.level >= O1 {
+		return c.getOptimizer().Optimize(code)
+	}
+	return code, nil
+}
+
+// optimizeFunction optimizes fn, whose frame has room for slots variables.
+func (c *Compiler) optimizeFunction(fn bytecode.Function, slots int, temporaries []uint64) (bytecode.Function, error) {
+	var err error
+	if c.level >= O2 {
+		f, err := ir.BuildFunction(fn)
+		if err != nil {
+			return bytecode.Function{}, err
+		}
+		f.Reserve(slots)
+		if fn.Code, err = c.lower(fn.Code, f, temporaries); err != nil {
+			return bytecode.Function{}, err
+		}
+	}
+	if c.level >= O1 {
+		if fn.Code, err = c.getOptimizer().Optimize(fn.Code); err != nil {
+			return bytecode.Function{}, err
+		}
+	}
+	return fn, nil
+}
+
+func (c *Compiler) getOptimizer() *interpreter.Optimizer {
+	if c.optimizer == nil {
+		c.optimizer = interpreter.NewOptimizer()
+	}
+	return c.optimizer
+}
+
+// lower optimizes f, built from code, and lowers it back. Stores into the
+// temporaries of code that no longer have a load are dropped.
+func (c *Compiler) lower(code bytecode.Bytecode, f *ir.Function, temporaries []uint64) (bytecode.Bytecode, error) {
+	if err := ir.Optimize(f, ir.Fold, ir.Discard(temporaries...), ir.Eliminate); err != nil {
+		return bytecode.Bytecode{}, err
+	}
+	lowered := f.Bytecode()
+	lowered.Symbols = code.Symbols
+	lowered.Exports = code.Exports
+	lowered.Source = code.Source
+	lowered.SourceHash = code.SourceHash
+	return lowered, nil
+}
@@ -0,0 +1,64 @@
+package compiler
+
+import (
+	"github.com/siyul-park/minijs/internal/ast"
+)
+
+type binding struct {
+	value  ast.Expression
+	stores int
+	loads  int
+	reads  int
+}
+
+func (c *Compiler) track(sym *Symbol) *binding {
+	b, ok := c.bindings[sym]
+	if !ok {
+		b = &binding{}
+		c.bindings[sym] = b
+	}
+	return b
+}
+
+func (c *Compiler) propagate(node *ast.IdentifierLiteral) (ast.Expression, bool) {
+	b, ok := c.propagations[node]
+	if !ok || c.level < O1 {
+		return nil, false
+	}
+	if sym := c.symbols[node]; b.stores != 1 || sym.escaped || !c.constant(b.value) {
+		return nil, false
+	}
+	return b.value, true
+}
+
+func (c *Compiler) redundant(node *ast.AssignmentExpression) bool {
+	left, ok := node.Left.(*ast.IdentifierLiteral)
+	if !ok || c.level < O1 || c.debug {
+		return false
+	}
+	sym := c.symbols[left]
+	b, ok := c.bindings[sym]
+	if !ok || b.value != node.Right || b.stores != 1 || sym.escaped || !c.constant(b.value) {
+		return false
+	}
+	if sym.Depth == 0 && c.session != nil {
+		return false
+	}
+	return b.reads == b.loads
+}
+
+func (c *Compiler) constant(node ast.Expression) bool {
+	switch node := node.(type) {
+	case *ast.NullLiteral, *ast.UndefinedLiteral, *ast.BoolLiteral, *ast.NumberLiteral, *ast.StringLiteral:
+		return true
+	case *ast.IdentifierLiteral:
+		b, ok := c.propagations[node]
+		return ok && b.stores == 1 && !c.symbols[node].escaped && c.constant(b.value)
+	case *ast.PrefixExpression:
+		return c.constant(node.Right)
+	case *ast.InfixExpression:
+		return c.constant(node.Left) && c.constant(node.Right)
+	default:
+		return false
+	}
+}
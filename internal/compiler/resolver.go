@@ -0,0 +1,379 @@
+package compiler
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/siyul-park/minijs/internal/ast"
+	"github.com/siyul-park/minijs/internal/interpreter"
+	"github.com/siyul-park/minijs/internal/token"
+)
+
+func (c *Compiler) resolve(node ast.Node) error {
+	switch node := node.(type) {
+	case *ast.Program:
+		return c.resolveProgram(node)
+	case *ast.EmptyStatement:
+		return nil
+	case *ast.BlockStatement:
+		return c.resolveBlockStatement(node)
+	case *ast.ExpressionStatement:
+		return c.resolveExpressionStatement(node)
+	case *ast.Directive:
+		return c.resolve(node.Value)
+	case *ast.VariableStatement:
+		return c.resolveVariableStatement(node)
+	case *ast.FunctionDeclaration:
+		return nil
+	case *ast.ReturnStatement:
+		return c.resolveReturnStatement(node)
+	case *ast.PrefixExpression:
+		return c.resolvePrefixExpression(node)
+	case *ast.InfixExpression:
+		return c.resolveInfixExpression(node)
+	case *ast.AssignmentExpression:
+		return c.resolveAssignmentExpression(node)
+	case *ast.CallExpression:
+		return c.resolveCallExpression(node)
+	case *ast.MemberExpression:
+		return c.resolveMemberExpression(node)
+	case *ast.IndexExpression:
+		return c.resolveIndexExpression(node)
+	case *ast.NullLiteral:
+		c.types[node] = interpreter.NULL
+		return nil
+	case *ast.UndefinedLiteral:
+		c.types[node] = interpreter.UNDEFINED
+		return nil
+	case *ast.BoolLiteral:
+		c.types[node] = interpreter.BOOL
+		return nil
+	case *ast.NumberLiteral:
+		return c.resolveNumberLiteral(node)
+	case *ast.StringLiteral:
+		c.types[node] = interpreter.STRING
+		return nil
+	case *ast.IdentifierLiteral:
+		return c.resolveIdentifierLiteral(node)
+	case *ast.FunctionLiteral:
+		return c.resolveFunctionLiteral(node)
+	default:
+		return c.errorf(node, "unsupported operand type: %T", node)
+	}
+}
+
+func (c *Compiler) resolveProgram(node *ast.Program) error {
+	defer c.enterStrict(node.Statements)()
+	c.resolveStatements(node.Statements)
+	return nil
+}
+
+func (c *Compiler) enterStrict(statements []ast.Statement) func() {
+	if c.strict || !useStrict(statements) {
+		return func() {}
+	}
+	c.strict = true
+	return func() { c.strict = false }
+}
+
+func (c *Compiler) resolveStatements(statements []ast.Statement) {
+	for _, n := range statements {
+		c.hoist(n)
+	}
+	for _, n := range hoisted(statements) {
+		if err := c.resolveFunctionDeclaration(n); err != nil {
+			c.report(n, err)
+		}
+	}
+	for _, n := range statements {
+		if err := c.resolve(n); err != nil {
+			c.report(n, err)
+		}
+	}
+}
+
+func (c *Compiler) resolveBlockStatement(node *ast.BlockStatement) error {
+	c.symbolTable = c.symbolTable.Enter()
+	defer func() { c.symbolTable = c.symbolTable.Leave() }()
+
+	for _, n := range node.Statements {
+		if err := c.resolve(n); err != nil {
+			c.report(n, err)
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) resolveExpressionStatement(node *ast.ExpressionStatement) error {
+	if err := c.resolve(node.Expression); err != nil {
+		return err
+	}
+	if _, ok := node.Expression.(*ast.AssignmentExpression); !ok && pure(node.Expression) {
+		c.warnf(node, "expression statement has no effect")
+	}
+	return nil
+}
+
+func (c *Compiler) resolveVariableStatement(node *ast.VariableStatement) error {
+	if node.Token.Type != token.VAR {
+		return c.errorf(node, "invalid variable token type: %s", node.Token.Type)
+	}
+	for _, n := range node.Right {
+		if err := c.resolve(n); err != nil {
+			return err
+		}
+		if left, ok := n.Left.(*ast.IdentifierLiteral); ok {
+			c.declarations = append(c.declarations, declaration{node: left, symbol: c.symbols[left]})
+			if b := c.track(c.symbols[left]); b.stores == 1 && c.constant(n.Right) {
+				b.value = n.Right
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) resolveFunctionDeclaration(node *ast.FunctionDeclaration) error {
+	if err := c.resolve(node.Function); err != nil {
+		return err
+	}
+	sym, _ := c.symbolTable.Resolve(node.Function.Name.Value)
+	c.track(sym).stores++
+	c.symbols[node.Function.Name] = sym
+	c.types[node.Function.Name] = sym.Type
+	return nil
+}
+
+func (c *Compiler) resolveReturnStatement(node *ast.ReturnStatement) error {
+	if c.symbolTable.depth == 0 {
+		return c.errorf(node, "illegal return statement")
+	}
+	if node.Value == nil {
+		return nil
+	}
+	return c.resolve(node.Value)
+}
+
+func (c *Compiler) resolvePrefixExpression(node *ast.PrefixExpression) error {
+	if err := c.resolve(node.Right); err != nil {
+		return err
+	}
+	c.types[node] = c.getPrefixExpressionType(node)
+	return nil
+}
+
+func (c *Compiler) resolveNumberLiteral(node *ast.NumberLiteral) error {
+	if lit := node.Token.Literal; c.strict && len(lit) > 1 && lit[0] == '0' && lit[1] >= '0' && lit[1] <= '9' {
+		if strings.Trim(lit, "01234567") == "" {
+			return c.errorf(node, "octal literals are not allowed in strict mode, use 0o%s instead", lit[1:])
+		}
+		return c.errorf(node, "decimals with leading zeros are not allowed in strict mode")
+	}
+	c.types[node] = c.getNumberLiteralType(node)
+	return nil
+}
+
+func (c *Compiler) resolveInfixExpression(node *ast.InfixExpression) error {
+	if err := c.resolve(node.Left); err != nil {
+		return err
+	}
+	if err := c.resolve(node.Right); err != nil {
+		return err
+	}
+	c.types[node] = c.getInfixExpressionType(node)
+	return nil
+}
+
+func (c *Compiler) resolveAssignmentExpression(node *ast.AssignmentExpression) error {
+	left, ok := node.Left.(*ast.IdentifierLiteral)
+	if !ok {
+		return c.errorf(node.Left, "invalid assignment target")
+	}
+	if err := c.resolve(node.Right); err != nil {
+		return err
+	}
+
+	sym, ok := c.symbolTable.Resolve(left.Value)
+	if !ok {
+		if c.strict {
+			return c.errorf(left, "assignment to undeclared variable '%s'", left.Value)
+		}
+		sym = c.symbolTable.Global().Define(left.Value)
+	}
+	c.capture(sym)
+	if sym.Depth != c.symbolTable.depth {
+		sym.escaped = true
+	}
+	c.track(sym).stores++
+	sym.Type = c.getType(node.Right)
+	if sym.escaped {
+		sym.Type = interpreter.ANY
+	}
+
+	c.symbols[left] = sym
+	c.types[left] = c.getType(node.Right)
+	c.types[node] = c.getType(node.Right)
+	return nil
+}
+
+func (c *Compiler) resolveCallExpression(node *ast.CallExpression) error {
+	if len(node.Arguments) > math.MaxUint8 {
+		return c.errorf(node, "too many arguments: %d", len(node.Arguments))
+	}
+	if ident, ok := node.Function.(*ast.IdentifierLiteral); ok && c.hosts[ident.Value] {
+		if _, ok := c.symbolTable.Resolve(ident.Value); !ok {
+			c.hostCalls[node] = ident.Value
+		}
+	}
+	if _, ok := c.hostCalls[node]; !ok {
+		if err := c.resolve(node.Function); err != nil {
+			return err
+		}
+	}
+	for _, arg := range node.Arguments {
+		if err := c.resolve(arg); err != nil {
+			return err
+		}
+	}
+	c.types[node] = interpreter.ANY
+	return nil
+}
+
+func (c *Compiler) resolveMemberExpression(node *ast.MemberExpression) error {
+	if err := c.resolve(node.Object); err != nil {
+		return err
+	}
+	c.types[node] = interpreter.ANY
+	return nil
+}
+
+func (c *Compiler) resolveIndexExpression(node *ast.IndexExpression) error {
+	if err := c.resolve(node.Left); err != nil {
+		return err
+	}
+	if err := c.resolve(node.Index); err != nil {
+		return err
+	}
+	c.types[node] = interpreter.ANY
+	return nil
+}
+
+func (c *Compiler) resolveIdentifierLiteral(node *ast.IdentifierLiteral) error {
+	sym, ok := c.symbolTable.Resolve(node.Value)
+	if !ok {
+		return c.errorf(node, "undefined identifier")
+	}
+	c.capture(sym)
+	b := c.track(sym)
+	b.loads++
+	if b.value != nil && sym.Depth == c.symbolTable.depth {
+		b.reads++
+		c.propagations[node] = b
+	}
+	c.reads[sym] = true
+	c.symbols[node] = sym
+	c.types[node] = sym.Type
+	if sym.Depth != c.symbolTable.depth {
+		c.types[node] = interpreter.ANY
+	}
+	return nil
+}
+
+func (c *Compiler) resolveFunctionLiteral(node *ast.FunctionLiteral) error {
+	table := c.symbolTable.EnterFunction()
+	c.scopes[node] = table
+
+	outer := c.symbolTable
+	c.symbolTable = table
+	c.enclosing = append(c.enclosing, node)
+	defer func() {
+		c.symbolTable = outer
+		c.enclosing = c.enclosing[:len(c.enclosing)-1]
+	}()
+
+	for _, param := range node.Parameters {
+		sym := table.Define(param.Value)
+		sym.Type = interpreter.ANY
+		c.symbols[param] = sym
+		c.types[param] = sym.Type
+	}
+	defer c.enterStrict(node.Body.Statements)()
+	c.resolveStatements(node.Body.Statements)
+
+	c.types[node] = interpreter.FUNCTION
+	return nil
+}
+
+func (c *Compiler) capture(sym *Symbol) {
+	if sym.Depth == 0 || sym.Depth == c.symbolTable.depth {
+		return
+	}
+
+	sym.captured = true
+	for _, fn := range c.enclosing[sym.Depth:] {
+		if !slices.Contains(c.upvalues[fn], sym) {
+			c.upvalues[fn] = append(c.upvalues[fn], sym)
+		}
+	}
+}
+
+func (c *Compiler) hoist(node ast.Statement) {
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		for _, n := range node.Statements {
+			c.hoist(n)
+		}
+	case *ast.VariableStatement:
+		if node.Token.Type != token.VAR {
+			return
+		}
+		for _, n := range node.Right {
+			left, ok := n.Left.(*ast.IdentifierLiteral)
+			if !ok {
+				continue
+			}
+			if _, ok := c.symbolTable.Function().symbols[left.Value]; ok {
+				continue
+			}
+			sym := c.symbolTable.Function().Define(left.Value)
+			sym.Type = interpreter.UNDEFINED
+		}
+	case *ast.FunctionDeclaration:
+		sym, ok := c.symbolTable.Function().symbols[node.Function.Name.Value]
+		if !ok {
+			sym = c.symbolTable.Function().Define(node.Function.Name.Value)
+		}
+		sym.Type = interpreter.FUNCTION
+		if sym.escaped {
+			sym.Type = interpreter.ANY
+		}
+	default:
+	}
+}
+
+func hoisted(statements []ast.Statement) []*ast.FunctionDeclaration {
+	var declarations []*ast.FunctionDeclaration
+	for _, n := range statements {
+		switch n := n.(type) {
+		case *ast.BlockStatement:
+			declarations = append(declarations, hoisted(n.Statements)...)
+		case *ast.FunctionDeclaration:
+			declarations = append(declarations, n)
+		default:
+		}
+	}
+	return declarations
+}
+
+func useStrict(statements []ast.Statement) bool {
+	for _, stmt := range statements {
+		directive, ok := stmt.(*ast.Directive)
+		if !ok {
+			return false
+		}
+		if directive.Value.Value == "use strict" {
+			return true
+		}
+	}
+	return false
+}
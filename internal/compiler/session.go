@@ -0,0 +1,26 @@
+package compiler
+
+// Session carries the global variables and their slots from one Compile call
+// to the next. Each call still emits only the constants its own code uses, so
+// a unit stays as small as the source it was compiled from.
+type Session struct {
+	symbolTable *SymbolTable
+}
+
+func NewSession() *Session {
+	return &Session{
+		symbolTable: NewSymbolTable(),
+	}
+}
+
+func (s *Session) SymbolTable() *SymbolTable {
+	return s.symbolTable
+}
+
+// Clone returns a copy of s that later compilations can extend without
+// changing s.
+func (s *Session) Clone() *Session {
+	return &Session{
+		symbolTable: s.symbolTable.clone(),
+	}
+}
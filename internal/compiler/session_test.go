@@ -0,0 +1,119 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/ast"
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/siyul-park/minijs/internal/interpreter"
+	"github.com/siyul-park/minijs/internal/lexer"
+	"github.com/siyul-park/minijs/internal/parser"
+	"github.com/siyul-park/minijs/internal/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	session := NewSession()
+
+	c1 := New(WithSession(session))
+	_, err := c1.Compile(ast.NewVariableStatement(
+		token.New(token.VAR, "var"),
+		ast.NewAssignmentExpression(
+			token.New(token.ASSIGN, "="),
+			ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+			ast.NewStringLiteral(token.New(token.STRING, "bar"), "bar"),
+		),
+	))
+	assert.NoError(t, err)
+
+	sym, ok := session.SymbolTable().Resolve("foo")
+	assert.True(t, ok)
+	assert.Equal(t, 0, sym.Index)
+
+	c2 := New(WithSession(session))
+	code, err := c2.Compile(ast.NewExpressionStatement(
+		ast.NewInfixExpression(
+			token.New(token.PLUS, "+"),
+			ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "foo"), "foo"),
+			ast.NewStringLiteral(token.New(token.STRING, "bar"), "bar"),
+		),
+	))
+	assert.NoError(t, err)
+
+	expected := bytecode.Bytecode{}
+	expected.Emit(
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.STRLOAD, 0),
+		bytecode.New(bytecode.STRADD),
+		bytecode.New(bytecode.POP),
+	)
+	expected.Store(bytecode.String("bar"))
+
+	assert.Equal(t, expected.String(), code.String())
+}
+
+func TestSession_Optimize(t *testing.T) {
+	session := NewSession()
+	c := New(WithSession(session), WithOptimization(O2))
+	i := interpreter.New()
+
+	for _, source := range []string{
+		"var a = 1; var b = 2; function g() { return 10 }; var r = 20;",
+		"g() + g();",
+		"var out = r - 1;",
+	} {
+		program, err := parser.New(lexer.New(strings.NewReader(source))).Parse()
+		assert.NoError(t, err)
+
+		code, err := c.Compile(program)
+		assert.NoError(t, err)
+
+		err = i.Execute(code)
+		assert.NoError(t, err)
+	}
+
+	sym, ok := session.SymbolTable().Resolve("out")
+	assert.True(t, ok)
+
+	val, ok := i.Global(sym.Index)
+	assert.True(t, ok)
+	assert.Equal(t, interpreter.Int32(19), val)
+}
+
+func TestSession_Constants(t *testing.T) {
+	session := NewSession()
+	c := New(WithSession(session), WithOptimization(O2))
+	i := interpreter.New()
+
+	for n := 0; n < 1000; n++ {
+		source := fmt.Sprintf("var v%d = 's%d' + v0;", n, n)
+		program, err := parser.New(lexer.New(strings.NewReader(source))).Parse()
+		assert.NoError(t, err)
+
+		code, err := c.Compile(program)
+		assert.NoError(t, err)
+		assert.Len(t, code.Constants, 1)
+
+		err = i.Execute(code)
+		assert.NoError(t, err)
+	}
+}
+
+func TestSession_Clone(t *testing.T) {
+	session := NewSession()
+	session.SymbolTable().Define("foo")
+
+	clone := session.Clone()
+	clone.SymbolTable().Define("bar")
+
+	sym, ok := clone.SymbolTable().Resolve("foo")
+	assert.True(t, ok)
+	assert.Equal(t, 0, sym.Index)
+
+	_, ok = session.SymbolTable().Resolve("bar")
+	assert.False(t, ok)
+	assert.Equal(t, 1, session.SymbolTable().Size())
+	assert.Equal(t, 2, clone.SymbolTable().Size())
+}
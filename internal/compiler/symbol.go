@@ -1,32 +1,160 @@
 package compiler
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/siyul-park/minijs/internal/interpreter"
 )
 
 type Symbol struct {
-	Name  string
-	Index int
-	Type  interpreter.Type
+	Name     string
+	Index    int
+	Depth    int
+	Type     interpreter.Type
+	slots    *slots
+	escaped  bool
+	captured bool
 }
 
 type SymbolTable struct {
+	outer   *SymbolTable
 	symbols map[string]*Symbol
+	slots   *slots
+	depth   int
+}
+
+type slots struct {
+	size int
+	free []int
 }
 
 func NewSymbolTable() *SymbolTable {
 	return &SymbolTable{
 		symbols: make(map[string]*Symbol),
+		slots:   &slots{},
+	}
+}
+
+func (s *SymbolTable) Enter() *SymbolTable {
+	return &SymbolTable{
+		outer:   s,
+		symbols: make(map[string]*Symbol),
+		slots:   s.slots,
+		depth:   s.depth,
+	}
+}
+
+func (s *SymbolTable) EnterFunction() *SymbolTable {
+	return &SymbolTable{
+		outer:   s,
+		symbols: make(map[string]*Symbol),
+		slots:   &slots{},
+		depth:   s.depth + 1,
+	}
+}
+
+func (s *SymbolTable) Leave() *SymbolTable {
+	if s.outer == nil {
+		return s
+	}
+	return s.outer
+}
+
+func (s *SymbolTable) Function() *SymbolTable {
+	for s.outer != nil && s.outer.depth == s.depth {
+		s = s.outer
+	}
+	return s
+}
+
+func (s *SymbolTable) Global() *SymbolTable {
+	for s.outer != nil {
+		s = s.outer
 	}
+	return s
 }
 
 func (s *SymbolTable) Define(name string) *Symbol {
-	sym := &Symbol{Name: name, Index: len(s.symbols)}
+	sym := &Symbol{Name: name, Index: s.slots.allocate(), Depth: s.depth, slots: s.slots}
 	s.symbols[name] = sym
 	return sym
 }
 
+func (s *SymbolTable) Temporary() *Symbol {
+	idx := s.slots.reuse()
+	sym := &Symbol{Name: fmt.Sprintf("@%d", idx), Index: idx, Depth: s.depth, slots: s.slots}
+	s.symbols[sym.Name] = sym
+	return sym
+}
+
+func (s *SymbolTable) Release(sym *Symbol) {
+	for t := s; t != nil && t.depth == sym.Depth; t = t.outer {
+		if t.symbols[sym.Name] == sym {
+			delete(t.symbols, sym.Name)
+			t.slots.free = append(t.slots.free, sym.Index)
+			return
+		}
+	}
+}
+
 func (s *SymbolTable) Resolve(name string) (*Symbol, bool) {
-	sym, ok := s.symbols[name]
-	return sym, ok
+	for ; s != nil; s = s.outer {
+		if sym, ok := s.symbols[name]; ok {
+			return sym, true
+		}
+	}
+	return nil, false
+}
+
+// Names returns the sorted names visible from s, leaving out temporaries.
+func (s *SymbolTable) Names() []string {
+	var names []string
+	for t := s; t != nil; t = t.outer {
+		for name := range t.symbols {
+			if !strings.HasPrefix(name, "@") && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// clone copies a global symbol table along with its symbols.
+func (s *SymbolTable) clone() *SymbolTable {
+	slots := &slots{size: s.slots.size, free: slices.Clone(s.slots.free)}
+	symbols := make(map[string]*Symbol, len(s.symbols))
+	for name, sym := range s.symbols {
+		sym := *sym
+		sym.slots = slots
+		symbols[name] = &sym
+	}
+	return &SymbolTable{symbols: symbols, slots: slots, depth: s.depth}
+}
+
+func (s *SymbolTable) Reset() {
+	clear(s.symbols)
+	s.slots.size = 0
+	s.slots.free = s.slots.free[:0]
+}
+
+func (s *SymbolTable) Size() int {
+	return s.slots.size
+}
+
+func (s *slots) allocate() int {
+	idx := s.size
+	s.size++
+	return idx
+}
+
+func (s *slots) reuse() int {
+	if n := len(s.free); n > 0 {
+		idx := s.free[n-1]
+		s.free = s.free[:n-1]
+		return idx
+	}
+	return s.allocate()
 }
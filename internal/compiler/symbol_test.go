@@ -0,0 +1,108 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbolTable_Define(t *testing.T) {
+	s := NewSymbolTable()
+
+	foo := s.Define("foo")
+	assert.Equal(t, 0, foo.Index)
+
+	sym, ok := s.Resolve("foo")
+	assert.True(t, ok)
+	assert.Equal(t, foo, sym)
+}
+
+func TestSymbolTable_Enter(t *testing.T) {
+	s := NewSymbolTable()
+	foo := s.Define("foo")
+
+	block := s.Enter()
+	shadow := block.Define("foo")
+	bar := block.Define("bar")
+
+	assert.Equal(t, 1, shadow.Index)
+	assert.Equal(t, 2, bar.Index)
+
+	sym, ok := block.Resolve("foo")
+	assert.True(t, ok)
+	assert.Equal(t, shadow, sym)
+
+	s = block.Leave()
+
+	sym, ok = s.Resolve("foo")
+	assert.True(t, ok)
+	assert.Equal(t, foo, sym)
+
+	_, ok = s.Resolve("bar")
+	assert.False(t, ok)
+	assert.Equal(t, 3, s.Size())
+}
+
+func TestSymbolTable_EnterFunction(t *testing.T) {
+	s := NewSymbolTable()
+	foo := s.Define("foo")
+
+	fn := s.EnterFunction()
+	block := fn.Enter()
+	bar := block.Define("bar")
+
+	assert.Equal(t, 0, bar.Index)
+	assert.Equal(t, 1, bar.Depth)
+	assert.Equal(t, fn, block.Function())
+	assert.Equal(t, s, block.Global())
+
+	sym, ok := block.Resolve("foo")
+	assert.True(t, ok)
+	assert.Equal(t, foo, sym)
+	assert.Equal(t, 0, sym.Depth)
+}
+
+func TestSymbolTable_Temporary(t *testing.T) {
+	s := NewSymbolTable()
+	s.Define("foo")
+
+	tmp1 := s.Temporary()
+	assert.Equal(t, 1, tmp1.Index)
+
+	s.Release(tmp1)
+
+	tmp2 := s.Temporary()
+	assert.Equal(t, 1, tmp2.Index)
+
+	bar := s.Define("bar")
+	assert.Equal(t, 2, bar.Index)
+	assert.Equal(t, 3, s.Size())
+}
+
+func TestSymbolTable_Names(t *testing.T) {
+	s := NewSymbolTable()
+	s.Define("foo")
+	s.Temporary()
+
+	block := s.EnterFunction()
+	block.Define("bar")
+	block.Define("foo")
+
+	assert.Equal(t, []string{"bar", "foo"}, block.Names())
+	assert.Equal(t, []string{"foo"}, s.Names())
+}
+
+func TestSymbolTable_Reset(t *testing.T) {
+	s := NewSymbolTable()
+	s.Define("foo")
+	s.Release(s.Temporary())
+
+	s.Reset()
+
+	_, ok := s.Resolve("foo")
+	assert.False(t, ok)
+	assert.Equal(t, 0, s.Size())
+
+	bar := s.Define("bar")
+	assert.Equal(t, 0, bar.Index)
+}
@@ -0,0 +1,395 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/siyul-park/minijs/internal/ast"
+	"github.com/siyul-park/minijs/internal/lexer"
+	"github.com/siyul-park/minijs/internal/parser"
+)
+
+type printer struct {
+	out      bytes.Buffer
+	indent   int
+	comments map[ast.Node]*ast.Comments
+	group    ast.Expression
+}
+
+func Source(src []byte) ([]byte, error) {
+	l := lexer.NewBytes(src, lexer.WithComments(true))
+	program, err := parser.New(l).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := Node(&buf, program); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Minimal reformats src statement by statement, keeping the whitespace and
+// comments between top-level statements as written so that statements already
+// in canonical form come through unchanged.
+func Minimal(src []byte) ([]byte, error) {
+	l := lexer.NewBytes(src, lexer.WithComments(true), lexer.WithWhitespace(true))
+	program, err := parser.New(l, parser.WithTrivia(true)).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, stmt := range program.Statements {
+		trivia := program.Trivia[stmt]
+		for _, tk := range trivia.Leading {
+			buf.WriteString(tk.Literal)
+		}
+
+		if commented(program.Comments[stmt], trivia) {
+			buf.Write(src[trivia.Start.Offset:trivia.End.Offset])
+			continue
+		}
+		p := &printer{comments: program.Comments}
+		if err := p.statement(stmt); err != nil {
+			return nil, err
+		}
+		buf.Write(p.out.Bytes())
+	}
+	if trivia := program.Trivia[program]; trivia != nil {
+		for _, tk := range trivia.Trailing {
+			buf.WriteString(tk.Literal)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func Node(w io.Writer, node ast.Node) error {
+	p := &printer{}
+	if program, ok := node.(*ast.Program); ok {
+		p.comments = program.Comments
+	}
+
+	var err error
+	switch node := node.(type) {
+	case *ast.Program:
+		err = p.statements(node, node.Statements)
+	case ast.Statement:
+		err = p.statement(node)
+	case ast.Expression:
+		err = p.expression(node, parser.LOWEST)
+	default:
+		err = fmt.Errorf("unsupported node %T", node)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(p.out.Bytes())
+	return err
+}
+
+func (p *printer) statements(parent ast.Node, statements []ast.Statement) error {
+	line := 0
+	for _, stmt := range statements {
+		group := p.comments[stmt]
+		if group == nil {
+			group = &ast.Comments{}
+		}
+
+		for _, c := range group.Leading {
+			p.space(line, c.Pos().Line)
+			p.line(c.String())
+			line = c.End().Line
+		}
+
+		p.space(line, stmt.Pos().Line)
+		p.write()
+		if err := p.statement(stmt); err != nil {
+			return err
+		}
+
+		line = stmt.End().Line
+		for _, c := range group.Trailing {
+			if c.Pos().Offset < stmt.End().Offset {
+				continue
+			}
+			if c.Pos().Line == line {
+				p.out.WriteString(" ")
+				p.out.WriteString(c.String())
+			} else {
+				p.out.WriteString("\n")
+				if c.Pos().Line > line+1 {
+					p.out.WriteString("\n")
+				}
+				p.write(c.String())
+			}
+			line = c.End().Line
+		}
+		p.out.WriteString("\n")
+	}
+
+	if len(statements) == 0 {
+		if group := p.comments[parent]; group != nil {
+			_, root := parent.(*ast.Program)
+			for _, c := range group.Trailing {
+				if root || c.Pos().Offset < parent.End().Offset {
+					p.line(c.String())
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (p *printer) statement(stmt ast.Statement) error {
+	switch stmt := stmt.(type) {
+	case *ast.EmptyStatement:
+		p.out.WriteString(";")
+	case *ast.BlockStatement:
+		return p.block(stmt)
+	case *ast.ExpressionStatement:
+		if fn, ok := leftmost(stmt.Expression).(*ast.FunctionLiteral); ok {
+			p.group = fn
+		}
+		err := p.expression(stmt.Expression, parser.LOWEST)
+		p.group = nil
+		if err != nil {
+			return err
+		}
+		p.out.WriteString(";")
+	case *ast.Directive:
+		p.out.WriteString(quote(stmt.Value.Value))
+		p.out.WriteString(";")
+	case *ast.VariableStatement:
+		p.out.WriteString("var ")
+		for i, exp := range stmt.Right {
+			if i > 0 {
+				p.out.WriteString(", ")
+			}
+			if err := p.expression(exp, parser.ASSIGN); err != nil {
+				return err
+			}
+		}
+		p.out.WriteString(";")
+	case *ast.FunctionDeclaration:
+		return p.function(stmt.Function)
+	case *ast.ReturnStatement:
+		p.out.WriteString("return")
+		if stmt.Value != nil {
+			p.out.WriteString(" ")
+			if err := p.expression(stmt.Value, parser.LOWEST); err != nil {
+				return err
+			}
+		}
+		p.out.WriteString(";")
+	default:
+		return fmt.Errorf("unsupported statement %T", stmt)
+	}
+	return nil
+}
+
+func (p *printer) block(stmt *ast.BlockStatement) error {
+	if len(stmt.Statements) == 0 && p.comments[stmt] == nil {
+		p.out.WriteString("{}")
+		return nil
+	}
+
+	p.out.WriteString("{\n")
+	p.indent++
+	if err := p.statements(stmt, stmt.Statements); err != nil {
+		return err
+	}
+	p.indent--
+	p.write("}")
+	return nil
+}
+
+func (p *printer) function(fn *ast.FunctionLiteral) error {
+	p.out.WriteString("function")
+	if fn.Name != nil {
+		p.out.WriteString(" ")
+		p.out.WriteString(fn.Name.Value)
+	}
+	p.out.WriteString("(")
+	for i, param := range fn.Parameters {
+		if i > 0 {
+			p.out.WriteString(", ")
+		}
+		p.out.WriteString(param.Value)
+	}
+	p.out.WriteString(") ")
+	if fn.Body == nil {
+		p.out.WriteString("{}")
+		return nil
+	}
+	return p.block(fn.Body)
+}
+
+func (p *printer) expression(exp ast.Expression, precedence int) error {
+	own := precedenceOf(exp)
+	if own < precedence || exp == p.group {
+		p.out.WriteString("(")
+		defer p.out.WriteString(")")
+	}
+
+	switch exp := exp.(type) {
+	case *ast.NullLiteral:
+		p.out.WriteString("null")
+	case *ast.UndefinedLiteral:
+		p.out.WriteString("undefined")
+	case *ast.BoolLiteral:
+		p.out.WriteString(strconv.FormatBool(exp.Value))
+	case *ast.NumberLiteral:
+		if exp.Token.Literal != "" {
+			p.out.WriteString(exp.Token.Literal)
+		} else {
+			p.out.WriteString(strconv.FormatFloat(exp.Value, 'g', -1, 64))
+		}
+	case *ast.StringLiteral:
+		p.out.WriteString(quote(exp.Value))
+	case *ast.IdentifierLiteral:
+		p.out.WriteString(exp.Value)
+	case *ast.FunctionLiteral:
+		return p.function(exp)
+	case *ast.PrefixExpression:
+		p.out.WriteString(exp.Token.Literal)
+		return p.expression(exp.Right, parser.PREFIX+1)
+	case *ast.InfixExpression:
+		if err := p.expression(exp.Left, own); err != nil {
+			return err
+		}
+		p.out.WriteString(" ")
+		p.out.WriteString(exp.Token.Literal)
+		p.out.WriteString(" ")
+		return p.expression(exp.Right, own+1)
+	case *ast.AssignmentExpression:
+		if err := p.expression(exp.Left, parser.ASSIGN+1); err != nil {
+			return err
+		}
+		p.out.WriteString(" = ")
+		return p.expression(exp.Right, parser.LOWEST)
+	case *ast.CallExpression:
+		if err := p.expression(exp.Function, parser.CALL); err != nil {
+			return err
+		}
+		p.out.WriteString("(")
+		for i, arg := range exp.Arguments {
+			if i > 0 {
+				p.out.WriteString(", ")
+			}
+			if err := p.expression(arg, parser.LOWEST); err != nil {
+				return err
+			}
+		}
+		p.out.WriteString(")")
+	case *ast.MemberExpression:
+		if err := p.expression(exp.Object, parser.CALL); err != nil {
+			return err
+		}
+		p.out.WriteString(".")
+		p.out.WriteString(exp.Property.Value)
+	case *ast.IndexExpression:
+		if err := p.expression(exp.Left, parser.CALL); err != nil {
+			return err
+		}
+		p.out.WriteString("[")
+		if err := p.expression(exp.Index, parser.LOWEST); err != nil {
+			return err
+		}
+		p.out.WriteString("]")
+	default:
+		return fmt.Errorf("unsupported expression %T", exp)
+	}
+	return nil
+}
+
+func (p *printer) write(text ...string) {
+	p.out.WriteString(strings.Repeat("  ", p.indent))
+	for _, t := range text {
+		p.out.WriteString(t)
+	}
+}
+
+func (p *printer) space(prev, next int) {
+	if prev > 0 && next > prev+1 {
+		p.out.WriteString("\n")
+	}
+}
+
+func (p *printer) line(text string) {
+	p.write(text)
+	p.out.WriteString("\n")
+}
+
+func precedenceOf(exp ast.Expression) int {
+	switch exp := exp.(type) {
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.InfixExpression:
+		return parser.Precedence(exp.Token.Type)
+	case *ast.AssignmentExpression:
+		return parser.ASSIGN
+	case *ast.CallExpression, *ast.MemberExpression, *ast.IndexExpression:
+		return parser.CALL
+	default:
+		return parser.HIGHEST
+	}
+}
+
+func commented(group *ast.Comments, trivia *ast.Trivia) bool {
+	if group == nil {
+		return false
+	}
+	for _, c := range slices.Concat(group.Leading, group.Trailing) {
+		if trivia.Start.Offset <= c.Pos().Offset && c.Pos().Offset < trivia.End.Offset {
+			return true
+		}
+	}
+	return false
+}
+
+func leftmost(exp ast.Expression) ast.Expression {
+	switch e := exp.(type) {
+	case *ast.InfixExpression:
+		return leftmost(e.Left)
+	case *ast.AssignmentExpression:
+		return leftmost(e.Left)
+	case *ast.CallExpression:
+		return leftmost(e.Function)
+	case *ast.MemberExpression:
+		return leftmost(e.Object)
+	case *ast.IndexExpression:
+		return leftmost(e.Left)
+	default:
+		return exp
+	}
+}
+
+func quote(s string) string {
+	var out strings.Builder
+	out.WriteString("\"")
+	for _, ch := range s {
+		switch ch {
+		case '"':
+			out.WriteString("\\\"")
+		case '\\':
+			out.WriteString("\\\\")
+		case '\n':
+			out.WriteString("\\n")
+		case '\r':
+			out.WriteString("\\r")
+		case '\t':
+			out.WriteString("\\t")
+		default:
+			out.WriteRune(ch)
+		}
+	}
+	out.WriteString("\"")
+	return out.String()
+}
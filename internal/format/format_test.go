@@ -0,0 +1,136 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/ast"
+	"github.com/siyul-park/minijs/internal/lexer"
+	"github.com/siyul-park/minijs/internal/parser"
+	"github.com/siyul-park/minijs/internal/token"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		source string
+		expect string
+	}{
+		{
+			source: "var a=1,b = 'x\"y\\n'",
+			expect: "var a = 1, b = \"x\\\"y\\n\";\n",
+		},
+		{
+			source: "function add(x,y){return x+y}",
+			expect: "function add(x, y) {\n  return x + y;\n}\n",
+		},
+		{
+			source: "(function(){ return })()",
+			expect: "(function() {\n  return;\n})();\n",
+		},
+		{
+			source: "-(-a); (a+b)*2 % 3; a - (b - 1); a = b = (1)",
+			expect: "-(-a);\n(a + b) * 2 % 3;\na - (b - 1);\na = b = 1;\n",
+		},
+		{
+			source: "f(1)(2, g(3)); (a + b)(1); function f() {}",
+			expect: "f(1)(2, g(3));\n(a + b)(1);\nfunction f() {}\n",
+		},
+		{
+			source: "user.name; (a+b).c; a[ i+1 ].f( x )[0]; (function(){}).x",
+			expect: "user.name;\n(a + b).c;\na[i + 1].f(x)[0];\n(function() {}).x;\n",
+		},
+		{
+			source: "'use strict'\nfunction f(){'use strict';return}",
+			expect: "\"use strict\";\nfunction f() {\n  \"use strict\";\n  return;\n}\n",
+		},
+		{
+			source: "{ {} ; }",
+			expect: "{\n  {}\n  ;\n}\n",
+		},
+		{
+			source: "// header\n\nvar a = 1; // trailing\n\n\nfoo(); /* a */ /* b */\n{ // dangling\n}\n// end",
+			expect: "// header\n\nvar a = 1; // trailing\n\nfoo(); /* a */ /* b */\n{\n  // dangling\n}\n// end\n",
+		},
+		{
+			source: "",
+			expect: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			actual, err := Source([]byte(tt.source))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, string(actual))
+
+			again, err := Source(actual)
+			assert.NoError(t, err)
+			assert.Equal(t, string(actual), string(again))
+
+			assert.Equal(t, parse(t, tt.source).String(), parse(t, string(actual)).String())
+		})
+	}
+}
+
+func TestSource_Error(t *testing.T) {
+	_, err := Source([]byte("foo(1;"))
+	assert.Error(t, err)
+}
+
+func TestMinimal(t *testing.T) {
+	tests := []struct {
+		source string
+		expect string
+	}{
+		{
+			source: "// header\n\n\nvar a=1;   // trailing\n",
+			expect: "// header\n\n\nvar a = 1;   // trailing\n",
+		},
+		{
+			source: "function  add(x,y){\n  // inner\n  return x+y\n}\n\n(a + 1) * 2;\n",
+			expect: "function add(x, y) {\n  // inner\n  return x + y;\n}\n\n(a + 1) * 2;\n",
+		},
+		{
+			source: "b = a /* keep */ + 1\n\tc=2",
+			expect: "b = a /* keep */ + 1\n\tc = 2;",
+		},
+		{
+			source: "",
+			expect: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			actual, err := Minimal([]byte(tt.source))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, string(actual))
+
+			again, err := Minimal(actual)
+			assert.NoError(t, err)
+			assert.Equal(t, string(actual), string(again))
+		})
+	}
+}
+
+func TestNode(t *testing.T) {
+	exp := ast.NewInfixExpression(
+		token.New(token.MULTIPLY, "*"),
+		ast.NewInfixExpression(token.New(token.PLUS, "+"), ast.NewNumberLiteral(token.Token{}, 1), ast.NewNumberLiteral(token.Token{}, 2.5)),
+		ast.NewStringLiteral(token.Token{}, "a"),
+	)
+
+	var buf bytes.Buffer
+	err := Node(&buf, exp)
+	assert.NoError(t, err)
+	assert.Equal(t, "(1 + 2.5) * \"a\"", buf.String())
+}
+
+func parse(t *testing.T, source string) *ast.Program {
+	program, err := parser.New(lexer.New(strings.NewReader(source))).Parse()
+	assert.NoError(t, err)
+	return program
+}
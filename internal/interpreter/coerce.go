@@ -0,0 +1,217 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+func ToNumber(val Value) Float64 {
+	switch v := val.(type) {
+	case Undefined:
+		return Float64(math.NaN())
+	case Null:
+		return 0
+	case Bool:
+		if v > 0 {
+			return 1
+		}
+		return 0
+	case Int32:
+		return Float64(v)
+	case Float64:
+		return v
+	case String:
+		return Float64(parseNumber(string(v)))
+	default:
+		return Float64(math.NaN())
+	}
+}
+
+// parseNumber converts s following JavaScript's StringNumericLiteral grammar,
+// returning NaN for strings outside it such as "inf" or "0x1p-2".
+func parseNumber(s string) float64 {
+	s = strings.TrimFunc(s, isSpace)
+	if s == "" {
+		return 0
+	}
+
+	if len(s) > 2 && s[0] == '0' {
+		base := 0
+		switch s[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 0 {
+			n, ok := new(big.Int).SetString(s[2:], base)
+			if !ok {
+				return math.NaN()
+			}
+			f, _ := n.Float64()
+			return f
+		}
+	}
+
+	unsigned := s
+	if s[0] == '+' || s[0] == '-' {
+		unsigned = s[1:]
+	}
+	if unsigned == "Infinity" {
+		if s[0] == '-' {
+			return math.Inf(-1)
+		}
+		return math.Inf(1)
+	}
+	if !isDecimal(unsigned) {
+		return math.NaN()
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return math.NaN()
+	}
+	return f
+}
+
+// isDecimal reports whether s is digits with an optional fraction and
+// exponent, with at least one digit before the exponent.
+func isDecimal(s string) bool {
+	digits := func(i int) int {
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i
+	}
+
+	i := digits(0)
+	n := i
+	if i < len(s) && s[i] == '.' {
+		j := digits(i + 1)
+		n += j - i - 1
+		i = j
+	}
+	if n == 0 {
+		return false
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		j := digits(i)
+		if j == i {
+			return false
+		}
+		i = j
+	}
+	return i == len(s)
+}
+
+func isSpace(r rune) bool {
+	switch r {
+	case '\t', '\n', '\v', '\f', '\r', ' ', 0xA0, 0x1680, 0x2028, 0x2029, 0x202F, 0x205F, 0x3000, 0xFEFF:
+		return true
+	}
+	return r >= 0x2000 && r <= 0x200A
+}
+
+func ToInt32(val Value) Int32 {
+	f := float64(ToNumber(val))
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return Int32(int32(uint32(int64(math.Trunc(math.Mod(f, 1<<32))))))
+}
+
+func ToString(val Value) String {
+	switch v := val.(type) {
+	case String:
+		return v
+	case Undefined:
+		return String(v.String())
+	case Null:
+		return String(v.String())
+	case Bool:
+		return String(v.String())
+	case Int32:
+		return String(v.String())
+	case Float64:
+		return String(v.String())
+	case Function:
+		return String(v.String())
+	case Host:
+		return String(v.String())
+	case Object:
+		return "[object Object]"
+	default:
+		return ""
+	}
+}
+
+func ToBool(val Value) Bool {
+	switch v := val.(type) {
+	case Bool:
+		return v
+	case Int32:
+		if v != 0 {
+			return 1
+		}
+	case Float64:
+		if v != 0 && !math.IsNaN(float64(v)) {
+			return 1
+		}
+	case String:
+		if len(v) > 0 {
+			return 1
+		}
+	case Function, Host, Object:
+		return 1
+	default:
+	}
+	return 0
+}
+
+// Get reads the property key of val. Reading from undefined or null fails, and
+// values that are not objects have no properties.
+func Get(val, key Value) (Value, error) {
+	switch v := val.(type) {
+	case Object:
+		prop, err := v.Get(string(ToString(key)))
+		if err != nil {
+			return nil, err
+		}
+		if prop == nil {
+			prop = Undefined{}
+		}
+		return prop, nil
+	case Undefined, Null:
+		return nil, fmt.Errorf("%w: cannot read property %q of %v", ErrTypeMismatch, string(ToString(key)), val)
+	default:
+		return Undefined{}, nil
+	}
+}
+
+func Add(left, right Value) Value {
+	_, ok1 := left.(String)
+	_, ok2 := right.(String)
+	if ok1 || ok2 {
+		return ToString(left) + ToString(right)
+	}
+
+	l, ok1 := left.(Int32)
+	r, ok2 := right.(Int32)
+	if ok1 && ok2 {
+		sum := int64(l) + int64(r)
+		if sum >= math.MinInt32 && sum <= math.MaxInt32 {
+			return Int32(sum)
+		}
+		return Float64(sum)
+	}
+	return ToNumber(left) + ToNumber(right)
+}
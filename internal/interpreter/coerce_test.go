@@ -0,0 +1,143 @@
+package interpreter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToNumber(t *testing.T) {
+	tests := []struct {
+		value  Value
+		expect Float64
+	}{
+		{value: Null{}, expect: 0},
+		{value: Bool(1), expect: 1},
+		{value: Int32(2), expect: 2},
+		{value: Float64(1.5), expect: 1.5},
+		{value: String(""), expect: 0},
+		{value: String(" 12 "), expect: 12},
+		{value: String("0x10"), expect: 16},
+		{value: String("0b11"), expect: 3},
+		{value: String("1e3"), expect: 1000},
+		{value: String("-Infinity"), expect: Float64(math.Inf(-1))},
+		{value: String("+Infinity"), expect: Float64(math.Inf(1))},
+		{value: String("0X1F"), expect: 31},
+		{value: String("0o17"), expect: 15},
+		{value: String(".5"), expect: 0.5},
+		{value: String("5."), expect: 5},
+		{value: String("-1.5e-1"), expect: -0.15},
+		{value: String("\t\n 7\u00a0"), expect: 7},
+		{value: String("1e400"), expect: Float64(math.Inf(1))},
+	}
+
+	for _, tt := range tests {
+		t.Run(ToString(tt.value).String(), func(t *testing.T) {
+			assert.Equal(t, tt.expect, ToNumber(tt.value))
+		})
+	}
+
+	for _, val := range []Value{
+		Undefined{},
+		String("foo"),
+		String("inf"),
+		String("infinity"),
+		String("-inf"),
+		String("NaN"),
+		String("0x1p-2"),
+		String("-0x10"),
+		String("0x"),
+		String("1_000"),
+		String("0x1_0"),
+		String("."),
+		String("1e"),
+		String("Infinityx"),
+	} {
+		t.Run(ToString(val).String(), func(t *testing.T) {
+			assert.True(t, math.IsNaN(float64(ToNumber(val))))
+		})
+	}
+}
+
+func TestToInt32(t *testing.T) {
+	tests := []struct {
+		value  Value
+		expect Int32
+	}{
+		{value: Float64(math.NaN()), expect: 0},
+		{value: Float64(math.Inf(1)), expect: 0},
+		{value: Float64(-1.5), expect: -1},
+		{value: Float64(4294967297), expect: 1},
+		{value: Float64(2147483648), expect: math.MinInt32},
+		{value: String("42"), expect: 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(ToString(tt.value).String(), func(t *testing.T) {
+			assert.Equal(t, tt.expect, ToInt32(tt.value))
+		})
+	}
+}
+
+func TestToString(t *testing.T) {
+	tests := []struct {
+		value  Value
+		expect String
+	}{
+		{value: Undefined{}, expect: "undefined"},
+		{value: Null{}, expect: "null"},
+		{value: Bool(0), expect: "false"},
+		{value: Int32(-3), expect: "-3"},
+		{value: Float64(1.5), expect: "1.5"},
+		{value: String("foo"), expect: "foo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.expect), func(t *testing.T) {
+			assert.Equal(t, tt.expect, ToString(tt.value))
+		})
+	}
+}
+
+func TestToBool(t *testing.T) {
+	tests := []struct {
+		value  Value
+		expect Bool
+	}{
+		{value: Undefined{}, expect: 0},
+		{value: Null{}, expect: 0},
+		{value: Int32(-1), expect: 1},
+		{value: Float64(0), expect: 0},
+		{value: Float64(math.NaN()), expect: 0},
+		{value: String(""), expect: 0},
+		{value: String("0"), expect: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(ToString(tt.value).String(), func(t *testing.T) {
+			assert.Equal(t, tt.expect, ToBool(tt.value))
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		left   Value
+		right  Value
+		expect Value
+	}{
+		{left: Int32(1), right: Int32(2), expect: Int32(3)},
+		{left: Int32(math.MaxInt32), right: Int32(1), expect: Float64(math.MaxInt32 + 1)},
+		{left: Int32(1), right: Float64(0.5), expect: Float64(1.5)},
+		{left: Bool(1), right: Null{}, expect: Float64(1)},
+		{left: String("a"), right: Int32(1), expect: String("a1")},
+		{left: Undefined{}, right: String("b"), expect: String("undefinedb")},
+	}
+
+	for _, tt := range tests {
+		t.Run(ToString(tt.left).String()+"+"+ToString(tt.right).String(), func(t *testing.T) {
+			assert.Equal(t, tt.expect, Add(tt.left, tt.right))
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package interpreter
+
+import (
+	"sort"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+)
+
+type Action int
+
+type Debugger struct {
+	breakpoints map[int]struct{}
+	lines       map[int]struct{}
+	watchpoints map[int]func(old, new Value)
+	stepping    bool
+	handler     func(*Interpreter, int) Action
+}
+
+const (
+	Continue Action = iota
+	Step
+)
+
+func NewDebugger(handler func(i *Interpreter, offset int) Action) *Debugger {
+	return &Debugger{
+		breakpoints: make(map[int]struct{}),
+		lines:       make(map[int]struct{}),
+		watchpoints: make(map[int]func(old, new Value)),
+		handler:     handler,
+	}
+}
+
+func (d *Debugger) SetBreakpoint(offset int) {
+	d.breakpoints[offset] = struct{}{}
+}
+
+func (d *Debugger) ClearBreakpoint(offset int) {
+	delete(d.breakpoints, offset)
+}
+
+func (d *Debugger) Breakpoints() []int {
+	offsets := make([]int, 0, len(d.breakpoints))
+	for offset := range d.breakpoints {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+	return offsets
+}
+
+// SetLineBreakpoint pauses before the first instruction compiled from source
+// line, in the top-level code or any function. It needs code compiled with
+// debug info.
+func (d *Debugger) SetLineBreakpoint(line int) {
+	d.lines[line] = struct{}{}
+}
+
+func (d *Debugger) ClearLineBreakpoint(line int) {
+	delete(d.lines, line)
+}
+
+func (d *Debugger) LineBreakpoints() []int {
+	lines := make([]int, 0, len(d.lines))
+	for line := range d.lines {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// SetWatchpoint calls fn whenever the script stores into global slot, whether
+// from the top-level code, a function or a closure that captured it. Slots of
+// function locals are not watched.
+func (d *Debugger) SetWatchpoint(slot int, fn func(old, new Value)) {
+	d.watchpoints[slot] = fn
+}
+
+func (d *Debugger) ClearWatchpoint(slot int) {
+	delete(d.watchpoints, slot)
+}
+
+func (d *Debugger) Step() {
+	d.stepping = true
+}
+
+func (d *Debugger) pause(i *Interpreter, offset int) {
+	if d.handler == nil {
+		return
+	}
+	if !d.stepping && !d.hit(i.current(), offset) {
+		return
+	}
+	d.stepping = d.handler(i, offset) == Step
+}
+
+// hit reports whether a breakpoint is set at offset, or on the line of code
+// that starts at offset.
+func (d *Debugger) hit(code *bytecode.Bytecode, offset int) bool {
+	if _, ok := d.breakpoints[offset]; ok {
+		return true
+	}
+	if len(d.lines) == 0 {
+		return false
+	}
+	line := code.Line(offset)
+	if _, ok := d.lines[line]; !ok || line == 0 {
+		return false
+	}
+	return offset == 0 || code.Line(offset-1) != line
+}
+
+// watch reports a store of new into global slot, which held old.
+func (d *Debugger) watch(slot int, old, new Value) {
+	if fn, ok := d.watchpoints[slot]; ok {
+		fn(old, new)
+	}
+}
+
+// watchCell reports a store of new into cell if it is the cell of a watched
+// global slot.
+func (d *Debugger) watchCell(i *Interpreter, cell *Cell, new Value) {
+	for slot, fn := range d.watchpoints {
+		if val, ok := i.frames[0].Slot(slot); ok && val == Value(cell) {
+			fn(cell.Load(), new)
+		}
+	}
+}
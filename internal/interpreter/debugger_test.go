@@ -0,0 +1,202 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugger_SetBreakpoint(t *testing.T) {
+	var code bytecode.Bytecode
+	code.Emit(bytecode.New(bytecode.I32LOAD, 1))
+	code.Emit(bytecode.New(bytecode.SLTSTORE, 0))
+	offset := code.Emit(bytecode.New(bytecode.I32LOAD, 2))
+	code.Emit(bytecode.New(bytecode.I32LOAD, 3))
+	code.Emit(bytecode.New(bytecode.I32ADD))
+
+	var offsets []int
+	var stacks [][]Value
+	d := NewDebugger(func(i *Interpreter, offset int) Action {
+		offsets = append(offsets, offset)
+		stacks = append(stacks, i.Stack())
+
+		val, ok := i.Slot(0)
+		assert.True(t, ok)
+		assert.Equal(t, Int32(1), val)
+
+		return Step
+	})
+	d.SetBreakpoint(offset)
+	assert.Equal(t, []int{offset}, d.Breakpoints())
+
+	interpreter := New(WithDebugger(d))
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []int{offset, offset + 5, offset + 10}, offsets)
+	assert.Equal(t, [][]Value{{}, {Int32(2)}, {Int32(2), Int32(3)}}, stacks)
+}
+
+func TestDebugger_SetLineBreakpoint(t *testing.T) {
+	var fn bytecode.Bytecode
+	fn.Mark(5)
+	fn.Emit(bytecode.New(bytecode.I32LOAD, 1))
+	fn.Mark(6)
+	fn.Emit(bytecode.New(bytecode.RET))
+
+	var code bytecode.Bytecode
+	code.Functions = []bytecode.Function{{Name: "f", Code: fn}}
+	code.Mark(1)
+	code.Emit(bytecode.New(bytecode.I32LOAD, 1))
+	code.Mark(2)
+	offset := code.Emit(bytecode.New(bytecode.I32LOAD, 2))
+	code.Emit(bytecode.New(bytecode.I32ADD))
+	code.Mark(3)
+	code.Emit(
+		bytecode.New(bytecode.FUNCLOAD, 0),
+		bytecode.New(bytecode.CALL, 0),
+	)
+
+	type pause struct {
+		line  int
+		stack []Value
+	}
+
+	var offsets []int
+	var pauses []pause
+	d := NewDebugger(func(i *Interpreter, offset int) Action {
+		offsets = append(offsets, offset)
+		pauses = append(pauses, pause{line: i.current().Line(offset), stack: i.Stack()})
+		return Continue
+	})
+	d.SetLineBreakpoint(2)
+	d.SetLineBreakpoint(6)
+	d.SetLineBreakpoint(9)
+	assert.Equal(t, []int{2, 6, 9}, d.LineBreakpoints())
+
+	interpreter := New(WithDebugger(d))
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []int{offset, 5}, offsets)
+	assert.Equal(t, []pause{
+		{line: 2, stack: []Value{Int32(1)}},
+		{line: 6, stack: []Value{Int32(3), Int32(1)}},
+	}, pauses)
+
+	d.ClearLineBreakpoint(2)
+	d.ClearLineBreakpoint(6)
+	offsets = nil
+
+	err = interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Empty(t, offsets)
+}
+
+func TestDebugger_ClearBreakpoint(t *testing.T) {
+	var code bytecode.Bytecode
+	code.Emit(bytecode.New(bytecode.NOP))
+
+	d := NewDebugger(func(_ *Interpreter, _ int) Action {
+		assert.Fail(t, "unexpected pause")
+		return Continue
+	})
+	d.SetBreakpoint(0)
+	d.ClearBreakpoint(0)
+
+	interpreter := New(WithDebugger(d))
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+}
+
+func TestDebugger_SetWatchpoint(t *testing.T) {
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.SLTSTORE, 0),
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.SLTSTORE, 1),
+		bytecode.New(bytecode.I32LOAD, 3),
+		bytecode.New(bytecode.SLTSTORE, 0),
+	)
+
+	var changes [][2]Value
+	d := NewDebugger(nil)
+	d.SetWatchpoint(0, func(old, new Value) {
+		changes = append(changes, [2]Value{old, new})
+	})
+
+	interpreter := New(WithDebugger(d))
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]Value{{Undefined{}, Int32(1)}, {Int32(1), Int32(3)}}, changes)
+
+	d.ClearWatchpoint(0)
+	changes = nil
+
+	err = interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Nil(t, changes)
+}
+
+func TestDebugger_SetWatchpoint_Function(t *testing.T) {
+	var set bytecode.Bytecode
+	set.Emit(
+		bytecode.New(bytecode.I32LOAD, 5),
+		bytecode.New(bytecode.SLTSTORE, 0),
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.GLBSTORE, 0),
+		bytecode.New(bytecode.UNDEFLOAD),
+		bytecode.New(bytecode.RET),
+	)
+
+	var closure bytecode.Bytecode
+	closure.Emit(
+		bytecode.New(bytecode.I32LOAD, 3),
+		bytecode.New(bytecode.UPVSTORE, 0),
+		bytecode.New(bytecode.UNDEFLOAD),
+		bytecode.New(bytecode.RET),
+	)
+
+	var code bytecode.Bytecode
+	code.Functions = []bytecode.Function{
+		{Name: "set", Params: 1, Code: set},
+		{Name: "closure", Upvalues: 1, Code: closure},
+	}
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.SLTSTORE, 0),
+		bytecode.New(bytecode.FUNCLOAD, 0),
+		bytecode.New(bytecode.I32LOAD, 9),
+		bytecode.New(bytecode.CALL, 1),
+		bytecode.New(bytecode.POP),
+		bytecode.New(bytecode.CELLREF, 0),
+		bytecode.New(bytecode.CLOSURE, 1, 1),
+		bytecode.New(bytecode.CALL, 0),
+		bytecode.New(bytecode.POP),
+		bytecode.New(bytecode.I32LOAD, 4),
+		bytecode.New(bytecode.CELLSTORE, 0),
+	)
+
+	var changes [][2]Value
+	d := NewDebugger(nil)
+	d.SetWatchpoint(0, func(old, new Value) {
+		changes = append(changes, [2]Value{old, new})
+	})
+
+	interpreter := New(WithDebugger(d))
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]Value{
+		{Undefined{}, Int32(1)},
+		{Int32(1), Int32(2)},
+		{Int32(2), Int32(3)},
+		{Int32(3), Int32(4)},
+	}, changes)
+}
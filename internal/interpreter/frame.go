@@ -1,12 +1,25 @@
 package interpreter
 
+import (
+	"fmt"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+)
+
+// MaxSlots bounds the slots of a frame, so a corrupt slot index fails instead
+// of allocating without limit.
+const MaxSlots = 1 << 16
+
 type Frame struct {
-	slots []Value
-	ip    int
+	name     string
+	code     *bytecode.Bytecode
+	upvalues []*Cell
+	slots    []Value
+	ip       int
 }
 
 func (f *Frame) Slot(idx int) (Value, bool) {
-	if len(f.slots) <= idx {
+	if idx < 0 || len(f.slots) <= idx {
 		return nil, false
 	}
 	val := f.slots[idx]
@@ -16,11 +29,27 @@ func (f *Frame) Slot(idx int) (Value, bool) {
 	return val, true
 }
 
-func (f *Frame) SetSlot(idx int, val Value) {
+func (f *Frame) SetSlot(idx int, val Value) error {
+	if idx < 0 || idx >= MaxSlots {
+		return fmt.Errorf("%w: slot %d out of range", ErrInvalidBytecode, idx)
+	}
 	if len(f.slots) <= idx {
-		slots := make([]Value, (idx+1)*2)
+		slots := make([]Value, min((idx+1)*2, MaxSlots))
 		copy(slots, f.slots)
 		f.slots = slots
 	}
 	f.slots[idx] = val
+	return nil
+}
+
+func (f *Frame) Cell(idx int) (*Cell, error) {
+	val, _ := f.Slot(idx)
+	if cell, ok := val.(*Cell); ok {
+		return cell, nil
+	}
+	cell := &Cell{Value: val}
+	if err := f.SetSlot(idx, cell); err != nil {
+		return nil, err
+	}
+	return cell, nil
 }
@@ -0,0 +1,131 @@
+package interpreter
+
+import "strconv"
+
+type Inspection struct {
+	Globals map[string]any
+	Stack   []any
+	Frames  []FrameInspection
+	Heap    []ObjectInspection
+}
+
+type FrameInspection struct {
+	IP    int
+	Slots map[int]any
+}
+
+// ObjectInspection is a value that lives on the heap: a cell holding a
+// variable captured by a closure, a closure itself, or an array passed in by
+// the embedder. Other embedder objects cannot list their keys and have no
+// properties.
+type ObjectInspection struct {
+	Type       string
+	Properties map[string]any
+}
+
+// Inspect returns a copy of the values i holds. Globals are named by the debug
+// info of the code run since the last Reset; compile with debug info to see
+// them.
+func (i *Interpreter) Inspect() Inspection {
+	var globals map[string]any
+	for idx, name := range i.names {
+		if val, ok := i.frames[0].Slot(idx); ok {
+			if globals == nil {
+				globals = make(map[string]any)
+			}
+			globals[name] = val.Interface()
+		}
+	}
+
+	stack := make([]any, i.sp)
+	for j, val := range i.stack[:i.sp] {
+		stack[j] = val.Interface()
+	}
+
+	frames := make([]FrameInspection, i.fp)
+	for j, frame := range i.frames[:i.fp] {
+		slots := make(map[int]any)
+		for idx, val := range frame.slots {
+			if val != nil {
+				slots[idx] = val.Interface()
+			}
+		}
+		frames[j] = FrameInspection{IP: frame.ip, Slots: slots}
+	}
+
+	var heap []ObjectInspection
+	i.heap(func(val Value) {
+		heap = append(heap, inspect(val))
+	})
+
+	return Inspection{Globals: globals, Stack: stack, Frames: frames, Heap: heap}
+}
+
+// heap calls fn once for each heap value reachable from the stack and the
+// frames, in the order they are first reached.
+func (i *Interpreter) heap(fn func(Value)) {
+	visits := map[any]bool{}
+
+	var visit func(val Value)
+	visit = func(val Value) {
+		switch v := val.(type) {
+		case *Cell:
+			if visits[v] {
+				return
+			}
+			visits[v] = true
+			fn(v)
+			visit(v.Value)
+		case Function:
+			if len(v.Upvalues) == 0 {
+				return
+			}
+			if visits[&v.Upvalues[0]] {
+				return
+			}
+			visits[&v.Upvalues[0]] = true
+			fn(v)
+			for _, cell := range v.Upvalues {
+				visit(cell)
+			}
+		case Object:
+			fn(v)
+		}
+	}
+
+	for _, val := range i.stack[:i.sp] {
+		visit(val)
+	}
+	for _, frame := range i.frames[:i.fp] {
+		for _, val := range frame.slots {
+			visit(val)
+		}
+		for _, cell := range frame.upvalues {
+			visit(cell)
+		}
+	}
+}
+
+func inspect(val Value) ObjectInspection {
+	switch v := val.(type) {
+	case *Cell:
+		return ObjectInspection{Type: "cell", Properties: map[string]any{"value": v.Interface()}}
+	case Function:
+		upvalues := make([]any, len(v.Upvalues))
+		for j, cell := range v.Upvalues {
+			upvalues[j] = cell.Interface()
+		}
+		return ObjectInspection{Type: "function", Properties: map[string]any{"name": v.Name, "upvalues": upvalues}}
+	case Array:
+		props := map[string]any{"length": v.Len()}
+		for j := 0; j < v.Len(); j++ {
+			key := strconv.Itoa(j)
+			if prop, err := v.Get(key); err == nil && prop != nil {
+				props[key] = prop.Interface()
+			}
+		}
+		return ObjectInspection{Type: "array", Properties: props}
+	default:
+		return ObjectInspection{Type: val.Type().String()}
+	}
+}
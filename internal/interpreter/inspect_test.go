@@ -0,0 +1,66 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpreter_Inspect(t *testing.T) {
+	interpreter := New()
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.STRLOAD, 0),
+		bytecode.New(bytecode.SLTSTORE, 1),
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.BOOLLOAD, 1),
+	)
+	code.Store(bytecode.String("foo"))
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	assert.Equal(t, Inspection{
+		Stack: []any{int32(1), true},
+		Frames: []FrameInspection{
+			{IP: len(code.Instructions) - 1, Slots: map[int]any{1: "foo"}},
+		},
+	}, interpreter.Inspect())
+}
+
+func TestInterpreter_Inspect_Heap(t *testing.T) {
+	interpreter := New()
+
+	var fn bytecode.Bytecode
+	fn.Emit(
+		bytecode.New(bytecode.UPVLOAD, 0),
+		bytecode.New(bytecode.RET),
+	)
+
+	var code bytecode.Bytecode
+	code.Functions = []bytecode.Function{{Name: "get", Upvalues: 1, Code: fn}}
+	code.Symbols = []bytecode.Symbol{{Index: 0, Name: "x"}, {Index: 1, Name: "get"}}
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.CELLSTORE, 0),
+		bytecode.New(bytecode.CELLREF, 0),
+		bytecode.New(bytecode.CLOSURE, 0, 1),
+		bytecode.New(bytecode.SLTSTORE, 1),
+	)
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	inspection := interpreter.Inspect()
+	assert.Equal(t, int32(1), inspection.Globals["x"])
+	assert.Contains(t, inspection.Globals, "get")
+	assert.Equal(t, []ObjectInspection{
+		{Type: "cell", Properties: map[string]any{"value": int32(1)}},
+		{Type: "function", Properties: map[string]any{"name": "get", "upvalues": []any{int32(1)}}},
+	}, inspection.Heap)
+
+	interpreter.Reset()
+	assert.Nil(t, interpreter.Inspect().Globals)
+}
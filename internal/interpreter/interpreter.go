@@ -1,205 +1,1024 @@
 package interpreter
 
 import (
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"math"
-	"strconv"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
 
 	"github.com/siyul-park/minijs/internal/bytecode"
 )
 
 type Interpreter struct {
-	stack  []Value
-	frames []Frame
-	sp     int
-	fp     int
+	stack     []Value
+	frames    []Frame
+	sp        int
+	fp        int
+	peak      int
+	constants int
+	trace     io.Writer
+	profiler  *profiler
+	sampler   *sampler
+	debugger  *Debugger
+	code      bytecode.Bytecode
+	names     map[int]string
+	running   bool
+	interrupt atomic.Pointer[error]
+	pre       []Hook
+	post      []Hook
+	handlers  map[bytecode.Opcode]Handler
+	hosts     map[string]Host
+	limits    *Limits
+	steps     int
 }
 
-func New() *Interpreter {
+// Limits caps the size of the values a script builds or receives from the
+// embedder and how much work a run may do. A zero field means no limit, except
+// that calls still nest at most 10000 deep without MaxCallDepth.
+type Limits struct {
+	MaxStringLength int
+	MaxArrayLength  int
+	MaxCallDepth    int
+	MaxInstructions int
+}
+
+// Error is a failure while running a script. Stack lists the calls that were
+// active when it happened, innermost first, ending with the top-level code.
+type Error struct {
+	Err   error
+	Stack []StackFrame
+}
+
+// StackFrame is a call in the stack of an Error. Function is empty for the
+// top-level code, and Line is 0 when the code has no line information.
+type StackFrame struct {
+	Function string
+	Line     int
+}
+
+type Hook func(ip int, op bytecode.Opcode)
+
+type Handler func(i *Interpreter, operands []uint64) error
+
+type Status int
+
+type Option func(*Interpreter)
+
+const (
+	Done Status = iota
+	Paused
+)
+
+// maxCallDepth is the number of nested script function calls after which
+// another call fails, so that endless recursion ends with an error instead of
+// exhausting memory.
+const maxCallDepth = 10000
+
+var (
+	ErrNotPaused      = errors.New("not paused")
+	ErrStackUnderflow = errors.New("stack underflow")
+	ErrTypeMismatch   = errors.New("type mismatch")
+	ErrUnknownExport  = errors.New("unknown export")
+	ErrUnknownHost    = errors.New("unknown host function")
+	ErrLimitExceeded  = errors.New("limit exceeded")
+	ErrInterrupted    = errors.New("interrupted")
+)
+
+func WithTrace(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.trace = w
+	}
+}
+
+func WithProfile() Option {
+	return func(i *Interpreter) {
+		i.profiler = &profiler{}
+	}
+}
+
+func WithPprofLabels(name string) Option {
+	return func(i *Interpreter) {
+		i.sampler = &sampler{
+			labels:   pprof.Labels("script", name),
+			interval: sampleInterval,
+		}
+	}
+}
+
+func WithDebugger(d *Debugger) Option {
+	return func(i *Interpreter) {
+		i.debugger = d
+	}
+}
+
+func WithPreHook(hook Hook) Option {
+	return func(i *Interpreter) {
+		i.pre = append(i.pre, hook)
+	}
+}
+
+func WithPostHook(hook Hook) Option {
+	return func(i *Interpreter) {
+		i.post = append(i.post, hook)
+	}
+}
+
+func WithHandler(op bytecode.Opcode, handler Handler) Option {
+	return func(i *Interpreter) {
+		if i.handlers == nil {
+			i.handlers = map[bytecode.Opcode]Handler{}
+		}
+		i.handlers[op] = handler
+	}
+}
+
+func WithHost(name string, fn Host) Option {
+	return func(i *Interpreter) {
+		if i.hosts == nil {
+			i.hosts = map[string]Host{}
+		}
+		i.hosts[name] = fn
+	}
+}
+
+// WithLimits makes strings built by concatenation and values returned by host
+// functions or read from objects fail with ErrLimitExceeded when they exceed l,
+// as do calls nested deeper than l allows and runs of Execute, Call or Apply
+// that execute more instructions.
+func WithLimits(l Limits) Option {
+	return func(i *Interpreter) {
+		i.limits = &l
+	}
+}
+
+func New(opts ...Option) *Interpreter {
 	i := &Interpreter{
 		stack:  make([]Value, 64),
 		frames: make([]Frame, 64),
 	}
+	for _, opt := range opts {
+		opt(i)
+	}
 	i.call(Frame{ip: -1})
 	return i
 }
 
-func (i *Interpreter) Pop() Value {
+func (i *Interpreter) Stack() []Value {
+	stack := make([]Value, i.sp)
+	copy(stack, i.stack[:i.sp])
+	return stack
+}
+
+func (i *Interpreter) Slot(idx int) (Value, bool) {
+	return i.frames[i.fp-1].Slot(idx)
+}
+
+// Global returns the value in global slot idx.
+func (i *Interpreter) Global(idx int) (Value, bool) {
+	return i.frames[0].Slot(idx)
+}
+
+// SetGlobal stores val in global slot idx, where compiled code reads the global
+// variable assigned that slot.
+func (i *Interpreter) SetGlobal(idx int, val Value) error {
+	return i.frames[0].SetSlot(idx, val)
+}
+
+func (i *Interpreter) Top() (Value, error) {
+	if i.sp == 0 {
+		return nil, ErrStackUnderflow
+	}
+	return i.stack[i.sp-1], nil
+}
+
+func (i *Interpreter) Push(val Value) {
+	i.push(val)
+}
+
+func (i *Interpreter) Pop() (Value, error) {
 	return i.pop()
 }
 
+func (i *Interpreter) Profile() []Profile {
+	if i.profiler == nil {
+		return nil
+	}
+	return i.profiler.profiles()
+}
+
+// Interrupt stops the running script before its next instruction, making the
+// run fail with err, or ErrInterrupted if err is nil. An interrupt that arrives
+// while nothing runs is dropped when the next run starts.
+func (i *Interpreter) Interrupt(err error) {
+	if err == nil {
+		err = ErrInterrupted
+	}
+	i.interrupt.Store(&err)
+}
+
+func (i *Interpreter) Reset() {
+	clear(i.stack[:i.sp])
+	i.sp = 0
+	i.peak = 0
+
+	for i.fp > 0 {
+		i.exit()
+	}
+	i.call(Frame{ip: -1})
+	i.code = bytecode.Bytecode{}
+	clear(i.names)
+	i.running = false
+	i.interrupt.Store(nil)
+}
+
 func (i *Interpreter) Execute(code bytecode.Bytecode) error {
+	_, err := i.ExecuteN(code, -1)
+	return err
+}
+
+func (i *Interpreter) ExecuteN(code bytecode.Bytecode, n int) (Status, error) {
+	if err := Verify(code); err != nil {
+		return Done, err
+	}
+
+	i.load(code)
+	i.frames[i.fp-1].ip = -1
+	return i.Resume(n)
+}
+
+func (i *Interpreter) Call(code bytecode.Bytecode, name string, args ...Value) (Value, error) {
+	if err := Verify(code); err != nil {
+		return nil, err
+	}
+	idx, ok := code.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownExport, name)
+	}
+
+	i.load(code)
+	i.frames[i.fp-1].ip = len(code.Instructions) - 1
+
+	fn := &i.code.Functions[idx]
+	i.call(Frame{name: fn.Name, code: &fn.Code, ip: -1})
+	for j, arg := range args {
+		if j < fn.Params {
+			if err := i.frames[i.fp-1].SetSlot(j, arg); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, err := i.Resume(-1); err != nil {
+		return nil, err
+	}
+	return i.Pop()
+}
+
+// Apply calls fn, a script or host function, with args on i, which must not be
+// running, and returns its result. Script functions read and write the global
+// variables of i.
+func (i *Interpreter) Apply(fn Value, args ...Value) (Value, error) {
+	switch fn := fn.(type) {
+	case Host:
+		val, err := fn(args)
+		if err != nil {
+			return nil, err
+		}
+		if val == nil {
+			val = Undefined{}
+		}
+		return val, nil
+	case Function:
+		i.load(bytecode.Bytecode{})
+		i.frames[i.fp-1].ip = -1
+
+		i.call(Frame{name: fn.Name, code: &fn.Code, upvalues: fn.Upvalues, ip: -1})
+		for j, arg := range args {
+			if j < fn.Params {
+				if err := i.frames[i.fp-1].SetSlot(j, arg); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if _, err := i.Resume(-1); err != nil {
+			return nil, err
+		}
+		return i.Pop()
+	default:
+		return nil, fmt.Errorf("%w: %v is not a function", ErrTypeMismatch, fn)
+	}
+}
+
+func (i *Interpreter) Resume(n int) (Status, error) {
+	if !i.running {
+		return Done, ErrNotPaused
+	}
+
+	var status Status
+	var err error
+	if i.sampler != nil {
+		pprof.Do(context.Background(), i.sampler.labels, func(ctx context.Context) {
+			i.sampler.ctx = ctx
+			status, err = i.execute(n)
+		})
+	} else {
+		status, err = i.execute(n)
+	}
+
+	if err != nil {
+		err = &Error{Err: err, Stack: i.stackTrace()}
+		for i.fp > 1 {
+			i.exit()
+		}
+	}
+	if err != nil || status == Done {
+		i.code = bytecode.Bytecode{}
+		i.running = false
+	}
+	return status, err
+}
+
+// stackTrace returns the calls that are active, innermost first.
+func (i *Interpreter) stackTrace() []StackFrame {
+	stack := make([]StackFrame, 0, i.fp)
+	for j := i.fp - 1; j >= 0; j-- {
+		frame := &i.frames[j]
+		code := frame.code
+		if code == nil {
+			code = &i.code
+		}
+		stack = append(stack, StackFrame{Function: frame.name, Line: code.Line(frame.ip)})
+	}
+	return stack
+}
+
+func (i *Interpreter) execute(n int) (Status, error) {
+	code := i.current()
 	instructions := code.Instructions
 	constants := code.Constants
 
-	i.frames[i.fp-1].ip = -1
-	for i.frames[i.fp-1].ip < len(instructions)-1 {
+	for ; i.frames[i.fp-1].ip < len(instructions)-1; n-- {
+		if n == 0 {
+			return Paused, nil
+		}
+		if i.interrupt.Load() != nil {
+			if err := i.interrupt.Swap(nil); err != nil {
+				return Done, *err
+			}
+		}
+		if i.limits != nil && i.limits.MaxInstructions > 0 {
+			if i.steps++; i.steps > i.limits.MaxInstructions {
+				return Done, fmt.Errorf("%w: more than %d instructions executed", ErrLimitExceeded, i.limits.MaxInstructions)
+			}
+		}
 		i.frames[i.fp-1].ip++
 
 		ip := i.frames[i.fp-1].ip
+		start := ip
 		opcode := bytecode.Opcode(instructions[ip])
 
+		if i.debugger != nil {
+			i.debugger.pause(i, start)
+		}
+		for _, hook := range i.pre {
+			hook(start, opcode)
+		}
+
+		var now time.Time
+		if i.profiler != nil {
+			now = time.Now()
+		}
+
+		var function string
+		if i.sampler != nil {
+			function = i.frames[i.fp-1].name
+		}
+
+		var inst bytecode.Instruction
+		if i.trace != nil {
+			inst, _ = code.Fetch(start)
+		}
+
+		switched := false
 		switch opcode {
 		case bytecode.NOP:
 		case bytecode.POP:
-			i.pop()
+			if _, err := i.pop(); err != nil {
+				return Done, err
+			}
+		case bytecode.DUP:
+			val, err := i.Top()
+			if err != nil {
+				return Done, err
+			}
+			i.push(val)
 		case bytecode.SLTLOAD:
-			idx := binary.BigEndian.Uint16(instructions[ip+1:])
+			idx, width := operand(instructions, ip+1)
 			var val Value = Undefined{}
 			if v, ok := i.frames[i.fp-1].Slot(int(idx)); ok {
 				val = v
 			}
 			i.push(val)
-			ip += 2
+			ip += width
 		case bytecode.SLTSTORE:
-			idx := binary.BigEndian.Uint16(instructions[ip+1:])
-			val := i.pop()
-			i.frames[i.fp-1].SetSlot(int(idx), val)
-			ip += 2
+			idx, width := operand(instructions, ip+1)
+			val, err := i.pop()
+			if err != nil {
+				return Done, err
+			}
+			if i.debugger != nil && i.fp == 1 {
+				i.watch(int(idx), val)
+			}
+			if err := i.frames[i.fp-1].SetSlot(int(idx), val); err != nil {
+				return Done, err
+			}
+			ip += width
+		case bytecode.GLBLOAD:
+			idx, width := operand(instructions, ip+1)
+			var val Value = Undefined{}
+			if v, ok := i.frames[0].Slot(int(idx)); ok {
+				val = v
+			}
+			i.push(val)
+			ip += width
+		case bytecode.GLBSTORE:
+			idx, width := operand(instructions, ip+1)
+			val, err := i.pop()
+			if err != nil {
+				return Done, err
+			}
+			if i.debugger != nil {
+				i.watch(int(idx), val)
+			}
+			if err := i.frames[0].SetSlot(int(idx), val); err != nil {
+				return Done, err
+			}
+			ip += width
+		case bytecode.CELLLOAD:
+			idx, width := operand(instructions, ip+1)
+			cell, err := i.frames[i.fp-1].Cell(int(idx))
+			if err != nil {
+				return Done, err
+			}
+			i.push(cell.Load())
+			ip += width
+		case bytecode.CELLSTORE:
+			idx, width := operand(instructions, ip+1)
+			val, err := i.pop()
+			if err != nil {
+				return Done, err
+			}
+			cell, err := i.frames[i.fp-1].Cell(int(idx))
+			if err != nil {
+				return Done, err
+			}
+			if i.debugger != nil {
+				i.debugger.watchCell(i, cell, val)
+			}
+			cell.Value = val
+			ip += width
+		case bytecode.CELLREF:
+			idx, width := operand(instructions, ip+1)
+			cell, err := i.frames[i.fp-1].Cell(int(idx))
+			if err != nil {
+				return Done, err
+			}
+			i.push(cell)
+			ip += width
+		case bytecode.UPVLOAD:
+			idx, width := operand(instructions, ip+1)
+			i.push(i.frames[i.fp-1].upvalues[idx].Load())
+			ip += width
+		case bytecode.UPVSTORE:
+			idx, width := operand(instructions, ip+1)
+			val, err := i.pop()
+			if err != nil {
+				return Done, err
+			}
+			cell := i.frames[i.fp-1].upvalues[idx]
+			if i.debugger != nil {
+				i.debugger.watchCell(i, cell, val)
+			}
+			cell.Value = val
+			ip += width
+		case bytecode.UPVREF:
+			idx, width := operand(instructions, ip+1)
+			i.push(i.frames[i.fp-1].upvalues[idx])
+			ip += width
 		case bytecode.UNDEFLOAD:
 			i.push(Undefined{})
 		case bytecode.UNDEFTOF64:
-			i.pop()
+			if _, err := pop[Undefined](i); err != nil {
+				return Done, err
+			}
 			i.push(Float64(math.NaN()))
 		case bytecode.UNDEFTOSTR:
-			val, _ := i.pop().(Undefined)
+			val, err := pop[Undefined](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(String(val.String()))
 		case bytecode.NULLLOAD:
 			i.push(Null{})
 		case bytecode.NULLTOI32:
-			i.pop()
+			if _, err := pop[Null](i); err != nil {
+				return Done, err
+			}
 			i.push(Int32(0))
 		case bytecode.NULLTOSTR:
-			val, _ := i.pop().(Null)
+			val, err := pop[Null](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(String(val.String()))
 		case bytecode.BOOLLOAD:
 			val := instructions[ip+1]
 			i.push(Bool(val))
 			ip += 1
 		case bytecode.BOOLTOI32:
-			val, _ := i.pop().(Bool)
+			val, err := pop[Bool](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(Int32(val))
 		case bytecode.BOOLTOSTR:
-			val, _ := i.pop().(Bool)
+			val, err := pop[Bool](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(String(val.String()))
 		case bytecode.I32LOAD:
 			val := Int32(binary.BigEndian.Uint32(instructions[ip+1:]))
 			i.push(val)
 			ip += 4
 		case bytecode.I32ADD:
-			val2, _ := i.pop().(Int32)
-			val1, _ := i.pop().(Int32)
+			val1, val2, err := pop2[Int32](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(val1 + val2)
 		case bytecode.I32SUB:
-			val2, _ := i.pop().(Int32)
-			val1, _ := i.pop().(Int32)
+			val1, val2, err := pop2[Int32](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(val1 - val2)
 		case bytecode.I32MUL:
-			val2, _ := i.pop().(Int32)
-			val1, _ := i.pop().(Int32)
+			val1, val2, err := pop2[Int32](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(val1 * val2)
 		case bytecode.I32DIV:
-			val2, _ := i.pop().(Int32)
-			val1, _ := i.pop().(Int32)
-			i.push(val1 / val2)
+			val1, val2, err := pop2[Int32](i)
+			if err != nil {
+				return Done, err
+			}
+			if val2 == 0 {
+				i.push(Float64(val1) / Float64(val2))
+			} else {
+				i.push(val1 / val2)
+			}
 		case bytecode.I32MOD:
-			val2, _ := i.pop().(Int32)
-			val1, _ := i.pop().(Int32)
-			i.push(val1 % val2)
+			val1, val2, err := pop2[Int32](i)
+			if err != nil {
+				return Done, err
+			}
+			if val2 == 0 {
+				i.push(Float64(math.NaN()))
+			} else {
+				i.push(val1 % val2)
+			}
+		case bytecode.I32SHL:
+			val1, val2, err := pop2[Int32](i)
+			if err != nil {
+				return Done, err
+			}
+			i.push(val1 << (uint32(val2) & 0x1F))
+		case bytecode.I32SHR:
+			val1, val2, err := pop2[Int32](i)
+			if err != nil {
+				return Done, err
+			}
+			i.push(val1 >> (uint32(val2) & 0x1F))
 		case bytecode.I32TOBOOL:
-			val, _ := i.pop().(Int32)
+			val, err := pop[Int32](i)
+			if err != nil {
+				return Done, err
+			}
 			if val > 0 {
 				val = 1
 			}
 			i.push(Bool(val))
 		case bytecode.I32TOF64:
-			val, _ := i.pop().(Int32)
+			val, err := pop[Int32](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(Float64(val))
 		case bytecode.I32TOSTR:
-			val, _ := i.pop().(Int32)
+			val, err := pop[Int32](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(String(val.String()))
 		case bytecode.F64LOAD:
 			val := Float64(math.Float64frombits(binary.BigEndian.Uint64(instructions[ip+1:])))
 			i.push(val)
 			ip += 8
+		case bytecode.F64CONST:
+			idx, width := operand(instructions, ip+1)
+			i.push(Float64(constants[idx].(bytecode.Float64)))
+			ip += width
 		case bytecode.F64ADD:
-			val2, _ := i.pop().(Float64)
-			val1, _ := i.pop().(Float64)
+			val1, val2, err := pop2[Float64](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(val1 + val2)
 		case bytecode.F64SUB:
-			val2, _ := i.pop().(Float64)
-			val1, _ := i.pop().(Float64)
+			val1, val2, err := pop2[Float64](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(val1 - val2)
 		case bytecode.F64MUL:
-			val2, _ := i.pop().(Float64)
-			val1, _ := i.pop().(Float64)
+			val1, val2, err := pop2[Float64](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(val1 * val2)
 		case bytecode.F64DIV:
-			val2, _ := i.pop().(Float64)
-			val1, _ := i.pop().(Float64)
+			val1, val2, err := pop2[Float64](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(val1 / val2)
 		case bytecode.F64MOD:
-			val2, _ := i.pop().(Float64)
-			val1, _ := i.pop().(Float64)
+			val1, val2, err := pop2[Float64](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(Float64(math.Mod(float64(val1), float64(val2))))
 		case bytecode.F64TOI32:
-			val, _ := i.pop().(Float64)
-			i.push(Int32(val))
+			val, err := pop[Float64](i)
+			if err != nil {
+				return Done, err
+			}
+			i.push(ToInt32(val))
 		case bytecode.F64TOSTR:
-			val, _ := i.pop().(Float64)
+			val, err := pop[Float64](i)
+			if err != nil {
+				return Done, err
+			}
 			i.push(String(val.String()))
 		case bytecode.STRLOAD:
-			offset := int(binary.BigEndian.Uint32(instructions[ip+1:]))
-			size := int(binary.BigEndian.Uint32(instructions[ip+5:]))
-			i.push(String(constants[offset : offset+size]))
-			ip += 8
+			idx, width := operand(instructions, ip+1)
+			i.push(String(constants[idx].(bytecode.String)))
+			ip += width
 		case bytecode.STRADD:
-			val2, _ := i.pop().(String)
-			val1, _ := i.pop().(String)
+			val1, val2, err := pop2[String](i)
+			if err != nil {
+				return Done, err
+			}
+			if err := i.limit(val1 + val2); err != nil {
+				return Done, err
+			}
 			i.push(val1 + val2)
 		case bytecode.STRTOI32:
-			val, _ := i.pop().(String)
-			n, err := strconv.Atoi(string(val))
+			val, err := pop[String](i)
 			if err != nil {
-				n = 0
+				return Done, err
 			}
-			i.push(Int32(n))
+			i.push(ToInt32(val))
 		case bytecode.STRTOF64:
-			val, _ := i.pop().(String)
-			f, err := strconv.ParseFloat(string(val), 64)
+			val, err := pop[String](i)
+			if err != nil {
+				return Done, err
+			}
+			i.push(ToNumber(val))
+		case bytecode.TONUM:
+			val, err := i.pop()
+			if err != nil {
+				return Done, err
+			}
+			i.push(ToNumber(val))
+		case bytecode.TOSTR:
+			val, err := i.pop()
+			if err != nil {
+				return Done, err
+			}
+			i.push(ToString(val))
+		case bytecode.TOBOOL:
+			val, err := i.pop()
+			if err != nil {
+				return Done, err
+			}
+			i.push(ToBool(val))
+		case bytecode.ADD:
+			val2, err := i.pop()
+			if err != nil {
+				return Done, err
+			}
+			val1, err := i.pop()
+			if err != nil {
+				return Done, err
+			}
+			val := Add(val1, val2)
+			if err := i.limit(val); err != nil {
+				return Done, err
+			}
+			i.push(val)
+		case bytecode.FUNCLOAD:
+			idx, width := operand(instructions, ip+1)
+			i.push(Function{Function: &code.Functions[idx]})
+			ip += width
+		case bytecode.CLOSURE:
+			idx, width := operand(instructions, ip+1)
+			ip += width
+			n, width := operand(instructions, ip+1)
+			upvalues := make([]*Cell, n)
+			for j := n - 1; j >= 0; j-- {
+				val, err := pop[*Cell](i)
+				if err != nil {
+					return Done, err
+				}
+				upvalues[j] = val
+			}
+			i.push(Function{Function: &code.Functions[idx], Upvalues: upvalues})
+			ip += width
+		case bytecode.CALL:
+			argc := int(instructions[ip+1])
+			ip += 1
+			if i.sp <= argc {
+				return Done, ErrStackUnderflow
+			}
+			if fn, ok := i.stack[i.sp-argc-1].(Host); ok {
+				val, err := i.invoke(fn, argc)
+				if err != nil {
+					return Done, err
+				}
+				_, _ = i.pop()
+				i.push(val)
+				break
+			}
+			fn, ok := i.stack[i.sp-argc-1].(Function)
+			if !ok {
+				return Done, fmt.Errorf("%w: %v is not a function", ErrTypeMismatch, i.stack[i.sp-argc-1])
+			}
+
+			if depth := i.depth(); i.fp > depth {
+				return Done, fmt.Errorf("%w: maximum call stack size of %d exceeded", ErrLimitExceeded, depth)
+			}
+
+			i.frames[i.fp-1].ip = ip
+			i.call(Frame{name: fn.Name, code: &fn.Code, upvalues: fn.Upvalues, ip: -1})
+			for j := argc - 1; j >= 0; j-- {
+				val, _ := i.pop()
+				if j < fn.Params {
+					if err := i.frames[i.fp-1].SetSlot(j, val); err != nil {
+						return Done, err
+					}
+				}
+			}
+			_, _ = i.pop()
+
+			ip = -1
+			switched = true
+		case bytecode.CALLHOST:
+			idx, width := operand(instructions, ip+1)
+			ip += width
+			argc := int(instructions[ip+1])
+			ip += 1
+			name := string(constants[idx].(bytecode.String))
+			fn, ok := i.hosts[name]
+			if !ok {
+				return Done, fmt.Errorf("%w: %s", ErrUnknownHost, name)
+			}
+			val, err := i.invoke(fn, argc)
 			if err != nil {
-				f = math.NaN()
+				return Done, err
 			}
-			i.push(Float64(f))
+			i.push(val)
+		case bytecode.PROPLOAD:
+			key, err := i.pop()
+			if err != nil {
+				return Done, err
+			}
+			obj, err := i.pop()
+			if err != nil {
+				return Done, err
+			}
+			val, err := Get(obj, key)
+			if err != nil {
+				return Done, err
+			}
+			if err := i.limit(val); err != nil {
+				return Done, err
+			}
+			i.push(val)
+		case bytecode.RET:
+			if i.fp <= 1 {
+				return Done, fmt.Errorf("return outside of function")
+			}
+			val, err := i.pop()
+			if err != nil {
+				return Done, err
+			}
+			i.exit()
+			i.push(val)
+
+			ip = i.frames[i.fp-1].ip
+			switched = true
 		default:
 			typ := bytecode.TypeOf(opcode)
 			if typ == nil {
-				return fmt.Errorf("unknown opcode: %v", opcode)
+				return Done, fmt.Errorf("unknown opcode: %v", opcode)
+			}
+			handler, ok := i.handlers[opcode]
+			if !ok {
+				return Done, fmt.Errorf("unknown opcode: %v", typ.Mnemonic)
 			}
-			return fmt.Errorf("unknown opcode: %v", typ.Mnemonic)
+			inst, width := code.Fetch(start)
+			if err := handler(i, inst.Operands()); err != nil {
+				return Done, err
+			}
+			ip += width - 1
 		}
 
 		i.frames[i.fp-1].ip = ip
+
+		if i.profiler != nil {
+			i.profiler.record(opcode, time.Since(now))
+		}
+		if i.sampler != nil {
+			i.sampler.sample(function, code, start)
+		}
+		for _, hook := range i.post {
+			hook(start, opcode)
+		}
+		if i.trace != nil {
+			if err := i.tracing(inst, start); err != nil {
+				return Done, err
+			}
+		}
+
+		if switched {
+			code = i.current()
+			instructions = code.Instructions
+			constants = code.Constants
+		}
+	}
+	return Done, nil
+}
+
+// watch reports a store of val into global slot idx to the debugger.
+func (i *Interpreter) watch(idx int, val Value) {
+	var old Value = Undefined{}
+	if v, ok := i.frames[0].Slot(idx); ok {
+		old = v
+	}
+	i.debugger.watch(idx, old, val)
+}
+
+func (i *Interpreter) invoke(fn Host, argc int) (Value, error) {
+	if i.sp < argc {
+		return nil, ErrStackUnderflow
+	}
+
+	args := make([]Value, argc)
+	copy(args, i.stack[i.sp-argc:i.sp])
+	clear(i.stack[i.sp-argc : i.sp])
+	i.sp -= argc
+
+	val, err := fn(args)
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		val = Undefined{}
+	}
+	if err := i.limit(val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// depth returns the number of nested script function calls allowed.
+func (i *Interpreter) depth() int {
+	if i.limits != nil && i.limits.MaxCallDepth > 0 {
+		return i.limits.MaxCallDepth
+	}
+	return maxCallDepth
+}
+
+// limit reports whether val fits the limits set with WithLimits.
+func (i *Interpreter) limit(val Value) error {
+	if i.limits == nil {
+		return nil
+	}
+	return i.limits.Check(val)
+}
+
+func (e *Error) Error() string {
+	if len(e.Stack) > 0 && e.Stack[0].Line > 0 {
+		return fmt.Sprintf("line %d: %v", e.Stack[0].Line, e.Err)
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Check returns an error wrapping ErrLimitExceeded if val is a string or array
+// longer than l allows.
+func (l Limits) Check(val Value) error {
+	switch val := val.(type) {
+	case String:
+		if l.MaxStringLength > 0 && len(val) > l.MaxStringLength {
+			return fmt.Errorf("%w: string of length %d exceeds %d", ErrLimitExceeded, len(val), l.MaxStringLength)
+		}
+	case Array:
+		if l.MaxArrayLength > 0 && val.Len() > l.MaxArrayLength {
+			return fmt.Errorf("%w: array of length %d exceeds %d", ErrLimitExceeded, val.Len(), l.MaxArrayLength)
+		}
 	}
 	return nil
 }
 
+func (i *Interpreter) load(code bytecode.Bytecode) {
+	i.code = code
+	i.running = true
+	i.steps = 0
+	i.peak = i.sp
+	i.interrupt.Store(nil)
+	for _, sym := range code.Symbols {
+		if i.names == nil {
+			i.names = map[int]string{}
+		}
+		i.names[sym.Index] = sym.Name
+	}
+	i.constants = 0
+	for _, val := range code.Constants {
+		switch val := val.(type) {
+		case bytecode.String:
+			i.constants += len(val)
+		case bytecode.Float64:
+			i.constants += 8
+		}
+	}
+}
+
+func (i *Interpreter) current() *bytecode.Bytecode {
+	if code := i.frames[i.fp-1].code; code != nil {
+		return code
+	}
+	return &i.code
+}
+
+func (i *Interpreter) tracing(inst bytecode.Instruction, offset int) error {
+	top := "-"
+	if i.sp > 0 {
+		top = fmt.Sprint(i.stack[i.sp-1])
+	}
+	_, err := fmt.Fprintf(i.trace, "%04X\t%-32s sp=%d fp=%d top=%s\n", offset, inst.String(), i.sp, i.fp, top)
+	return err
+}
+
 func (i *Interpreter) call(frame Frame) {
 	if len(i.frames) <= i.fp {
 		i.frames = append(i.frames, make([]Frame, len(i.frames)+1)...)
 	}
+	if frame.slots == nil {
+		frame.slots = i.frames[i.fp].slots
+	}
 	i.frames[i.fp] = frame
 	i.fp++
 }
 
-func (i *Interpreter) exit() Frame {
+func (i *Interpreter) exit() {
 	if i.fp == 0 {
-		return Frame{}
+		return
 	}
 	i.fp--
-	i.frames[i.fp] = Frame{}
-	return i.frames[i.fp]
+	slots := i.frames[i.fp].slots
+	clear(slots)
+	i.frames[i.fp] = Frame{slots: slots}
+}
+
+func operand(instructions []byte, ip int) (int, int) {
+	if b := instructions[ip]; b < 0x80 {
+		return int(b), 1
+	}
+	v, n := binary.Uvarint(instructions[ip:])
+	return int(v), n
 }
 
 func (i *Interpreter) push(val Value) {
@@ -208,12 +1027,43 @@ func (i *Interpreter) push(val Value) {
 	}
 	i.stack[i.sp] = val
 	i.sp++
+	if i.sp > i.peak {
+		i.peak = i.sp
+	}
 }
 
-func (i *Interpreter) pop() Value {
+func (i *Interpreter) pop() (Value, error) {
 	if i.sp == 0 {
-		return nil
+		return nil, ErrStackUnderflow
 	}
 	i.sp--
-	return i.stack[i.sp]
+	val := i.stack[i.sp]
+	i.stack[i.sp] = nil
+	return val, nil
+}
+
+func pop[T Value](i *Interpreter) (T, error) {
+	var zero T
+	val, err := i.pop()
+	if err != nil {
+		return zero, err
+	}
+	v, ok := val.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: expected %v, got %v", ErrTypeMismatch, zero.Type(), val.Type())
+	}
+	return v, nil
+}
+
+func pop2[T Value](i *Interpreter) (T, T, error) {
+	var zero T
+	val2, err := pop[T](i)
+	if err != nil {
+		return zero, zero, err
+	}
+	val1, err := pop[T](i)
+	if err != nil {
+		return zero, zero, err
+	}
+	return val1, val2, nil
 }
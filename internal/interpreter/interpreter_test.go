@@ -1,7 +1,9 @@
 package interpreter
 
 import (
+	"errors"
 	"math"
+	"strings"
 	"testing"
 
 	"github.com/siyul-park/minijs/internal/bytecode"
@@ -12,6 +14,7 @@ func TestInterpreter_Execute(t *testing.T) {
 	tests := []struct {
 		instructions []bytecode.Instruction
 		literals     []string
+		numbers      []float64
 		stack        []Value
 	}{
 		{
@@ -21,9 +24,17 @@ func TestInterpreter_Execute(t *testing.T) {
 		},
 		{
 			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
 				bytecode.New(bytecode.POP),
 			},
 		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.DUP),
+			},
+			stack: []Value{Int32(1), Int32(1)},
+		},
 		{
 			instructions: []bytecode.Instruction{
 				bytecode.New(bytecode.I32LOAD, 1),
@@ -144,6 +155,38 @@ func TestInterpreter_Execute(t *testing.T) {
 			},
 			stack: []Value{Int32(1)},
 		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.I32LOAD, 0),
+				bytecode.New(bytecode.I32DIV),
+			},
+			stack: []Value{Float64(math.Inf(1))},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, uint64(0xFFFFFFFF)),
+				bytecode.New(bytecode.I32LOAD, 0),
+				bytecode.New(bytecode.I32DIV),
+			},
+			stack: []Value{Float64(math.Inf(-1))},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 3),
+				bytecode.New(bytecode.I32LOAD, 2),
+				bytecode.New(bytecode.I32SHL),
+			},
+			stack: []Value{Int32(12)},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, uint64(0xFFFFFFF8)),
+				bytecode.New(bytecode.I32LOAD, 2),
+				bytecode.New(bytecode.I32SHR),
+			},
+			stack: []Value{Int32(-2)},
+		},
 		{
 			instructions: []bytecode.Instruction{
 				bytecode.New(bytecode.I32LOAD, 5),
@@ -179,6 +222,13 @@ func TestInterpreter_Execute(t *testing.T) {
 			},
 			stack: []Value{Float64(1)},
 		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.F64CONST, 0),
+			},
+			numbers: []float64{1.5},
+			stack:   []Value{Float64(1.5)},
+		},
 		{
 			instructions: []bytecode.Instruction{
 				bytecode.New(bytecode.F64LOAD, math.Float64bits(1)),
@@ -235,15 +285,15 @@ func TestInterpreter_Execute(t *testing.T) {
 		},
 		{
 			instructions: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 3),
+				bytecode.New(bytecode.STRLOAD, 0),
 			},
 			literals: []string{"abc"},
 			stack:    []Value{String("abc")},
 		},
 		{
 			instructions: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 3),
-				bytecode.New(bytecode.STRLOAD, 0, 3),
+				bytecode.New(bytecode.STRLOAD, 0),
+				bytecode.New(bytecode.STRLOAD, 0),
 				bytecode.New(bytecode.STRADD),
 			},
 			literals: []string{"abc"},
@@ -251,7 +301,7 @@ func TestInterpreter_Execute(t *testing.T) {
 		},
 		{
 			instructions: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 3),
+				bytecode.New(bytecode.STRLOAD, 0),
 				bytecode.New(bytecode.STRTOI32),
 			},
 			literals: []string{"123"},
@@ -259,19 +309,44 @@ func TestInterpreter_Execute(t *testing.T) {
 		},
 		{
 			instructions: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 1),
+				bytecode.New(bytecode.STRLOAD, 0),
 				bytecode.New(bytecode.STRTOF64),
 			},
 			literals: []string{"1"},
 			stack:    []Value{Float64(1)},
 		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.BOOLLOAD, 1),
+				bytecode.New(bytecode.TONUM),
+			},
+			stack: []Value{Float64(1)},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.NULLLOAD),
+				bytecode.New(bytecode.TOSTR),
+			},
+			stack: []Value{String("null")},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.STRLOAD, 0),
+				bytecode.New(bytecode.TOBOOL),
+			},
+			literals: []string{"foo"},
+			stack:    []Value{Bool(1)},
+		},
 	}
 
 	for _, tt := range tests {
 		var code bytecode.Bytecode
 		code.Emit(tt.instructions...)
 		for _, c := range tt.literals {
-			code.Store([]byte(c + "\x00"))
+			code.Store(bytecode.String(c))
+		}
+		for _, n := range tt.numbers {
+			code.Store(bytecode.Float64(n))
 		}
 
 		t.Run(code.String(), func(t *testing.T) {
@@ -281,12 +356,779 @@ func TestInterpreter_Execute(t *testing.T) {
 			assert.NoError(t, err)
 
 			for _, val := range tt.stack {
-				assert.Equal(t, val, interpreter.Pop())
+				v, err := interpreter.Pop()
+				assert.NoError(t, err)
+				assert.Equal(t, val, v)
 			}
 		})
 	}
 }
 
+func TestInterpreter_Execute_Call(t *testing.T) {
+	var fn bytecode.Bytecode
+	fn.Emit(
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.SLTLOAD, 1),
+		bytecode.New(bytecode.I32ADD),
+		bytecode.New(bytecode.GLBLOAD, 0),
+		bytecode.New(bytecode.I32ADD),
+		bytecode.New(bytecode.RET),
+	)
+
+	var code bytecode.Bytecode
+	code.Functions = []bytecode.Function{{Name: "add", Params: 2, Code: fn}}
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 3),
+		bytecode.New(bytecode.SLTSTORE, 0),
+		bytecode.New(bytecode.FUNCLOAD, 0),
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.CALL, 2),
+		bytecode.New(bytecode.SLTLOAD, 0),
+	)
+
+	interpreter := New()
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Equal(t, []Value{Int32(6), Int32(3)}, interpreter.Stack())
+}
+
+func TestInterpreter_Call(t *testing.T) {
+	var fn bytecode.Bytecode
+	fn.Emit(
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.SLTLOAD, 1),
+		bytecode.New(bytecode.I32ADD),
+		bytecode.New(bytecode.GLBLOAD, 0),
+		bytecode.New(bytecode.I32ADD),
+		bytecode.New(bytecode.RET),
+	)
+
+	var code bytecode.Bytecode
+	code.Functions = []bytecode.Function{{Name: "add", Params: 2, Code: fn}}
+	code.Exports = []bytecode.Export{{Name: "add", Function: 0}}
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 3),
+		bytecode.New(bytecode.SLTSTORE, 0),
+	)
+
+	interpreter := New()
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	val, err := interpreter.Call(code, "add", Int32(1), Int32(2))
+	assert.NoError(t, err)
+	assert.Equal(t, Int32(6), val)
+	assert.Empty(t, interpreter.Stack())
+
+	_, err = interpreter.Call(code, "sub")
+	assert.ErrorIs(t, err, ErrUnknownExport)
+}
+
+func TestInterpreter_Apply(t *testing.T) {
+	var fn bytecode.Bytecode
+	fn.Emit(
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.GLBLOAD, 0),
+		bytecode.New(bytecode.I32ADD),
+		bytecode.New(bytecode.DUP),
+		bytecode.New(bytecode.GLBSTORE, 0),
+		bytecode.New(bytecode.RET),
+	)
+	add := Function{Function: &bytecode.Function{Name: "add", Params: 1, Code: fn}}
+
+	interpreter := New()
+	interpreter.SetGlobal(0, Int32(1))
+
+	val, err := interpreter.Apply(add, Int32(2))
+	assert.NoError(t, err)
+	assert.Equal(t, Int32(3), val)
+	assert.Empty(t, interpreter.Stack())
+
+	val, ok := interpreter.Global(0)
+	assert.True(t, ok)
+	assert.Equal(t, Int32(3), val)
+
+	val, err = interpreter.Apply(Host(func(args []Value) (Value, error) { return nil, nil }))
+	assert.NoError(t, err)
+	assert.Equal(t, Undefined{}, val)
+
+	_, err = interpreter.Apply(Int32(1))
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+}
+
+func TestInterpreter_Execute_Closure(t *testing.T) {
+	var fn bytecode.Bytecode
+	fn.Emit(
+		bytecode.New(bytecode.UPVLOAD, 0),
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32ADD),
+		bytecode.New(bytecode.DUP),
+		bytecode.New(bytecode.UPVSTORE, 0),
+		bytecode.New(bytecode.RET),
+	)
+
+	var code bytecode.Bytecode
+	code.Functions = []bytecode.Function{{Upvalues: 1, Code: fn}}
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.CELLSTORE, 0),
+		bytecode.New(bytecode.CELLREF, 0),
+		bytecode.New(bytecode.CLOSURE, 0, 1),
+		bytecode.New(bytecode.CALL, 0),
+		bytecode.New(bytecode.CELLLOAD, 0),
+	)
+
+	interpreter := New()
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Equal(t, []Value{Int32(2), Int32(2)}, interpreter.Stack())
+}
+
+func TestInterpreter_Execute_Error(t *testing.T) {
+	tests := []struct {
+		instructions []bytecode.Instruction
+		err          error
+	}{
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.POP),
+			},
+			err: ErrStackUnderflow,
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.I32ADD),
+			},
+			err: ErrStackUnderflow,
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.F64LOAD, math.Float64bits(1)),
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.I32ADD),
+			},
+			err: ErrTypeMismatch,
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.CALL, 0),
+			},
+			err: ErrTypeMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		var code bytecode.Bytecode
+		code.Emit(tt.instructions...)
+
+		t.Run(code.String(), func(t *testing.T) {
+			interpreter := New()
+
+			err := interpreter.Execute(code)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestInterpreter_Execute_Error_Line(t *testing.T) {
+	var code bytecode.Bytecode
+	code.Mark(1)
+	code.Emit(bytecode.New(bytecode.F64LOAD, math.Float64bits(1)))
+	code.Mark(2)
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32ADD),
+	)
+
+	interpreter := New()
+
+	err := interpreter.Execute(code)
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestInterpreter_Execute_Error_Stack(t *testing.T) {
+	var fn bytecode.Bytecode
+	fn.Mark(5)
+	fn.Emit(
+		bytecode.New(bytecode.UNDEFLOAD),
+		bytecode.New(bytecode.CALL, 0),
+		bytecode.New(bytecode.RET),
+	)
+
+	var code bytecode.Bytecode
+	code.Functions = []bytecode.Function{{Name: "fail", Code: fn}}
+	code.Mark(1)
+	code.Emit(bytecode.New(bytecode.NOP))
+	code.Mark(2)
+	code.Emit(
+		bytecode.New(bytecode.FUNCLOAD, 0),
+		bytecode.New(bytecode.CALL, 0),
+	)
+
+	interpreter := New()
+
+	err := interpreter.Execute(code)
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.ErrorContains(t, err, "line 5")
+
+	var e *Error
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, []StackFrame{{Function: "fail", Line: 5}, {Line: 2}}, e.Stack)
+}
+
+func TestInterpreter_Execute_Recursion(t *testing.T) {
+	var fn bytecode.Bytecode
+	fn.Emit(
+		bytecode.New(bytecode.GLBLOAD, 0),
+		bytecode.New(bytecode.CALL, 0),
+		bytecode.New(bytecode.RET),
+	)
+
+	var code bytecode.Bytecode
+	code.Functions = []bytecode.Function{{Name: "f", Code: fn}}
+	code.Emit(
+		bytecode.New(bytecode.FUNCLOAD, 0),
+		bytecode.New(bytecode.GLBSTORE, 0),
+		bytecode.New(bytecode.GLBLOAD, 0),
+		bytecode.New(bytecode.CALL, 0),
+	)
+
+	interpreter := New()
+
+	err := interpreter.Execute(code)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	var e *Error
+	assert.ErrorAs(t, err, &e)
+	assert.Len(t, e.Stack, maxCallDepth+1)
+
+	interpreter.Reset()
+	assert.Empty(t, interpreter.Stack())
+}
+
+func TestInterpreter_Execute_Trace(t *testing.T) {
+	var trace strings.Builder
+	interpreter := New(WithTrace(&trace))
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.I32ADD),
+		bytecode.New(bytecode.POP),
+	)
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(trace.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.Contains(t, lines[0], "i32.load 0x00000001")
+	assert.Contains(t, lines[0], "sp=1 fp=1 top=1")
+	assert.Contains(t, lines[2], "i32.add")
+	assert.Contains(t, lines[2], "sp=1 fp=1 top=3")
+	assert.Contains(t, lines[3], "sp=0 fp=1 top=-")
+}
+
+func TestInterpreter_Execute_Handler(t *testing.T) {
+	op := bytecode.CustomOpcodes
+	err := bytecode.Register(op, bytecode.Type{Mnemonic: "i32.scale", Widths: []int{bytecode.Varint}, Pop: 1, Push: 1})
+	assert.NoError(t, err)
+	defer bytecode.Unregister(op)
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 3),
+		bytecode.New(op, 200),
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32ADD),
+	)
+
+	interpreter := New(WithHandler(op, func(i *Interpreter, operands []uint64) error {
+		val, err := i.Pop()
+		if err != nil {
+			return err
+		}
+		i.Push(val.(Int32) * Int32(operands[0]))
+		return nil
+	}))
+
+	err = interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Equal(t, []Value{Int32(601)}, interpreter.Stack())
+
+	err = New().Execute(code)
+	assert.Error(t, err)
+}
+
+func TestInterpreter_Execute_Host(t *testing.T) {
+	code := bytecode.Bytecode{Constants: []bytecode.Constant{bytecode.String("sub")}}
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 5),
+		bytecode.New(bytecode.I32LOAD, 3),
+		bytecode.New(bytecode.CALLHOST, 0, 2),
+	)
+
+	interpreter := New(WithHost("sub", func(args []Value) (Value, error) {
+		return args[0].(Int32) - args[1].(Int32), nil
+	}))
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Equal(t, []Value{Int32(2)}, interpreter.Stack())
+
+	err = New().Execute(code)
+	assert.ErrorIs(t, err, ErrUnknownHost)
+
+	errHost := errors.New("host")
+	err = New(WithHost("sub", func([]Value) (Value, error) {
+		return nil, errHost
+	})).Execute(code)
+	assert.ErrorIs(t, err, errHost)
+}
+
+func TestInterpreter_Execute_Limits(t *testing.T) {
+	constants := []bytecode.Constant{bytecode.String("abc"), bytecode.String("long")}
+	host := WithHost("long", func([]Value) (Value, error) {
+		return String("abcdef"), nil
+	})
+
+	tests := []struct {
+		instructions []bytecode.Instruction
+		err          error
+	}{
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.STRLOAD, 0),
+				bytecode.New(bytecode.STRLOAD, 0),
+				bytecode.New(bytecode.STRADD),
+			},
+			err: ErrLimitExceeded,
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.STRLOAD, 0),
+				bytecode.New(bytecode.I32LOAD, 12),
+				bytecode.New(bytecode.ADD),
+			},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.STRLOAD, 0),
+				bytecode.New(bytecode.I32LOAD, 123),
+				bytecode.New(bytecode.ADD),
+			},
+			err: ErrLimitExceeded,
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.CALLHOST, 1, 0),
+			},
+			err: ErrLimitExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		code := bytecode.Bytecode{Constants: constants}
+		code.Emit(tt.instructions...)
+
+		t.Run(code.String(), func(t *testing.T) {
+			err := New(host, WithLimits(Limits{MaxStringLength: 5})).Execute(code)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			err = New(host).Execute(code)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestInterpreter_Execute_Limits_Calls(t *testing.T) {
+	var fn bytecode.Bytecode
+	fn.Emit(
+		bytecode.New(bytecode.GLBLOAD, 0),
+		bytecode.New(bytecode.CALL, 0),
+		bytecode.New(bytecode.RET),
+	)
+
+	var code bytecode.Bytecode
+	code.Functions = []bytecode.Function{{Name: "f", Code: fn}}
+	code.Emit(
+		bytecode.New(bytecode.FUNCLOAD, 0),
+		bytecode.New(bytecode.GLBSTORE, 0),
+		bytecode.New(bytecode.GLBLOAD, 0),
+		bytecode.New(bytecode.CALL, 0),
+	)
+
+	err := New(WithLimits(Limits{MaxCallDepth: 5})).Execute(code)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	var e *Error
+	assert.ErrorAs(t, err, &e)
+	assert.Len(t, e.Stack, 6)
+}
+
+func TestInterpreter_Execute_Limits_Instructions(t *testing.T) {
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.I32ADD),
+	)
+
+	interpreter := New(WithLimits(Limits{MaxInstructions: 3}))
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	err = interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Equal(t, []Value{Int32(3), Int32(3)}, interpreter.Stack())
+
+	err = New(WithLimits(Limits{MaxInstructions: 2})).Execute(code)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+}
+
+type record map[string]Value
+
+func (r record) Type() Type {
+	return OBJECT
+}
+
+func (r record) Interface() any {
+	return map[string]Value(r)
+}
+
+func (r record) Get(key string) (Value, error) {
+	return r[key], nil
+}
+
+func TestInterpreter_Execute_Object(t *testing.T) {
+	obj := record{
+		"name": String("alice"),
+		"1":    Int32(2),
+		"double": Host(func(args []Value) (Value, error) {
+			return args[0].(Int32) * 2, nil
+		}),
+	}
+	constants := []bytecode.Constant{bytecode.String("obj"), bytecode.String("name"), bytecode.String("double")}
+
+	tests := []struct {
+		instructions []bytecode.Instruction
+		expect       Value
+	}{
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.CALLHOST, 0, 0),
+				bytecode.New(bytecode.STRLOAD, 1),
+				bytecode.New(bytecode.PROPLOAD),
+			},
+			expect: String("alice"),
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.CALLHOST, 0, 0),
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.PROPLOAD),
+			},
+			expect: Int32(2),
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.CALLHOST, 0, 0),
+				bytecode.New(bytecode.STRLOAD, 0),
+				bytecode.New(bytecode.PROPLOAD),
+			},
+			expect: Undefined{},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.CALLHOST, 0, 0),
+				bytecode.New(bytecode.STRLOAD, 2),
+				bytecode.New(bytecode.PROPLOAD),
+				bytecode.New(bytecode.I32LOAD, 21),
+				bytecode.New(bytecode.CALL, 1),
+			},
+			expect: Int32(42),
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.STRLOAD, 1),
+				bytecode.New(bytecode.PROPLOAD),
+			},
+			expect: Undefined{},
+		},
+	}
+
+	for _, tt := range tests {
+		code := bytecode.Bytecode{Constants: constants}
+		code.Emit(tt.instructions...)
+
+		t.Run(code.String(), func(t *testing.T) {
+			interpreter := New(WithHost("obj", func([]Value) (Value, error) {
+				return obj, nil
+			}))
+			err := interpreter.Execute(code)
+			assert.NoError(t, err)
+			assert.Equal(t, []Value{tt.expect}, interpreter.Stack())
+		})
+	}
+
+	code := bytecode.Bytecode{Constants: constants}
+	code.Emit(
+		bytecode.New(bytecode.UNDEFLOAD),
+		bytecode.New(bytecode.STRLOAD, 1),
+		bytecode.New(bytecode.PROPLOAD),
+	)
+	err := New().Execute(code)
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+}
+
+func TestInterpreter_Execute_Hook(t *testing.T) {
+	var pre, post []bytecode.Opcode
+	interpreter := New(
+		WithPreHook(func(ip int, op bytecode.Opcode) {
+			assert.Len(t, post, len(pre))
+			pre = append(pre, op)
+		}),
+		WithPostHook(func(ip int, op bytecode.Opcode) {
+			post = append(post, op)
+		}),
+	)
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.POP),
+	)
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Equal(t, []bytecode.Opcode{bytecode.I32LOAD, bytecode.POP}, pre)
+	assert.Equal(t, pre, post)
+}
+
+func TestInterpreter_Interrupt(t *testing.T) {
+	cause := errors.New("interrupted")
+
+	var count int
+	var interpreter *Interpreter
+	interpreter = New(WithPreHook(func(_ int, _ bytecode.Opcode) {
+		count++
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			interpreter.Interrupt(cause)
+		}()
+		<-done
+	}))
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.I32ADD),
+	)
+
+	err := interpreter.Execute(code)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []Value{Int32(1)}, interpreter.Stack())
+}
+
+func TestInterpreter_Interrupt_Idle(t *testing.T) {
+	interpreter := New()
+	interpreter.Interrupt(errors.New("interrupted"))
+
+	var code bytecode.Bytecode
+	code.Emit(bytecode.New(bytecode.I32LOAD, 1))
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	interpreter.Interrupt(errors.New("interrupted"))
+	interpreter.Reset()
+
+	_, err = interpreter.ExecuteN(code, 0)
+	assert.NoError(t, err)
+	status, err := interpreter.Resume(-1)
+	assert.NoError(t, err)
+	assert.Equal(t, Done, status)
+}
+
+func TestInterpreter_Interrupt_Nil(t *testing.T) {
+	interpreter := New()
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32LOAD, 2),
+	)
+
+	status, err := interpreter.ExecuteN(code, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, Paused, status)
+
+	interpreter.Interrupt(nil)
+
+	_, err = interpreter.Resume(-1)
+	assert.ErrorIs(t, err, ErrInterrupted)
+}
+
+func TestInterpreter_ExecuteN(t *testing.T) {
+	interpreter := New()
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.I32ADD),
+	)
+
+	status, err := interpreter.ExecuteN(code, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, Paused, status)
+	assert.Equal(t, []Value{Int32(1), Int32(2)}, interpreter.Stack())
+
+	status, err = interpreter.Resume(1)
+	assert.NoError(t, err)
+	assert.Equal(t, Done, status)
+	assert.Equal(t, []Value{Int32(3)}, interpreter.Stack())
+
+	_, err = interpreter.Resume(1)
+	assert.ErrorIs(t, err, ErrNotPaused)
+}
+
+func TestInterpreter_SetGlobal(t *testing.T) {
+	interpreter := New()
+	err := interpreter.SetGlobal(1, Int32(2))
+	assert.NoError(t, err)
+
+	err = interpreter.SetGlobal(MaxSlots, Int32(2))
+	assert.ErrorIs(t, err, ErrInvalidBytecode)
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.GLBLOAD, 1),
+		bytecode.New(bytecode.GLBSTORE, 0),
+	)
+
+	err = interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	val, ok := interpreter.Global(0)
+	assert.True(t, ok)
+	assert.Equal(t, Int32(2), val)
+
+	_, ok = interpreter.Global(2)
+	assert.False(t, ok)
+
+	interpreter.Reset()
+	_, ok = interpreter.Global(0)
+	assert.False(t, ok)
+}
+
+func TestInterpreter_Top(t *testing.T) {
+	interpreter := New()
+
+	_, err := interpreter.Top()
+	assert.ErrorIs(t, err, ErrStackUnderflow)
+
+	var code bytecode.Bytecode
+	code.Emit(bytecode.New(bytecode.I32LOAD, 1))
+
+	err = interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	val, err := interpreter.Top()
+	assert.NoError(t, err)
+	assert.Equal(t, Int32(1), val)
+
+	val, err = interpreter.Pop()
+	assert.NoError(t, err)
+	assert.Equal(t, Int32(1), val)
+}
+
+func TestInterpreter_Reset(t *testing.T) {
+	interpreter := New()
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.SLTSTORE, 0),
+		bytecode.New(bytecode.I32LOAD, 2),
+	)
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	interpreter.Reset()
+	_, err = interpreter.Pop()
+	assert.ErrorIs(t, err, ErrStackUnderflow)
+
+	code = bytecode.Bytecode{}
+	code.Emit(bytecode.New(bytecode.SLTLOAD, 0))
+
+	err = interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	val, err := interpreter.Pop()
+	assert.NoError(t, err)
+	assert.Equal(t, Undefined{}, val)
+}
+
+func TestInterpreter_Reset_Allocs(t *testing.T) {
+	interpreter := New()
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.BOOLLOAD, 1),
+		bytecode.New(bytecode.SLTSTORE, 8),
+	)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = interpreter.Execute(code)
+		interpreter.Reset()
+	})
+	assert.Zero(t, allocs)
+}
+
+func FuzzExecute(f *testing.F) {
+	for _, instructions := range [][]bytecode.Instruction{
+		{bytecode.New(bytecode.I32LOAD, 1), bytecode.New(bytecode.I32LOAD, 2), bytecode.New(bytecode.I32ADD)},
+		{bytecode.New(bytecode.I32LOAD, 1), bytecode.New(bytecode.I32LOAD, 0), bytecode.New(bytecode.I32DIV)},
+		{bytecode.New(bytecode.I32LOAD, 1), bytecode.New(bytecode.I32LOAD, 0), bytecode.New(bytecode.I32MOD)},
+		{bytecode.New(bytecode.UNDEFLOAD), bytecode.New(bytecode.SLTSTORE, 0x3FFFFFFFFFFFFFFF)},
+		{bytecode.New(bytecode.CELLLOAD, 0x7FFFFFFFFFFFFFFF)},
+	} {
+		var code bytecode.Bytecode
+		code.Emit(instructions...)
+		f.Add([]byte(code.Instructions))
+	}
+
+	f.Fuzz(func(t *testing.T, instructions []byte) {
+		code := bytecode.Bytecode{Instructions: instructions}
+		if Verify(code) != nil {
+			return
+		}
+
+		_, _ = New().ExecuteN(code, 10_000)
+		_, _ = NewOptimizer().Optimize(code)
+	})
+}
+
 func BenchmarkInterpreter_Execute(b *testing.B) {
 	tests := []struct {
 		instructions []bytecode.Instruction
@@ -299,6 +1141,7 @@ func BenchmarkInterpreter_Execute(b *testing.B) {
 		},
 		{
 			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
 				bytecode.New(bytecode.POP),
 			},
 		},
@@ -511,15 +1354,15 @@ func BenchmarkInterpreter_Execute(b *testing.B) {
 		},
 		{
 			instructions: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 3),
+				bytecode.New(bytecode.STRLOAD, 0),
 				bytecode.New(bytecode.POP),
 			},
 			literals: []string{"abc"},
 		},
 		{
 			instructions: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 3),
-				bytecode.New(bytecode.STRLOAD, 0, 3),
+				bytecode.New(bytecode.STRLOAD, 0),
+				bytecode.New(bytecode.STRLOAD, 0),
 				bytecode.New(bytecode.STRADD),
 				bytecode.New(bytecode.POP),
 			},
@@ -527,7 +1370,7 @@ func BenchmarkInterpreter_Execute(b *testing.B) {
 		},
 		{
 			instructions: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 3),
+				bytecode.New(bytecode.STRLOAD, 0),
 				bytecode.New(bytecode.STRTOI32),
 				bytecode.New(bytecode.POP),
 			},
@@ -535,7 +1378,7 @@ func BenchmarkInterpreter_Execute(b *testing.B) {
 		},
 		{
 			instructions: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 1),
+				bytecode.New(bytecode.STRLOAD, 0),
 				bytecode.New(bytecode.STRTOF64),
 				bytecode.New(bytecode.POP),
 			},
@@ -547,7 +1390,7 @@ func BenchmarkInterpreter_Execute(b *testing.B) {
 		var code bytecode.Bytecode
 		code.Emit(tt.instructions...)
 		for _, c := range tt.literals {
-			code.Store([]byte(c + "\x00"))
+			code.Store(bytecode.String(c))
 		}
 
 		b.Run(code.String(), func(b *testing.B) {
@@ -0,0 +1,62 @@
+package interpreter
+
+import (
+	"unsafe"
+)
+
+// MemStats is the memory a script holds, in bytes. Heap counts the cells of
+// captured variables and the upvalues of closures, and Strings counts the
+// strings held on the stack, in slots or in cells. Objects and arrays are not
+// counted: the embedder provides them, and their memory is not visible to the
+// interpreter. PeakStack is the largest the stack grew since the current or
+// last run started.
+type MemStats struct {
+	Stack     int
+	Slots     int
+	Constants int
+	Strings   int
+	Heap      int
+	Cells     int
+	PeakStack int
+}
+
+var (
+	valueSize = int(unsafe.Sizeof(Value(nil)))
+	cellSize  = int(unsafe.Sizeof(Cell{}))
+	cellPtr   = int(unsafe.Sizeof((*Cell)(nil)))
+)
+
+func (i *Interpreter) MemStats() MemStats {
+	stats := MemStats{
+		Stack:     i.sp * valueSize,
+		Constants: i.constants,
+		PeakStack: i.peak * valueSize,
+	}
+
+	for _, val := range i.stack[:i.sp] {
+		if s, ok := val.(String); ok {
+			stats.Strings += len(s)
+		}
+	}
+	for _, frame := range i.frames[:i.fp] {
+		stats.Slots += len(frame.slots) * valueSize
+		for _, val := range frame.slots {
+			if s, ok := val.(String); ok {
+				stats.Strings += len(s)
+			}
+		}
+	}
+	i.heap(func(val Value) {
+		switch v := val.(type) {
+		case *Cell:
+			stats.Cells++
+			stats.Heap += cellSize
+			if s, ok := v.Value.(String); ok {
+				stats.Strings += len(s)
+			}
+		case Function:
+			stats.Heap += len(v.Upvalues) * cellPtr
+		}
+	})
+	return stats
+}
@@ -0,0 +1,81 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpreter_MemStats(t *testing.T) {
+	interpreter := New()
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.STRLOAD, 0),
+		bytecode.New(bytecode.SLTSTORE, 0),
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.I32ADD),
+	)
+	code.Store(bytecode.String("foo"))
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	stats := interpreter.MemStats()
+	assert.Equal(t, valueSize, stats.Stack)
+	assert.Equal(t, 2*valueSize, stats.PeakStack)
+	assert.Equal(t, 3, stats.Constants)
+	assert.Equal(t, 3, stats.Strings)
+	assert.Equal(t, 2*valueSize, stats.Slots)
+}
+
+func TestInterpreter_MemStats_Heap(t *testing.T) {
+	interpreter := New()
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.STRLOAD, 0),
+		bytecode.New(bytecode.CELLSTORE, 0),
+		bytecode.New(bytecode.CELLREF, 0),
+		bytecode.New(bytecode.POP),
+	)
+	code.Store(bytecode.String("foo"))
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	stats := interpreter.MemStats()
+	assert.Equal(t, 1, stats.Cells)
+	assert.Equal(t, cellSize, stats.Heap)
+	assert.Equal(t, 3, stats.Strings)
+}
+
+func TestInterpreter_MemStats_PeakStack(t *testing.T) {
+	interpreter := New()
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.I32LOAD, 3),
+		bytecode.New(bytecode.POP),
+		bytecode.New(bytecode.POP),
+		bytecode.New(bytecode.POP),
+	)
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Equal(t, 3*valueSize, interpreter.MemStats().PeakStack)
+
+	interpreter.Reset()
+	assert.Equal(t, 0, interpreter.MemStats().PeakStack)
+
+	code = bytecode.Bytecode{}
+	code.Emit(bytecode.New(bytecode.I32LOAD, 1))
+
+	err = interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Equal(t, valueSize, interpreter.MemStats().PeakStack)
+}
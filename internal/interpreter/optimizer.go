@@ -1,8 +1,9 @@
 package interpreter
 
 import (
-	"encoding/binary"
 	"math"
+	"math/bits"
+	"slices"
 
 	"github.com/siyul-park/minijs/internal/bytecode"
 )
@@ -18,13 +19,13 @@ func NewOptimizer() *Optimizer {
 }
 
 func (o *Optimizer) Optimize(code bytecode.Bytecode) (bytecode.Bytecode, error) {
-	constants := code.Constants
+	constants := slices.Clone(code.Constants)
 
 	var instructions []bytecode.Instruction
-	for offset := 0; offset < len(code.Instructions); {
-		inst, size := code.Fetch(offset)
+	var lines []int
+	for offset, inst := range code.Iter() {
 		instructions = append(instructions, inst)
-		offset += size
+		lines = append(lines, code.Line(offset))
 	}
 
 	instructions, constants, err := o.fusion(instructions, constants)
@@ -32,162 +33,150 @@ func (o *Optimizer) Optimize(code bytecode.Bytecode) (bytecode.Bytecode, error)
 		return bytecode.Bytecode{}, err
 	}
 
-	instructions, constants = o.compress(instructions, constants)
+	instructions = o.reduce(instructions)
+
+	var kept []int
+	for i, inst := range instructions {
+		if inst.Opcode() != bytecode.NOP {
+			kept = append(kept, lines[i])
+		}
+	}
+
+	instructions, code.Constants = o.compress(instructions, constants)
+	instructions = o.pool(instructions, &code)
 
 	code.Instructions = nil
-	code.Constants = constants
-	code.Emit(instructions...)
+	code.Lines = nil
+	for i, inst := range instructions {
+		code.Mark(kept[i])
+		code.Emit(inst)
+	}
 	return code, nil
 }
 
-func (o *Optimizer) fusion(instructions []bytecode.Instruction, constants []byte) ([]bytecode.Instruction, []byte, error) {
-	literals := map[string]int{}
-	for i := 0; i < len(instructions); i++ {
-		inst := instructions[i]
-		if inst.Opcode() == bytecode.STRLOAD {
-			offset := int(binary.BigEndian.Uint32(inst[1:]))
-			size := int(binary.BigEndian.Uint32(inst[5:]))
-
-			literal := string(constants[offset : offset+size])
-			literals[literal] = offset
+func (o *Optimizer) fusion(instructions []bytecode.Instruction, constants []bytecode.Constant) ([]bytecode.Instruction, []bytecode.Constant, error) {
+	literals := map[bytecode.String]int{}
+	for i, val := range constants {
+		if val, ok := val.(bytecode.String); ok {
+			literals[val] = i
 		}
 	}
 
 	for i := 0; i < len(instructions); i++ {
 		inst := instructions[i]
-		if i > 0 {
-			j := i - 1
-			for ; j > 0; j-- {
-				if instructions[j].Opcode() != bytecode.NOP {
-					break
-				}
-			}
-
+		if j := previous(instructions, i); j >= 0 {
 			operand := instructions[j]
 			switch operand.Opcode() {
 			case bytecode.UNDEFLOAD, bytecode.NULLLOAD, bytecode.BOOLLOAD, bytecode.I32LOAD, bytecode.F64LOAD, bytecode.STRLOAD:
 				switch inst.Opcode() {
 				case bytecode.I32TOBOOL:
-					code := bytecode.Bytecode{Constants: constants}
-					code.Emit(operand, inst)
-					if err := o.interpreter.Execute(code); err != nil {
+					v, err := o.evaluate(constants, operand, inst)
+					if err != nil {
 						return nil, nil, err
 					}
 
-					val, _ := o.interpreter.Pop().(Bool)
+					val, _ := v.(Bool)
 
 					instructions[j] = bytecode.New(bytecode.NOP)
 					instructions[i] = bytecode.New(bytecode.BOOLLOAD, uint64(val))
 				case bytecode.NULLTOI32, bytecode.BOOLTOI32, bytecode.F64TOI32, bytecode.STRTOI32:
-					code := bytecode.Bytecode{Constants: constants}
-					code.Emit(operand, inst)
-					if err := o.interpreter.Execute(code); err != nil {
+					v, err := o.evaluate(constants, operand, inst)
+					if err != nil {
 						return nil, nil, err
 					}
 
-					val, _ := o.interpreter.Pop().(Int32)
+					val, _ := v.(Int32)
 
 					instructions[j] = bytecode.New(bytecode.NOP)
 					instructions[i] = bytecode.New(bytecode.I32LOAD, uint64(val))
 				case bytecode.UNDEFTOF64, bytecode.I32TOF64, bytecode.STRTOF64:
-					code := bytecode.Bytecode{Constants: constants}
-					code.Emit(operand, inst)
-					if err := o.interpreter.Execute(code); err != nil {
+					v, err := o.evaluate(constants, operand, inst)
+					if err != nil {
 						return nil, nil, err
 					}
 
-					val, _ := o.interpreter.Pop().(Float64)
+					val, _ := v.(Float64)
 
 					instructions[j] = bytecode.New(bytecode.NOP)
 					instructions[i] = bytecode.New(bytecode.F64LOAD, math.Float64bits(float64(val)))
 				case bytecode.UNDEFTOSTR, bytecode.NULLTOSTR, bytecode.BOOLTOSTR, bytecode.I32TOSTR, bytecode.F64TOSTR:
-					code := bytecode.Bytecode{Constants: constants}
-					code.Emit(operand, inst)
-					if err := o.interpreter.Execute(code); err != nil {
+					v, err := o.evaluate(constants, operand, inst)
+					if err != nil {
 						return nil, nil, err
 					}
 
-					val, _ := o.interpreter.Pop().(String)
+					val, _ := v.(String)
 
-					offset, ok := literals[string(val)]
+					idx, ok := literals[bytecode.String(val)]
 					if !ok {
-						offset = len(constants)
-						constants = append(constants, []byte(string(val)+"\x00")...)
-						literals[string(val)] = offset
+						idx = len(constants)
+						constants = append(constants, bytecode.String(val))
+						literals[bytecode.String(val)] = idx
 					}
 
 					instructions[j] = bytecode.New(bytecode.NOP)
-					instructions[i] = bytecode.New(bytecode.STRLOAD, uint64(offset), uint64(len(val)))
+					instructions[i] = bytecode.New(bytecode.STRLOAD, uint64(idx))
 				default:
 				}
 			default:
 			}
 		}
 
-		if i > 1 {
-			j := i - 1
-			for ; j > 0; j-- {
-				if instructions[j].Opcode() != bytecode.NOP {
-					break
-				}
-			}
-
-			k := j - 1
-			for ; k > 0; k-- {
-				if instructions[k].Opcode() != bytecode.NOP {
-					break
-				}
-			}
-
+		j := previous(instructions, i)
+		k := -1
+		if j >= 0 {
+			k = previous(instructions, j)
+		}
+		if k >= 0 {
 			operand1 := instructions[j]
 			operand2 := instructions[k]
 			if operand1.Opcode() == operand2.Opcode() {
 				switch operand1.Opcode() {
 				case bytecode.BOOLLOAD, bytecode.I32LOAD, bytecode.F64LOAD, bytecode.STRLOAD:
 					switch inst.Opcode() {
-					case bytecode.I32ADD, bytecode.I32SUB, bytecode.I32MUL, bytecode.I32DIV, bytecode.I32MOD:
-						code := bytecode.Bytecode{Constants: constants}
-						code.Emit(operand2, operand1, inst)
-						if err := o.interpreter.Execute(code); err != nil {
+					case bytecode.I32ADD, bytecode.I32SUB, bytecode.I32MUL, bytecode.I32DIV, bytecode.I32MOD, bytecode.I32SHL, bytecode.I32SHR:
+						v, err := o.evaluate(constants, operand2, operand1, inst)
+						if err != nil {
 							return nil, nil, err
 						}
 
-						val, _ := o.interpreter.Pop().(Int32)
-
 						instructions[k] = bytecode.New(bytecode.NOP)
 						instructions[j] = bytecode.New(bytecode.NOP)
-						instructions[i] = bytecode.New(bytecode.I32LOAD, uint64(val))
+						if val, ok := v.(Float64); ok {
+							instructions[i] = bytecode.New(bytecode.F64LOAD, math.Float64bits(float64(val)))
+						} else {
+							val, _ := v.(Int32)
+							instructions[i] = bytecode.New(bytecode.I32LOAD, uint64(val))
+						}
 					case bytecode.F64ADD, bytecode.F64SUB, bytecode.F64MUL, bytecode.F64DIV, bytecode.F64MOD:
-						code := bytecode.Bytecode{Constants: constants}
-						code.Emit(operand2, operand1, inst)
-						if err := o.interpreter.Execute(code); err != nil {
+						v, err := o.evaluate(constants, operand2, operand1, inst)
+						if err != nil {
 							return nil, nil, err
 						}
 
-						val, _ := o.interpreter.Pop().(Float64)
+						val, _ := v.(Float64)
 
 						instructions[k] = bytecode.New(bytecode.NOP)
 						instructions[j] = bytecode.New(bytecode.NOP)
 						instructions[i] = bytecode.New(bytecode.F64LOAD, math.Float64bits(float64(val)))
 					case bytecode.STRADD:
-						code := bytecode.Bytecode{Constants: constants}
-						code.Emit(operand2, operand1, inst)
-						if err := o.interpreter.Execute(code); err != nil {
+						v, err := o.evaluate(constants, operand2, operand1, inst)
+						if err != nil {
 							return nil, nil, err
 						}
 
-						val, _ := o.interpreter.Pop().(String)
+						val, _ := v.(String)
 
-						offset, ok := literals[string(val)]
+						idx, ok := literals[bytecode.String(val)]
 						if !ok {
-							offset = len(constants)
-							constants = append(constants, []byte(string(val)+"\x00")...)
-							literals[string(val)] = offset
+							idx = len(constants)
+							constants = append(constants, bytecode.String(val))
+							literals[bytecode.String(val)] = idx
 						}
 
 						instructions[k] = bytecode.New(bytecode.NOP)
 						instructions[j] = bytecode.New(bytecode.NOP)
-						instructions[i] = bytecode.New(bytecode.STRLOAD, uint64(offset), uint64(len(val)))
+						instructions[i] = bytecode.New(bytecode.STRLOAD, uint64(idx))
 					default:
 					}
 				default:
@@ -198,31 +187,115 @@ func (o *Optimizer) fusion(instructions []bytecode.Instruction, constants []byte
 	return instructions, constants, nil
 }
 
-func (o *Optimizer) compress(instructions []bytecode.Instruction, constants []byte) ([]bytecode.Instruction, []byte) {
-	literals := map[string]int{}
-	for i := 0; i < len(instructions); i++ {
+func (o *Optimizer) reduce(instructions []bytecode.Instruction) []bytecode.Instruction {
+	for i := 1; i < len(instructions); i++ {
 		inst := instructions[i]
-		if inst.Opcode() == bytecode.STRLOAD {
-			offset := int(binary.BigEndian.Uint32(inst[1:]))
-			size := int(binary.BigEndian.Uint32(inst[5:]))
 
-			literal := string(constants[offset : offset+size])
-			literals[literal] = offset
+		j := previous(instructions, i)
+		if j < 0 {
+			continue
+		}
+		operand := instructions[j]
+		if operand.Opcode() != bytecode.I32LOAD {
+			continue
+		}
+		val := int32(operand.Operands()[0])
+
+		switch inst.Opcode() {
+		case bytecode.I32ADD, bytecode.I32SUB, bytecode.I32SHL, bytecode.I32SHR:
+			if val == 0 {
+				instructions[j] = bytecode.New(bytecode.NOP)
+				instructions[i] = bytecode.New(bytecode.NOP)
+			}
+		case bytecode.I32MUL:
+			if val == 1 {
+				instructions[j] = bytecode.New(bytecode.NOP)
+				instructions[i] = bytecode.New(bytecode.NOP)
+			} else if val > 1 && val&(val-1) == 0 {
+				instructions[j] = bytecode.New(bytecode.I32LOAD, uint64(bits.TrailingZeros32(uint32(val))))
+				instructions[i] = bytecode.New(bytecode.I32SHL)
+			}
+		case bytecode.I32DIV:
+			if val == 1 {
+				instructions[j] = bytecode.New(bytecode.NOP)
+				instructions[i] = bytecode.New(bytecode.NOP)
+			}
+		default:
 		}
 	}
+	return instructions
+}
 
-	compressed := make([]byte, 0, len(constants))
-	for literal := range literals {
-		literals[literal] = len(compressed)
-		compressed = append(compressed, []byte(literal+"\x00")...)
+// previous returns the index of the last instruction before i that is not a
+// NOP, or -1 if there is none.
+func previous(instructions []bytecode.Instruction, i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if instructions[j].Opcode() != bytecode.NOP {
+			return j
+		}
 	}
+	return -1
+}
 
+func (o *Optimizer) pool(instructions []bytecode.Instruction, code *bytecode.Bytecode) []bytecode.Instruction {
+	counts := map[uint64]int{}
+	for _, inst := range instructions {
+		if inst.Opcode() == bytecode.F64LOAD {
+			counts[inst.Operands()[0]]++
+		}
+	}
+
+	indices := map[uint64]int{}
+	for i, val := range code.Constants {
+		if val, ok := val.(bytecode.Float64); ok {
+			indices[math.Float64bits(float64(val))] = i
+		}
+	}
+
+	for i, inst := range instructions {
+		if inst.Opcode() != bytecode.F64LOAD {
+			continue
+		}
+		bits := inst.Operands()[0]
+		if counts[bits] < 2 {
+			continue
+		}
+
+		idx, ok := indices[bits]
+		if !ok {
+			idx = code.Store(bytecode.Float64(math.Float64frombits(bits)))
+			indices[bits] = idx
+		}
+		instructions[i] = bytecode.New(bytecode.F64CONST, uint64(idx))
+	}
+	return instructions
+}
+
+func (o *Optimizer) evaluate(constants []bytecode.Constant, instructions ...bytecode.Instruction) (Value, error) {
+	defer o.interpreter.Reset()
+
+	code := bytecode.Bytecode{Constants: constants}
+	code.Emit(instructions...)
+	if err := o.interpreter.Execute(code); err != nil {
+		return nil, err
+	}
+	return o.interpreter.Pop()
+}
+
+func (o *Optimizer) compress(instructions []bytecode.Instruction, constants []bytecode.Constant) ([]bytecode.Instruction, []bytecode.Constant) {
+	var compressed []bytecode.Constant
+	indices := map[uint64]int{}
 	for i := 0; i < len(instructions); i++ {
 		inst := instructions[i]
-		if inst.Opcode() == bytecode.STRLOAD {
-			offset := int(binary.BigEndian.Uint32(inst[1:]))
-			size := int(binary.BigEndian.Uint32(inst[5:]))
-			instructions[i] = bytecode.New(bytecode.STRLOAD, uint64(literals[string(constants[offset:offset+size])]), uint64(size))
+		if op := inst.Opcode(); op == bytecode.STRLOAD || op == bytecode.F64CONST || op == bytecode.CALLHOST {
+			operands := inst.Operands()
+			idx := operands[0]
+			if _, ok := indices[idx]; !ok {
+				indices[idx] = len(compressed)
+				compressed = append(compressed, constants[idx])
+			}
+			operands[0] = uint64(indices[idx])
+			instructions[i] = bytecode.New(op, operands...)
 		}
 	}
 
@@ -30,7 +30,7 @@ func TestOptimizer_Optimize(t *testing.T) {
 				bytecode.New(bytecode.UNDEFTOSTR),
 			},
 			expected: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 9),
+				bytecode.New(bytecode.STRLOAD, 0),
 			},
 		},
 		{
@@ -48,7 +48,7 @@ func TestOptimizer_Optimize(t *testing.T) {
 				bytecode.New(bytecode.NULLTOSTR),
 			},
 			expected: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 4),
+				bytecode.New(bytecode.STRLOAD, 0),
 			},
 		},
 		{
@@ -66,7 +66,7 @@ func TestOptimizer_Optimize(t *testing.T) {
 				bytecode.New(bytecode.BOOLTOSTR),
 			},
 			expected: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 4),
+				bytecode.New(bytecode.STRLOAD, 0),
 			},
 		},
 
@@ -94,7 +94,7 @@ func TestOptimizer_Optimize(t *testing.T) {
 				bytecode.New(bytecode.I32TOSTR),
 			},
 			expected: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 1),
+				bytecode.New(bytecode.STRLOAD, 0),
 			},
 		},
 
@@ -113,13 +113,13 @@ func TestOptimizer_Optimize(t *testing.T) {
 				bytecode.New(bytecode.F64TOSTR),
 			},
 			expected: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 1),
+				bytecode.New(bytecode.STRLOAD, 0),
 			},
 		},
 
 		{
 			commands: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 3),
+				bytecode.New(bytecode.STRLOAD, 0),
 				bytecode.New(bytecode.STRTOI32),
 			},
 			expected: []bytecode.Instruction{
@@ -129,7 +129,7 @@ func TestOptimizer_Optimize(t *testing.T) {
 		},
 		{
 			commands: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 3),
+				bytecode.New(bytecode.STRLOAD, 0),
 				bytecode.New(bytecode.STRTOF64),
 			},
 			expected: []bytecode.Instruction{
@@ -188,6 +188,16 @@ func TestOptimizer_Optimize(t *testing.T) {
 				bytecode.New(bytecode.I32LOAD, 0),
 			},
 		},
+		{
+			commands: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.I32LOAD, 0),
+				bytecode.New(bytecode.I32DIV),
+			},
+			expected: []bytecode.Instruction{
+				bytecode.New(bytecode.F64LOAD, math.Float64bits(math.Inf(1))),
+			},
+		},
 
 		{
 			commands: []bytecode.Instruction{
@@ -242,15 +252,48 @@ func TestOptimizer_Optimize(t *testing.T) {
 
 		{
 			commands: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 3),
-				bytecode.New(bytecode.STRLOAD, 0, 3),
+				bytecode.New(bytecode.STRLOAD, 0),
+				bytecode.New(bytecode.STRLOAD, 0),
 				bytecode.New(bytecode.STRADD),
 			},
 			expected: []bytecode.Instruction{
-				bytecode.New(bytecode.STRLOAD, 0, 6),
+				bytecode.New(bytecode.STRLOAD, 0),
 			},
 			literals: []string{"foo"},
 		},
+
+		{
+			commands: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.I32MUL),
+			},
+			expected: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+			},
+		},
+		{
+			commands: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.I32LOAD, 0),
+				bytecode.New(bytecode.I32ADD),
+			},
+			expected: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+			},
+		},
+		{
+			commands: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.I32LOAD, 8),
+				bytecode.New(bytecode.I32MUL),
+			},
+			expected: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.I32LOAD, 3),
+				bytecode.New(bytecode.I32SHL),
+			},
+		},
 	}
 
 	optimizer := NewOptimizer()
@@ -259,7 +302,7 @@ func TestOptimizer_Optimize(t *testing.T) {
 		commands := bytecode.Bytecode{}
 		commands.Emit(tt.commands...)
 		for _, c := range tt.literals {
-			commands.Store([]byte(c + "\x00"))
+			commands.Store(bytecode.String(c))
 		}
 
 		expected := bytecode.Bytecode{}
@@ -274,3 +317,32 @@ func TestOptimizer_Optimize(t *testing.T) {
 		})
 	}
 }
+
+func TestOptimizer_Optimize_Numbers(t *testing.T) {
+	optimizer := NewOptimizer()
+
+	code := bytecode.Bytecode{}
+	code.Emit(
+		bytecode.New(bytecode.F64LOAD, math.Float64bits(1.5)),
+		bytecode.New(bytecode.SLTSTORE, 0),
+		bytecode.New(bytecode.F64LOAD, math.Float64bits(2.5)),
+		bytecode.New(bytecode.SLTSTORE, 1),
+		bytecode.New(bytecode.F64LOAD, math.Float64bits(1.5)),
+		bytecode.New(bytecode.SLTSTORE, 2),
+	)
+
+	expected := bytecode.Bytecode{}
+	expected.Emit(
+		bytecode.New(bytecode.F64CONST, 0),
+		bytecode.New(bytecode.SLTSTORE, 0),
+		bytecode.New(bytecode.F64LOAD, math.Float64bits(2.5)),
+		bytecode.New(bytecode.SLTSTORE, 1),
+		bytecode.New(bytecode.F64CONST, 0),
+		bytecode.New(bytecode.SLTSTORE, 2),
+	)
+
+	actual, err := optimizer.Optimize(code)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.Instructions, actual.Instructions)
+	assert.Equal(t, []bytecode.Constant{bytecode.Float64(1.5)}, actual.Constants)
+}
@@ -0,0 +1,28 @@
+package interpreter
+
+import (
+	"sync"
+)
+
+type Pool struct {
+	pool sync.Pool
+}
+
+func NewPool(opts ...Option) *Pool {
+	return &Pool{
+		pool: sync.Pool{
+			New: func() any {
+				return New(opts...)
+			},
+		},
+	}
+}
+
+func (p *Pool) Get() *Interpreter {
+	return p.pool.Get().(*Interpreter)
+}
+
+func (p *Pool) Put(i *Interpreter) {
+	i.Reset()
+	p.pool.Put(i)
+}
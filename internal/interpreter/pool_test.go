@@ -0,0 +1,41 @@
+package interpreter
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool_Get(t *testing.T) {
+	pool := NewPool()
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.POP),
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.SLTSTORE, 0),
+		bytecode.New(bytecode.SLTLOAD, 0),
+	)
+
+	var wg sync.WaitGroup
+	for j := 0; j < 16; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			interpreter := pool.Get()
+			defer pool.Put(interpreter)
+
+			err := interpreter.Execute(code)
+			assert.NoError(t, err)
+
+			val, err := interpreter.Pop()
+			assert.NoError(t, err)
+			assert.Equal(t, Int32(1), val)
+		}()
+	}
+	wg.Wait()
+}
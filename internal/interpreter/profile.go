@@ -0,0 +1,38 @@
+package interpreter
+
+import (
+	"time"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+)
+
+type Profile struct {
+	Opcode   bytecode.Opcode
+	Count    int
+	Duration time.Duration
+}
+
+type profiler struct {
+	counts    [256]int
+	durations [256]time.Duration
+}
+
+func (p *profiler) record(op bytecode.Opcode, d time.Duration) {
+	p.counts[op]++
+	p.durations[op] += d
+}
+
+func (p *profiler) profiles() []Profile {
+	var profiles []Profile
+	for op, count := range p.counts {
+		if count == 0 {
+			continue
+		}
+		profiles = append(profiles, Profile{
+			Opcode:   bytecode.Opcode(op),
+			Count:    count,
+			Duration: p.durations[op],
+		})
+	}
+	return profiles
+}
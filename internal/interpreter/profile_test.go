@@ -0,0 +1,36 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpreter_Profile(t *testing.T) {
+	interpreter := New(WithProfile())
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.I32ADD),
+		bytecode.New(bytecode.POP),
+	)
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	profiles := interpreter.Profile()
+	assert.Len(t, profiles, 3)
+
+	counts := map[bytecode.Opcode]int{}
+	for _, p := range profiles {
+		counts[p.Opcode] = p.Count
+	}
+	assert.Equal(t, map[bytecode.Opcode]int{
+		bytecode.POP:     1,
+		bytecode.I32LOAD: 2,
+		bytecode.I32ADD:  1,
+	}, counts)
+}
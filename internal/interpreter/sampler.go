@@ -0,0 +1,41 @@
+package interpreter
+
+import (
+	"context"
+	"runtime/pprof"
+	"strconv"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+)
+
+type sampler struct {
+	ctx      context.Context
+	labels   pprof.LabelSet
+	interval int
+	count    int
+}
+
+const sampleInterval = 1024
+
+// sample labels the goroutine with the instruction at offset in function, an
+// empty name meaning the top-level code, once every interval instructions.
+// Offsets inside a function are qualified by its name, since each function
+// counts them from its own start. The source line is looked up in code only
+// when a sample is taken.
+func (s *sampler) sample(function string, code *bytecode.Bytecode, offset int) {
+	s.count++
+	if s.count < s.interval {
+		return
+	}
+	s.count = 0
+
+	at := strconv.Itoa(offset)
+	if function != "" {
+		at = function + ":" + at
+	}
+	labels := pprof.Labels("function", function, "offset", at)
+	if line := code.Line(offset); line > 0 {
+		labels = pprof.Labels("function", function, "offset", at, "line", strconv.Itoa(line))
+	}
+	pprof.SetGoroutineLabels(pprof.WithLabels(s.ctx, labels))
+}
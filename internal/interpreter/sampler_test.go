@@ -0,0 +1,79 @@
+package interpreter
+
+import (
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpreter_Execute_PprofLabels(t *testing.T) {
+	interpreter := New(WithPprofLabels("test"))
+	interpreter.sampler.interval = 1
+
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.POP),
+	)
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, interpreter.sampler.count)
+
+	script, ok := pprof.Label(interpreter.sampler.ctx, "script")
+	assert.True(t, ok)
+	assert.Equal(t, "test", script)
+}
+
+func TestInterpreter_Execute_PprofLabels_Line(t *testing.T) {
+	var profile strings.Builder
+	interpreter := New(WithPprofLabels("test"), WithHost("probe", func([]Value) (Value, error) {
+		return nil, pprof.Lookup("goroutine").WriteTo(&profile, 1)
+	}))
+	interpreter.sampler.interval = 1
+
+	code := bytecode.Bytecode{Constants: []bytecode.Constant{bytecode.String("probe")}}
+	code.Mark(3)
+	code.Emit(bytecode.New(bytecode.I32LOAD, 1))
+	offset := code.Emit(bytecode.New(bytecode.POP))
+	code.Mark(4)
+	code.Emit(bytecode.New(bytecode.CALLHOST, 0, 0))
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Contains(t, profile.String(), `"line":"3"`)
+	assert.Contains(t, profile.String(), `"offset":"`+strconv.Itoa(offset)+`"`)
+	assert.Contains(t, profile.String(), `"script":"test"`)
+}
+
+func TestInterpreter_Execute_PprofLabels_Function(t *testing.T) {
+	var profile strings.Builder
+	interpreter := New(WithPprofLabels("test"), WithHost("probe", func([]Value) (Value, error) {
+		return nil, pprof.Lookup("goroutine").WriteTo(&profile, 1)
+	}))
+	interpreter.sampler.interval = 1
+
+	fn := bytecode.Bytecode{Constants: []bytecode.Constant{bytecode.String("probe")}}
+	fn.Emit(bytecode.New(bytecode.I32LOAD, 1))
+	offset := fn.Emit(bytecode.New(bytecode.POP))
+	fn.Emit(
+		bytecode.New(bytecode.CALLHOST, 0, 0),
+		bytecode.New(bytecode.RET),
+	)
+
+	var code bytecode.Bytecode
+	code.Functions = []bytecode.Function{{Name: "f", Code: fn}}
+	code.Emit(
+		bytecode.New(bytecode.FUNCLOAD, 0),
+		bytecode.New(bytecode.CALL, 0),
+	)
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+	assert.Contains(t, profile.String(), `"function":"f"`)
+	assert.Contains(t, profile.String(), `"offset":"f:`+strconv.Itoa(offset)+`"`)
+}
@@ -0,0 +1,523 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"unsafe"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+)
+
+type encoder struct {
+	bytes.Buffer
+	refs  map[any]int
+	heap  []any
+	hosts map[unsafe.Pointer]string
+}
+
+type decoder struct {
+	*bytes.Reader
+	heap   []any
+	refs   int
+	hosts  map[string]Host
+	checks []func() error
+}
+
+const (
+	heapCell byte = iota
+	heapFunction
+	heapCode
+)
+
+const (
+	functionScript byte = iota
+	functionHost
+)
+
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// Snapshot encodes the operand stack and the frames of i, including the global
+// variables held by the top-level frame and the code each frame runs, so that
+// Restore can resume a paused execution. Cells and functions that several
+// values share are written once, so they are still shared after Restore. Host
+// functions are written by the name they were registered with WithHost.
+func (i *Interpreter) Snapshot() ([]byte, error) {
+	e := &encoder{refs: map[any]int{}, hosts: map[unsafe.Pointer]string{}}
+	for name, fn := range i.hosts {
+		e.hosts[identity(fn)] = name
+	}
+
+	e.uvarint(uint64(i.sp))
+	for _, val := range i.stack[:i.sp] {
+		if err := e.value(val); err != nil {
+			return nil, err
+		}
+	}
+
+	e.uvarint(uint64(i.fp))
+	for _, frame := range i.frames[:i.fp] {
+		e.string(frame.name)
+		if frame.code == nil {
+			e.WriteByte(0)
+		} else {
+			e.WriteByte(1)
+			e.ref(frame.code)
+		}
+		e.uvarint(uint64(len(frame.upvalues)))
+		for _, cell := range frame.upvalues {
+			e.ref(cell)
+		}
+		e.varint(int64(frame.ip))
+		e.uvarint(uint64(len(frame.slots)))
+		for _, val := range frame.slots {
+			if err := e.value(val); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !i.running {
+		e.WriteByte(0)
+	} else {
+		e.WriteByte(1)
+		if err := e.code(&i.code); err != nil {
+			return nil, err
+		}
+	}
+
+	for j := 0; j < len(e.heap); j++ {
+		switch v := e.heap[j].(type) {
+		case *Cell:
+			e.WriteByte(heapCell)
+			if err := e.value(v.Value); err != nil {
+				return nil, err
+			}
+		case *bytecode.Function:
+			e.WriteByte(heapFunction)
+			e.string(v.Name)
+			e.uvarint(uint64(v.Params))
+			e.uvarint(uint64(v.Upvalues))
+			if err := e.code(&v.Code); err != nil {
+				return nil, err
+			}
+		case *bytecode.Bytecode:
+			e.WriteByte(heapCode)
+			if err := e.code(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return e.Bytes(), nil
+}
+
+// Restore replaces the stack and frames of i with those encoded in data by
+// Snapshot. If the snapshot was taken while paused, Resume continues from
+// there. Host functions are looked up among those registered on i.
+func (i *Interpreter) Restore(data []byte) error {
+	d := &decoder{Reader: bytes.NewReader(data), hosts: i.hosts}
+
+	sp, err := d.count(1)
+	if err != nil {
+		return err
+	}
+	stack := make([]Value, max(sp, len(i.stack)))
+	for j := 0; j < sp; j++ {
+		if stack[j], err = d.value(); err != nil {
+			return err
+		}
+	}
+
+	fp, err := d.count(5)
+	if err != nil {
+		return err
+	}
+	if fp == 0 {
+		return fmt.Errorf("%w: no frames", ErrInvalidSnapshot)
+	}
+	frames := make([]Frame, max(fp, len(i.frames)))
+	for j := 0; j < fp; j++ {
+		frame := &frames[j]
+		if frame.name, err = d.string(); err != nil {
+			return err
+		}
+		if ok, err := d.ReadByte(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		} else if ok != 0 {
+			if frame.code, err = ref[bytecode.Bytecode](d); err != nil {
+				return err
+			}
+		}
+		if frame.upvalues, err = d.cells(); err != nil {
+			return err
+		}
+		ip, err := binary.ReadVarint(d)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+		frame.ip = int(ip)
+		n, err := d.count(1)
+		if err != nil {
+			return err
+		}
+		frame.slots = make([]Value, n)
+		for k := range frame.slots {
+			if frame.slots[k], err = d.value(); err != nil {
+				return err
+			}
+		}
+	}
+
+	var code *bytecode.Bytecode
+	if running, err := d.ReadByte(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	} else if running != 0 {
+		if code, err = d.code(); err != nil {
+			return err
+		}
+		if err := Verify(*code); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+	}
+
+	n := 0
+	for ; d.Len() > 0; n++ {
+		kind, _ := d.ReadByte()
+		switch kind {
+		case heapCell:
+			cell, err := at[Cell](d, n)
+			if err != nil {
+				return err
+			}
+			if cell.Value, err = d.value(); err != nil {
+				return err
+			}
+		case heapFunction:
+			fn, err := at[bytecode.Function](d, n)
+			if err != nil {
+				return err
+			}
+			if fn.Name, err = d.string(); err != nil {
+				return err
+			}
+			params, err := d.count(0)
+			if err != nil {
+				return err
+			}
+			upvalues, err := d.count(0)
+			if err != nil {
+				return err
+			}
+			c, err := d.code()
+			if err != nil {
+				return err
+			}
+			fn.Params, fn.Upvalues, fn.Code = params, upvalues, *c
+			if err := VerifyFunction(*fn); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+			}
+		case heapCode:
+			c, err := at[bytecode.Bytecode](d, n)
+			if err != nil {
+				return err
+			}
+			decoded, err := d.code()
+			if err != nil {
+				return err
+			}
+			*c = *decoded
+		default:
+			return fmt.Errorf("%w: unknown heap entry %d", ErrInvalidSnapshot, kind)
+		}
+	}
+	if n != d.refs {
+		return fmt.Errorf("%w: %d heap entries for %d references", ErrInvalidSnapshot, n, d.refs)
+	}
+	for _, check := range d.checks {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+
+	verified := map[*bytecode.Bytecode]int{}
+	for j := range frames[:fp] {
+		frame := &frames[j]
+		c := frame.code
+		if c == nil {
+			c = code
+		} else if upvalues, ok := verified[c]; !ok || upvalues != len(frame.upvalues) {
+			if err := VerifyFunction(bytecode.Function{Upvalues: len(frame.upvalues), Code: *c}); err != nil {
+				return fmt.Errorf("%w: frame %d: %w", ErrInvalidSnapshot, j, err)
+			}
+			verified[c] = len(frame.upvalues)
+		}
+		if c != nil && !boundary(c, frame.ip+1) {
+			return fmt.Errorf("%w: frame %d: invalid offset %d", ErrInvalidSnapshot, j, frame.ip)
+		}
+	}
+
+	i.stack = stack
+	i.sp = sp
+	i.frames = frames
+	i.fp = fp
+	i.code = bytecode.Bytecode{}
+	i.running = false
+	if code != nil {
+		i.load(*code)
+	}
+	return nil
+}
+
+func (e *encoder) value(val Value) error {
+	if val == nil {
+		e.WriteByte(byte(UNKNOWN))
+		return nil
+	}
+
+	e.WriteByte(byte(val.Type()))
+	switch v := val.(type) {
+	case Undefined, Null:
+	case Bool:
+		e.WriteByte(byte(v))
+	case Int32:
+		_ = binary.Write(e, binary.BigEndian, int32(v))
+	case Float64:
+		_ = binary.Write(e, binary.BigEndian, math.Float64bits(float64(v)))
+	case String:
+		e.string(string(v))
+	case *Cell:
+		e.ref(v)
+	case Function:
+		e.WriteByte(functionScript)
+		e.ref(v.Function)
+		e.uvarint(uint64(len(v.Upvalues)))
+		for _, cell := range v.Upvalues {
+			e.ref(cell)
+		}
+	case Host:
+		name, ok := e.hosts[identity(v)]
+		if !ok {
+			return fmt.Errorf("unsupported value: host function not registered with WithHost")
+		}
+		e.WriteByte(functionHost)
+		e.string(name)
+	default:
+		return fmt.Errorf("unsupported value type: %v", val.Type())
+	}
+	return nil
+}
+
+func (e *encoder) code(code *bytecode.Bytecode) error {
+	data, err := code.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	e.string(string(data))
+	return nil
+}
+
+// ref writes the index of v in the heap, adding v to it the first time.
+func (e *encoder) ref(v any) {
+	idx, ok := e.refs[v]
+	if !ok {
+		idx = len(e.heap)
+		e.refs[v] = idx
+		e.heap = append(e.heap, v)
+	}
+	e.uvarint(uint64(idx))
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.WriteString(s)
+}
+
+func (e *encoder) uvarint(v uint64) {
+	e.Write(binary.AppendUvarint(nil, v))
+}
+
+func (e *encoder) varint(v int64) {
+	e.Write(binary.AppendVarint(nil, v))
+}
+
+func (d *decoder) value() (Value, error) {
+	typ, err := d.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+
+	switch Type(typ) {
+	case UNKNOWN:
+		return nil, nil
+	case UNDEFINED:
+		return Undefined{}, nil
+	case NULL:
+		return Null{}, nil
+	case BOOL:
+		b, err := d.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+		return Bool(b), nil
+	case INT32:
+		var v int32
+		if err := binary.Read(d, binary.BigEndian, &v); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+		return Int32(v), nil
+	case FLOAT64:
+		var v uint64
+		if err := binary.Read(d, binary.BigEndian, &v); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+		return Float64(math.Float64frombits(v)), nil
+	case STRING:
+		s, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		return String(s), nil
+	case ANY:
+		return ref[Cell](d)
+	case FUNCTION:
+		kind, err := d.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+		switch kind {
+		case functionScript:
+			fn, err := ref[bytecode.Function](d)
+			if err != nil {
+				return nil, err
+			}
+			upvalues, err := d.cells()
+			if err != nil {
+				return nil, err
+			}
+			d.checks = append(d.checks, func() error {
+				if len(upvalues) < fn.Upvalues {
+					return fmt.Errorf("%w: function %s has %d of %d upvalues", ErrInvalidSnapshot, fn.Name, len(upvalues), fn.Upvalues)
+				}
+				return nil
+			})
+			return Function{Function: fn, Upvalues: upvalues}, nil
+		case functionHost:
+			name, err := d.string()
+			if err != nil {
+				return nil, err
+			}
+			fn, ok := d.hosts[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownHost, name)
+			}
+			return fn, nil
+		default:
+			return nil, fmt.Errorf("%w: unknown function kind %d", ErrInvalidSnapshot, kind)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown value type %d", ErrInvalidSnapshot, typ)
+	}
+}
+
+func (d *decoder) cells() ([]*Cell, error) {
+	n, err := d.count(1)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	cells := make([]*Cell, n)
+	for j := range cells {
+		if cells[j], err = ref[Cell](d); err != nil {
+			return nil, err
+		}
+	}
+	return cells, nil
+}
+
+func (d *decoder) code() (*bytecode.Bytecode, error) {
+	data, err := d.string()
+	if err != nil {
+		return nil, err
+	}
+	code := &bytecode.Bytecode{}
+	if err := code.UnmarshalBinary([]byte(data)); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	return code, nil
+}
+
+func (d *decoder) string() (string, error) {
+	n, err := d.count(1)
+	if err != nil {
+		return "", err
+	}
+	s := make([]byte, n)
+	if _, err := io.ReadFull(d, s); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	return string(s), nil
+}
+
+// count reads a count of items that take at least size bytes each, failing if
+// fewer bytes are left than they need. A size of 0 reads a number that takes
+// no room, such as a parameter count.
+func (d *decoder) count(size int) (int, error) {
+	n, err := binary.ReadUvarint(d)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	if size == 0 && n > math.MaxInt32 || size > 0 && n > uint64(d.Len()/size) {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidSnapshot, io.ErrUnexpectedEOF)
+	}
+	return int(n), nil
+}
+
+// ref reads the index of a heap entry and returns the entry, which is filled
+// in when the heap is read.
+func ref[T any](d *decoder) (*T, error) {
+	idx, err := d.count(1)
+	if err != nil {
+		return nil, err
+	}
+	d.refs = max(d.refs, idx+1)
+	return at[T](d, idx)
+}
+
+// at returns the heap entry at idx, allocating it the first time.
+func at[T any](d *decoder, idx int) (*T, error) {
+	for len(d.heap) <= idx {
+		d.heap = append(d.heap, nil)
+	}
+	if d.heap[idx] == nil {
+		d.heap[idx] = new(T)
+	}
+	v, ok := d.heap[idx].(*T)
+	if !ok {
+		return nil, fmt.Errorf("%w: heap entry %d is a %T, not a %T", ErrInvalidSnapshot, idx, d.heap[idx], v)
+	}
+	return v, nil
+}
+
+// boundary reports whether offset is where an instruction of code starts or
+// the end of its instructions.
+func boundary(code *bytecode.Bytecode, offset int) bool {
+	for start := 0; start < len(code.Instructions); {
+		if start == offset {
+			return true
+		}
+		_, width := code.Fetch(start)
+		start += width
+	}
+	return offset == len(code.Instructions)
+}
+
+// identity returns the address of the closure fn refers to, which tells apart
+// host functions that cannot be compared with ==.
+func identity(fn Host) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&fn))
+}
@@ -0,0 +1,171 @@
+package interpreter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpreter_Snapshot(t *testing.T) {
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.STRLOAD, 0),
+		bytecode.New(bytecode.SLTSTORE, 2),
+		bytecode.New(bytecode.UNDEFLOAD),
+		bytecode.New(bytecode.NULLLOAD),
+		bytecode.New(bytecode.BOOLLOAD, 1),
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.F64LOAD, math.Float64bits(1.5)),
+	)
+	code.Store(bytecode.String("foo"))
+
+	interpreter := New()
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	data, err := interpreter.Snapshot()
+	assert.NoError(t, err)
+
+	restored := New()
+
+	err = restored.Restore(data)
+	assert.NoError(t, err)
+
+	assert.Equal(t, interpreter.Stack(), restored.Stack())
+
+	val, ok := restored.Slot(2)
+	assert.True(t, ok)
+	assert.Equal(t, String("foo"), val)
+
+	_, ok = restored.Slot(0)
+	assert.False(t, ok)
+}
+
+func TestInterpreter_Snapshot_Globals(t *testing.T) {
+	var code bytecode.Bytecode
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.CELLSTORE, 0),
+		bytecode.New(bytecode.CELLREF, 0),
+		bytecode.New(bytecode.STRLOAD, 0),
+		bytecode.New(bytecode.GLBSTORE, 1),
+	)
+	code.Store(bytecode.String("foo"))
+
+	interpreter := New()
+
+	err := interpreter.Execute(code)
+	assert.NoError(t, err)
+
+	data, err := interpreter.Snapshot()
+	assert.NoError(t, err)
+
+	restored := New()
+
+	err = restored.Restore(data)
+	assert.NoError(t, err)
+
+	val, ok := restored.Global(1)
+	assert.True(t, ok)
+	assert.Equal(t, String("foo"), val)
+
+	cell, ok := restored.Global(0)
+	assert.True(t, ok)
+	assert.Equal(t, &Cell{Value: Int32(1)}, cell)
+
+	top, err := restored.Top()
+	assert.NoError(t, err)
+	assert.Same(t, cell, top)
+}
+
+func TestInterpreter_Snapshot_Call(t *testing.T) {
+	inc := Host(func(args []Value) (Value, error) {
+		return Int32(ToInt32(args[0]) + 1), nil
+	})
+
+	var fn bytecode.Bytecode
+	fn.Emit(
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.SLTLOAD, 1),
+		bytecode.New(bytecode.I32ADD),
+		bytecode.New(bytecode.UPVLOAD, 0),
+		bytecode.New(bytecode.I32ADD),
+		bytecode.New(bytecode.RET),
+	)
+
+	var code bytecode.Bytecode
+	code.Functions = []bytecode.Function{{Name: "add", Params: 2, Upvalues: 1, Code: fn}}
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 10),
+		bytecode.New(bytecode.CELLSTORE, 0),
+		bytecode.New(bytecode.CELLREF, 0),
+		bytecode.New(bytecode.CLOSURE, 0, 1),
+		bytecode.New(bytecode.GLBSTORE, 1),
+		bytecode.New(bytecode.GLBLOAD, 1),
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.CALL, 2),
+		bytecode.New(bytecode.GLBLOAD, 2),
+		bytecode.New(bytecode.I32LOAD, 5),
+		bytecode.New(bytecode.CALL, 1),
+	)
+
+	interpreter := New(WithHost("inc", inc))
+	interpreter.SetGlobal(2, inc)
+
+	status, err := interpreter.ExecuteN(code, 11)
+	assert.NoError(t, err)
+	assert.Equal(t, Paused, status)
+	assert.Equal(t, []Value{Int32(1), Int32(2)}, interpreter.Stack())
+
+	data, err := interpreter.Snapshot()
+	assert.NoError(t, err)
+
+	restored := New(WithHost("inc", inc))
+
+	err = restored.Restore(data)
+	assert.NoError(t, err)
+
+	fn1, _ := restored.Global(1)
+	cell, _ := restored.Global(0)
+	assert.Same(t, cell, fn1.(Function).Upvalues[0])
+
+	status, err = restored.Resume(-1)
+	assert.NoError(t, err)
+	assert.Equal(t, Done, status)
+	assert.Equal(t, []Value{Int32(13), Int32(6)}, restored.Stack())
+
+	err = New().Restore(data)
+	assert.ErrorIs(t, err, ErrUnknownHost)
+
+	interpreter.SetGlobal(2, Host(func(args []Value) (Value, error) { return nil, nil }))
+	_, err = interpreter.Snapshot()
+	assert.Error(t, err)
+}
+
+func TestInterpreter_Restore(t *testing.T) {
+	err := New().Restore([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00})
+	assert.NoError(t, err)
+
+	tests := [][]byte{
+		nil,
+		{0x00},
+		{0x01, 0xFF},
+		{0x00, 0x01, 0x00, 0x01},
+		{0x80, 0x80, 0x80, 0x80, 0x80, 0x20},
+		{0x00, 0x80, 0x80, 0x80, 0x80, 0x80, 0x20},
+		{0x01, byte(ANY), 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00},
+		{0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, heapCell, byte(UNDEFINED)},
+		{0x01, byte(ANY), 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, heapCell, byte(UNDEFINED)},
+		{0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x02, 0xFF, 0xFF},
+	}
+
+	for _, data := range tests {
+		interpreter := New()
+		err := interpreter.Restore(data)
+		assert.ErrorIs(t, err, ErrInvalidSnapshot)
+	}
+}
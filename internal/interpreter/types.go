@@ -3,6 +3,8 @@ package interpreter
 import (
 	"math"
 	"strconv"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
 )
 
 type Value interface {
@@ -22,6 +24,8 @@ const (
 	FLOAT64
 	STRING
 	OBJECT
+	ANY
+	FUNCTION
 )
 
 func (t Type) String() string {
@@ -42,6 +46,10 @@ func (t Type) String() string {
 		return "string"
 	case OBJECT:
 		return "object"
+	case ANY:
+		return "any"
+	case FUNCTION:
+		return "function"
 	default:
 		return "<invalid>"
 	}
@@ -139,3 +147,72 @@ func (s String) Interface() any {
 func (s String) String() string {
 	return "\"" + string(s) + "\""
 }
+
+type Function struct {
+	*bytecode.Function
+	Upvalues []*Cell
+}
+
+func (f Function) Type() Type {
+	return FUNCTION
+}
+
+func (f Function) Interface() any {
+	return f.Function
+}
+
+func (f Function) String() string {
+	return "function " + f.Name + "() { [bytecode] }"
+}
+
+// Host is a function implemented by the embedder. Scripts reach it through
+// call.host or as a value passed to call. A nil result is treated as undefined.
+type Host func(args []Value) (Value, error)
+
+func (h Host) Type() Type {
+	return FUNCTION
+}
+
+func (h Host) Interface() any {
+	return h
+}
+
+func (h Host) String() string {
+	return "function () { [native code] }"
+}
+
+// Object is a value whose properties are provided by the embedder. Property
+// keys are strings; an index is converted with ToString first. A nil result is
+// treated as undefined.
+type Object interface {
+	Value
+	Get(key string) (Value, error)
+}
+
+// Array is an Object whose elements are read by index, from 0 up to Len.
+type Array interface {
+	Object
+	Len() int
+}
+
+type Cell struct {
+	Value Value
+}
+
+func (c *Cell) Type() Type {
+	return ANY
+}
+
+func (c *Cell) Interface() any {
+	if c.Value == nil {
+		return nil
+	}
+	return c.Value.Interface()
+}
+
+func (c *Cell) Load() Value {
+	if c.Value == nil {
+		return Undefined{}
+	}
+	return c.Value
+}
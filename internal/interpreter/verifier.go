@@ -0,0 +1,64 @@
+package interpreter
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+)
+
+var ErrInvalidBytecode = bytecode.ErrInvalidBytecode
+
+func Verify(code bytecode.Bytecode) error {
+	if err := code.Validate(); err != nil {
+		return err
+	}
+	return verify(code, nil)
+}
+
+func VerifyFunction(fn bytecode.Function) error {
+	if err := fn.Code.Validate(); err != nil {
+		return err
+	}
+	return verify(fn.Code, &fn)
+}
+
+func verify(code bytecode.Bytecode, fn *bytecode.Function) error {
+	depth := 0
+	last := bytecode.NOP
+	for offset := 0; offset < len(code.Instructions); {
+		inst, width := code.Fetch(offset)
+		opcode := inst.Opcode()
+
+		switch opcode {
+		case bytecode.SLTLOAD, bytecode.SLTSTORE, bytecode.GLBLOAD, bytecode.GLBSTORE, bytecode.CELLLOAD, bytecode.CELLSTORE, bytecode.CELLREF:
+			if idx, _ := binary.Uvarint(inst[1:]); idx >= MaxSlots {
+				return fmt.Errorf("%w: slot out of range in %s at offset %d", ErrInvalidBytecode, inst.String(), offset)
+			}
+		case bytecode.UPVLOAD, bytecode.UPVSTORE, bytecode.UPVREF:
+			if fn == nil || inst.Operands()[0] >= uint64(fn.Upvalues) {
+				return fmt.Errorf("%w: upvalue out of range in %s at offset %d", ErrInvalidBytecode, inst.String(), offset)
+			}
+		default:
+		}
+
+		pop, push := inst.Effect()
+		if depth < pop {
+			return fmt.Errorf("%w: %w in %s at offset %d", ErrInvalidBytecode, ErrStackUnderflow, inst.Type().Mnemonic, offset)
+		}
+		depth += push - pop
+
+		last = opcode
+		offset += width
+	}
+
+	if fn != nil && last != bytecode.RET {
+		return fmt.Errorf("%w: missing ret at end of function", ErrInvalidBytecode)
+	}
+	for i := range code.Functions {
+		if err := verify(code.Functions[i].Code, &code.Functions[i]); err != nil {
+			return fmt.Errorf("function %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		code bytecode.Bytecode
+		err  error
+	}{
+		{
+			code: bytecode.Bytecode{},
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: []byte{byte(bytecode.I32LOAD), 0, 0, 0, 1, byte(bytecode.POP)},
+			},
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: []byte{0xFF},
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: []byte{byte(bytecode.I32LOAD), 0, 0},
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: []byte{byte(bytecode.POP)},
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: bytecode.New(bytecode.STRLOAD, 0),
+				Constants:    []bytecode.Constant{bytecode.String("foo")},
+			},
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: bytecode.New(bytecode.STRLOAD, 1),
+				Constants:    []bytecode.Constant{bytecode.String("foo")},
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: bytecode.New(bytecode.STRLOAD, 0),
+				Constants:    []bytecode.Constant{bytecode.Float64(1)},
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: bytecode.New(bytecode.F64CONST, 0),
+				Constants:    []bytecode.Constant{bytecode.String("foo")},
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: bytecode.New(bytecode.FUNCLOAD, 0),
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: append(bytecode.New(bytecode.FUNCLOAD, 0), bytecode.New(bytecode.CALL, 1)...),
+				Functions:    []bytecode.Function{{Code: bytecode.Bytecode{Instructions: append(bytecode.New(bytecode.UNDEFLOAD), bytecode.New(bytecode.RET)...)}}},
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: append(bytecode.New(bytecode.FUNCLOAD, 0), bytecode.New(bytecode.CALL, 0)...),
+				Functions:    []bytecode.Function{{Code: bytecode.Bytecode{Instructions: append(bytecode.New(bytecode.UNDEFLOAD), bytecode.New(bytecode.RET)...)}}},
+			},
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: bytecode.New(bytecode.FUNCLOAD, 0),
+				Functions:    []bytecode.Function{{Code: bytecode.Bytecode{Instructions: bytecode.New(bytecode.UNDEFLOAD)}}},
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: bytecode.New(bytecode.FUNCLOAD, 0),
+				Functions:    []bytecode.Function{{Upvalues: 1, Code: bytecode.Bytecode{Instructions: append(bytecode.New(bytecode.UPVLOAD, 0), bytecode.New(bytecode.RET)...)}}},
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: append(bytecode.New(bytecode.CELLREF, 0), bytecode.New(bytecode.CLOSURE, 0, 1)...),
+				Functions:    []bytecode.Function{{Upvalues: 1, Code: bytecode.Bytecode{Instructions: append(bytecode.New(bytecode.UPVLOAD, 0), bytecode.New(bytecode.RET)...)}}},
+			},
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: append(bytecode.New(bytecode.CELLREF, 0), bytecode.New(bytecode.CLOSURE, 0, 1)...),
+				Functions:    []bytecode.Function{{Upvalues: 1, Code: bytecode.Bytecode{Instructions: append(bytecode.New(bytecode.UPVLOAD, 1), bytecode.New(bytecode.RET)...)}}},
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: bytecode.New(bytecode.UPVLOAD, 0),
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: append(bytecode.New(bytecode.UNDEFLOAD), bytecode.New(bytecode.SLTSTORE, MaxSlots-1)...),
+			},
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: append(bytecode.New(bytecode.UNDEFLOAD), bytecode.New(bytecode.SLTSTORE, 0x3FFFFFFFFFFFFFFF)...),
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: bytecode.New(bytecode.CELLLOAD, 0x7FFFFFFFFFFFFFFF),
+			},
+			err: ErrInvalidBytecode,
+		},
+		{
+			code: bytecode.Bytecode{
+				Instructions: bytecode.New(bytecode.GLBLOAD, MaxSlots),
+			},
+			err: ErrInvalidBytecode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			err := Verify(tt.code)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
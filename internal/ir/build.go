@@ -0,0 +1,151 @@
+package ir
+
+import (
+	"fmt"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/siyul-park/minijs/internal/interpreter"
+)
+
+func Build(code bytecode.Bytecode) (*Function, error) {
+	if err := interpreter.Verify(code); err != nil {
+		return nil, err
+	}
+	return build(code)
+}
+
+func BuildFunction(fn bytecode.Function) (*Function, error) {
+	if err := interpreter.VerifyFunction(fn); err != nil {
+		return nil, err
+	}
+	return build(fn.Code)
+}
+
+func build(code bytecode.Bytecode) (*Function, error) {
+	f := &Function{
+		Constants: append([]bytecode.Constant(nil), code.Constants...),
+		Functions: code.Functions,
+	}
+	b := f.NewBlock()
+
+	for _, fn := range code.Functions {
+		f.slots = max(f.slots, globals(fn.Code))
+	}
+
+	var stack []*Value
+	defs := map[uint64]*Value{}
+
+	pop := func() *Value {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+
+	for offset, inst := range code.Iter() {
+		line := code.Line(offset)
+
+		op := inst.Opcode()
+		operands := inst.Operands()
+
+		switch op {
+		case bytecode.NOP:
+		case bytecode.POP:
+			pop()
+		case bytecode.DUP:
+			stack = append(stack, stack[len(stack)-1])
+		case bytecode.SLTLOAD:
+			f.slots = max(f.slots, int(operands[0])+1)
+			if v, ok := defs[operands[0]]; ok {
+				stack = append(stack, v)
+				continue
+			}
+			v := f.NewValue(b, op, nil, operands...)
+			v.Line = line
+			defs[operands[0]] = v
+			stack = append(stack, v)
+		case bytecode.SLTSTORE:
+			f.slots = max(f.slots, int(operands[0])+1)
+			arg := pop()
+			v := f.NewValue(b, op, []*Value{arg}, operands...)
+			v.Line = line
+			defs[operands[0]] = arg
+		case bytecode.CELLLOAD, bytecode.CELLREF:
+			f.slots = max(f.slots, int(operands[0])+1)
+			v := f.NewValue(b, op, nil, operands...)
+			v.Line = line
+			stack = append(stack, v)
+		case bytecode.CELLSTORE:
+			f.slots = max(f.slots, int(operands[0])+1)
+			v := f.NewValue(b, op, []*Value{pop()}, operands...)
+			v.Line = line
+		case bytecode.CLOSURE:
+			args := make([]*Value, operands[1])
+			for i := len(args) - 1; i >= 0; i-- {
+				args[i] = pop()
+			}
+			v := f.NewValue(b, op, args, operands...)
+			v.Line = line
+			stack = append(stack, v)
+		case bytecode.CALL:
+			args := make([]*Value, operands[0]+1)
+			for i := len(args) - 1; i >= 0; i-- {
+				args[i] = pop()
+			}
+			v := f.NewValue(b, op, args, operands...)
+			v.Line = line
+			clear(defs)
+			stack = append(stack, v)
+		default:
+			n, push := inst.Effect()
+			if push > 1 {
+				return nil, fmt.Errorf("unsupported opcode: %s", inst.Type().Mnemonic)
+			}
+			args := make([]*Value, n)
+			for i := n - 1; i >= 0; i-- {
+				args[i] = pop()
+			}
+			v := f.NewValue(b, op, args, operands...)
+			v.Line = line
+			if push == 1 {
+				stack = append(stack, v)
+			}
+		}
+	}
+
+	b.Results = stack
+	f.count()
+	return f, nil
+}
+
+func globals(code bytecode.Bytecode) int {
+	n := 0
+	for _, inst := range code.Iter() {
+		switch inst.Opcode() {
+		case bytecode.GLBLOAD, bytecode.GLBSTORE:
+			n = max(n, int(inst.Operands()[0])+1)
+		default:
+		}
+	}
+	for _, fn := range code.Functions {
+		n = max(n, globals(fn.Code))
+	}
+	return n
+}
+
+func (f *Function) count() {
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			v.Uses = 0
+		}
+	}
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			for _, arg := range v.Args {
+				arg.Uses++
+			}
+		}
+		for _, v := range b.Results {
+			v.Uses++
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package ir is a value graph for optimizing compiled code. It is built from
+// verified bytecode rather than from the syntax tree, so it only sees what the
+// compiler already emitted: a Function holds straight-line blocks of values
+// with no names or scopes, and Bytecode lowers it back. Fold evaluates
+// constant values by running them on the interpreter, so folding follows the
+// interpreter's semantics exactly.
+package ir
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+)
+
+type Value struct {
+	ID   int
+	Op   bytecode.Opcode
+	Args []*Value
+	Aux  []uint64
+	Uses int
+	Line int
+}
+
+type Block struct {
+	ID      int
+	Values  []*Value
+	Results []*Value
+}
+
+type Function struct {
+	Blocks    []*Block
+	Constants []bytecode.Constant
+	Functions []bytecode.Function
+	slots     int
+	values    int
+}
+
+func (f *Function) NewBlock() *Block {
+	b := &Block{ID: len(f.Blocks)}
+	f.Blocks = append(f.Blocks, b)
+	return b
+}
+
+func (f *Function) NewValue(b *Block, op bytecode.Opcode, args []*Value, aux ...uint64) *Value {
+	v := &Value{ID: f.values, Op: op, Args: args, Aux: aux}
+	f.values++
+	b.Values = append(b.Values, v)
+	return v
+}
+
+// Reserve keeps the slots below n out of the temporaries that Bytecode
+// allocates, for variables that f does not touch but other code run in the
+// same frame does.
+func (f *Function) Reserve(n int) {
+	f.slots = max(f.slots, n)
+}
+
+func (f *Function) String() string {
+	var out strings.Builder
+	for _, b := range f.Blocks {
+		out.WriteString(b.String())
+	}
+	return out.String()
+}
+
+func (b *Block) String() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "b%d:\n", b.ID)
+	for _, v := range b.Values {
+		fmt.Fprintf(&out, "\t%s\n", v.String())
+	}
+	if len(b.Results) > 0 {
+		var results []string
+		for _, v := range b.Results {
+			results = append(results, fmt.Sprintf("v%d", v.ID))
+		}
+		fmt.Fprintf(&out, "\tresult %s\n", strings.Join(results, " "))
+	}
+	return out.String()
+}
+
+func (v *Value) String() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "v%d = %s", v.ID, bytecode.TypeOf(v.Op).Mnemonic)
+	for _, arg := range v.Args {
+		fmt.Fprintf(&out, " v%d", arg.ID)
+	}
+	for _, aux := range v.Aux {
+		fmt.Fprintf(&out, " 0x%X", aux)
+	}
+	return out.String()
+}
+
+func (v *Value) Constant() bool {
+	switch v.Op {
+	case bytecode.UNDEFLOAD, bytecode.NULLLOAD, bytecode.BOOLLOAD, bytecode.I32LOAD, bytecode.F64LOAD, bytecode.F64CONST, bytecode.STRLOAD:
+		return true
+	default:
+		return false
+	}
+}
+
+func (v *Value) Pure() bool {
+	switch v.Op {
+	case bytecode.SLTSTORE, bytecode.GLBLOAD, bytecode.GLBSTORE,
+		bytecode.CELLLOAD, bytecode.CELLSTORE, bytecode.CELLREF,
+		bytecode.UPVLOAD, bytecode.UPVSTORE, bytecode.UPVREF,
+		bytecode.CLOSURE, bytecode.CALL, bytecode.RET, bytecode.CALLHOST, bytecode.PROPLOAD:
+		return false
+	default:
+		return v.Op < bytecode.CustomOpcodes
+	}
+}
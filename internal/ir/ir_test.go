@@ -0,0 +1,287 @@
+package ir
+
+import (
+	"testing"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/siyul-park/minijs/internal/interpreter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild(t *testing.T) {
+	code := bytecode.Bytecode{}
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.SLTSTORE, 0),
+		bytecode.New(bytecode.SLTLOAD, 0),
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.I32ADD),
+	)
+
+	f, err := Build(code)
+	assert.NoError(t, err)
+	assert.Equal(t, "b0:\n\tv0 = i32.load 0x1\n\tv1 = slot.store v0 0x0\n\tv2 = i32.load 0x2\n\tv3 = i32.add v0 v2\n\tresult v3\n", f.String())
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		instructions []bytecode.Instruction
+		functions    []bytecode.Function
+		expected     []bytecode.Instruction
+	}{
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.I32LOAD, 2),
+				bytecode.New(bytecode.I32ADD),
+			},
+			expected: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 3),
+			},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.SLTSTORE, 0),
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.I32LOAD, 2),
+				bytecode.New(bytecode.I32MUL),
+			},
+			expected: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.SLTSTORE, 0),
+				bytecode.New(bytecode.I32LOAD, 2),
+			},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.I32LOAD, 2),
+				bytecode.New(bytecode.I32ADD),
+				bytecode.New(bytecode.POP),
+				bytecode.New(bytecode.I32LOAD, 3),
+			},
+			expected: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 3),
+			},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.DUP),
+				bytecode.New(bytecode.I32ADD),
+			},
+			expected: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.DUP),
+				bytecode.New(bytecode.SLTSTORE, 1),
+				bytecode.New(bytecode.SLTLOAD, 1),
+				bytecode.New(bytecode.I32ADD),
+			},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.SLTSTORE, 0),
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.I32ADD),
+			},
+			expected: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.SLTSTORE, 1),
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.SLTSTORE, 0),
+				bytecode.New(bytecode.SLTLOAD, 1),
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.I32ADD),
+			},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.DUP),
+				bytecode.New(bytecode.I32MUL),
+				bytecode.New(bytecode.SLTSTORE, 1),
+				bytecode.New(bytecode.SLTLOAD, 2),
+				bytecode.New(bytecode.DUP),
+				bytecode.New(bytecode.I32MUL),
+				bytecode.New(bytecode.SLTSTORE, 1),
+			},
+			expected: []bytecode.Instruction{
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.DUP),
+				bytecode.New(bytecode.SLTSTORE, 3),
+				bytecode.New(bytecode.SLTLOAD, 3),
+				bytecode.New(bytecode.I32MUL),
+				bytecode.New(bytecode.SLTSTORE, 1),
+				bytecode.New(bytecode.SLTLOAD, 2),
+				bytecode.New(bytecode.DUP),
+				bytecode.New(bytecode.SLTSTORE, 3),
+				bytecode.New(bytecode.SLTLOAD, 3),
+				bytecode.New(bytecode.I32MUL),
+				bytecode.New(bytecode.SLTSTORE, 1),
+			},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.SLTSTORE, 0),
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.FUNCLOAD, 0),
+				bytecode.New(bytecode.CALL, 0),
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.I32ADD),
+				bytecode.New(bytecode.I32ADD),
+			},
+			functions: []bytecode.Function{
+				{
+					Code: bytecode.Bytecode{
+						Instructions: concat(
+							bytecode.New(bytecode.GLBLOAD, 0),
+							bytecode.New(bytecode.I32LOAD, 1),
+							bytecode.New(bytecode.I32ADD),
+							bytecode.New(bytecode.DUP),
+							bytecode.New(bytecode.GLBSTORE, 0),
+							bytecode.New(bytecode.RET),
+						),
+					},
+				},
+			},
+			expected: []bytecode.Instruction{
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.SLTSTORE, 0),
+				bytecode.New(bytecode.FUNCLOAD, 0),
+				bytecode.New(bytecode.CALL, 0),
+				bytecode.New(bytecode.SLTSTORE, 1),
+				bytecode.New(bytecode.I32LOAD, 1),
+				bytecode.New(bytecode.SLTLOAD, 1),
+				bytecode.New(bytecode.SLTLOAD, 0),
+				bytecode.New(bytecode.I32ADD),
+				bytecode.New(bytecode.I32ADD),
+			},
+		},
+		{
+			instructions: []bytecode.Instruction{
+				bytecode.New(bytecode.FUNCLOAD, 0),
+				bytecode.New(bytecode.CALL, 0),
+				bytecode.New(bytecode.POP),
+			},
+			functions: []bytecode.Function{
+				{
+					Code: bytecode.Bytecode{
+						Instructions: concat(
+							bytecode.New(bytecode.GLBLOAD, 0),
+							bytecode.New(bytecode.I32LOAD, 1),
+							bytecode.New(bytecode.I32ADD),
+							bytecode.New(bytecode.DUP),
+							bytecode.New(bytecode.GLBSTORE, 0),
+							bytecode.New(bytecode.RET),
+						),
+					},
+				},
+			},
+			expected: []bytecode.Instruction{
+				bytecode.New(bytecode.FUNCLOAD, 0),
+				bytecode.New(bytecode.CALL, 0),
+				bytecode.New(bytecode.POP),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		code := bytecode.Bytecode{Functions: tt.functions}
+		code.Emit(tt.instructions...)
+
+		t.Run(code.String(), func(t *testing.T) {
+			f, err := Build(code)
+			assert.NoError(t, err)
+
+			err = Optimize(f, Fold, Eliminate)
+			assert.NoError(t, err)
+
+			expected := bytecode.Bytecode{Functions: tt.functions}
+			expected.Emit(tt.expected...)
+
+			actual := f.Bytecode()
+			assert.Equal(t, expected.String(), actual.String())
+
+			i1 := interpreter.New()
+			i2 := interpreter.New()
+			for idx := range 3 {
+				i1.SetGlobal(idx, interpreter.Int32(idx+3))
+				i2.SetGlobal(idx, interpreter.Int32(idx+3))
+			}
+
+			err = i1.Execute(code)
+			assert.NoError(t, err)
+			err = i2.Execute(actual)
+			assert.NoError(t, err)
+
+			assert.Equal(t, i1.Stack(), i2.Stack())
+		})
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	code := bytecode.Bytecode{}
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.DUP),
+		bytecode.New(bytecode.SLTSTORE, 1),
+		bytecode.New(bytecode.SLTLOAD, 1),
+		bytecode.New(bytecode.I32ADD),
+		bytecode.New(bytecode.SLTSTORE, 0),
+	)
+
+	f, err := Build(code)
+	assert.NoError(t, err)
+
+	err = Optimize(f, Fold, Discard(1), Eliminate)
+	assert.NoError(t, err)
+
+	expected := bytecode.Bytecode{}
+	expected.Emit(
+		bytecode.New(bytecode.I32LOAD, 2),
+		bytecode.New(bytecode.SLTSTORE, 0),
+	)
+	actual := f.Bytecode()
+	assert.Equal(t, expected.String(), actual.String())
+}
+
+func TestFunction_Reserve(t *testing.T) {
+	code := bytecode.Bytecode{}
+	code.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.CELLSTORE, 0),
+		bytecode.New(bytecode.GLBLOAD, 0),
+		bytecode.New(bytecode.DUP),
+		bytecode.New(bytecode.ADD),
+	)
+
+	f, err := Build(code)
+	assert.NoError(t, err)
+
+	f.Reserve(3)
+
+	expected := bytecode.Bytecode{}
+	expected.Emit(
+		bytecode.New(bytecode.I32LOAD, 1),
+		bytecode.New(bytecode.CELLSTORE, 0),
+		bytecode.New(bytecode.GLBLOAD, 0),
+		bytecode.New(bytecode.SLTSTORE, 3),
+		bytecode.New(bytecode.SLTLOAD, 3),
+		bytecode.New(bytecode.SLTLOAD, 3),
+		bytecode.New(bytecode.ADD),
+	)
+	actual := f.Bytecode()
+	assert.Equal(t, expected.String(), actual.String())
+}
+
+func concat(instructions ...bytecode.Instruction) []byte {
+	var out []byte
+	for _, inst := range instructions {
+		out = append(out, inst...)
+	}
+	return out
+}
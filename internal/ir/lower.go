@@ -0,0 +1,104 @@
+package ir
+
+import (
+	"github.com/siyul-park/minijs/internal/bytecode"
+)
+
+type lowering struct {
+	code      bytecode.Bytecode
+	emitted   map[*Value]bool
+	temps     map[*Value]uint64
+	remaining map[*Value]int
+	free      []uint64
+	slots     int
+}
+
+func (f *Function) Bytecode() bytecode.Bytecode {
+	l := &lowering{
+		code: bytecode.Bytecode{
+			Constants: f.Constants,
+			Functions: f.Functions,
+		},
+		emitted:   map[*Value]bool{},
+		temps:     map[*Value]uint64{},
+		remaining: map[*Value]int{},
+		slots:     f.slots,
+	}
+
+	for _, b := range f.Blocks {
+		for i, v := range b.Values {
+			if v.Pure() {
+				continue
+			}
+			for _, u := range b.Values[:i] {
+				if u.Op == bytecode.SLTLOAD && (v.Op == bytecode.CALL || v.Op == bytecode.SLTSTORE && u.Aux[0] == v.Aux[0]) && u.Uses > 0 && !l.emitted[u] {
+					l.spill(u)
+				}
+			}
+			l.emit(v)
+			if bytecode.TypeOf(v.Op).Push > 0 {
+				l.store(v)
+			}
+		}
+		for _, v := range b.Results {
+			l.emit(v)
+		}
+	}
+	return l.code
+}
+
+func (l *lowering) emit(v *Value) {
+	if tmp, ok := l.temps[v]; ok {
+		l.code.Mark(v.Line)
+		l.code.Emit(bytecode.New(bytecode.SLTLOAD, tmp))
+		if l.remaining[v]--; l.remaining[v] == 0 {
+			delete(l.temps, v)
+			l.free = append(l.free, tmp)
+		}
+		return
+	}
+
+	for _, arg := range v.Args {
+		l.emit(arg)
+	}
+	l.code.Mark(v.Line)
+	l.code.Emit(bytecode.New(v.Op, v.Aux...))
+	l.emitted[v] = true
+
+	if v.Pure() && !v.Constant() && v.Uses > 1 {
+		tmp := l.temp()
+		l.code.Emit(bytecode.New(bytecode.DUP), bytecode.New(bytecode.SLTSTORE, tmp))
+		l.temps[v] = tmp
+		l.remaining[v] = v.Uses - 1
+	}
+}
+
+func (l *lowering) spill(v *Value) {
+	l.code.Mark(v.Line)
+	l.code.Emit(bytecode.New(v.Op, v.Aux...))
+	l.emitted[v] = true
+	l.store(v)
+}
+
+func (l *lowering) store(v *Value) {
+	if v.Uses == 0 {
+		l.code.Emit(bytecode.New(bytecode.POP))
+		return
+	}
+
+	tmp := l.temp()
+	l.code.Emit(bytecode.New(bytecode.SLTSTORE, tmp))
+	l.temps[v] = tmp
+	l.remaining[v] = v.Uses
+}
+
+func (l *lowering) temp() uint64 {
+	if n := len(l.free); n > 0 {
+		tmp := l.free[n-1]
+		l.free = l.free[:n-1]
+		return tmp
+	}
+	tmp := uint64(l.slots)
+	l.slots++
+	return tmp
+}
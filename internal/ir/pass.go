@@ -0,0 +1,132 @@
+package ir
+
+import (
+	"math"
+	"slices"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/siyul-park/minijs/internal/interpreter"
+)
+
+type Pass func(*Function) error
+
+func Optimize(f *Function, passes ...Pass) error {
+	for _, pass := range passes {
+		if err := pass(f); err != nil {
+			return err
+		}
+	}
+	f.count()
+	return nil
+}
+
+func Fold(f *Function) error {
+	var i *interpreter.Interpreter
+	for _, b := range f.Blocks {
+		for _, v := range b.Values {
+			if !v.Pure() || v.Constant() || v.Op == bytecode.SLTLOAD || len(v.Args) == 0 {
+				continue
+			}
+
+			code := bytecode.Bytecode{Constants: f.Constants}
+			foldable := true
+			for _, arg := range v.Args {
+				if !arg.Constant() {
+					foldable = false
+					break
+				}
+				code.Emit(bytecode.New(arg.Op, arg.Aux...))
+			}
+			if !foldable {
+				continue
+			}
+			code.Emit(bytecode.New(v.Op, v.Aux...))
+
+			if i == nil {
+				i = interpreter.New()
+			}
+			if err := i.Execute(code); err != nil {
+				return err
+			}
+			val, err := i.Pop()
+			if err != nil {
+				return err
+			}
+
+			switch val := val.(type) {
+			case interpreter.Undefined:
+				v.Op, v.Aux = bytecode.UNDEFLOAD, nil
+			case interpreter.Null:
+				v.Op, v.Aux = bytecode.NULLLOAD, nil
+			case interpreter.Bool:
+				v.Op, v.Aux = bytecode.BOOLLOAD, []uint64{uint64(val)}
+			case interpreter.Int32:
+				v.Op, v.Aux = bytecode.I32LOAD, []uint64{uint64(uint32(val))}
+			case interpreter.Float64:
+				v.Op, v.Aux = bytecode.F64LOAD, []uint64{math.Float64bits(float64(val))}
+			case interpreter.String:
+				idx := len(f.Constants)
+				f.Constants = append(f.Constants, bytecode.String(val))
+				v.Op, v.Aux = bytecode.STRLOAD, []uint64{uint64(idx)}
+			default:
+				continue
+			}
+			v.Args = nil
+		}
+	}
+	return nil
+}
+
+func Eliminate(f *Function) error {
+	f.count()
+	for _, b := range f.Blocks {
+		for changed := true; changed; {
+			changed = false
+			values := b.Values[:0]
+			for _, v := range b.Values {
+				if v.Pure() && v.Uses == 0 {
+					for _, arg := range v.Args {
+						arg.Uses--
+					}
+					changed = true
+					continue
+				}
+				values = append(values, v)
+			}
+			b.Values = values
+		}
+	}
+	return nil
+}
+
+// Discard returns a pass that removes the stores into slots that no value of f
+// loads. The slots must die with the code, as the compiler's temporaries do,
+// since a later run cannot read what was not stored.
+func Discard(slots ...uint64) Pass {
+	return func(f *Function) error {
+		if len(slots) == 0 {
+			return nil
+		}
+
+		loaded := map[uint64]bool{}
+		for _, b := range f.Blocks {
+			for _, v := range b.Values {
+				if v.Op == bytecode.SLTLOAD {
+					loaded[v.Aux[0]] = true
+				}
+			}
+		}
+
+		for _, b := range f.Blocks {
+			values := b.Values[:0]
+			for _, v := range b.Values {
+				if v.Op == bytecode.SLTSTORE && !loaded[v.Aux[0]] && slices.Contains(slots, v.Aux[0]) {
+					continue
+				}
+				values = append(values, v)
+			}
+			b.Values = values
+		}
+		return nil
+	}
+}
@@ -1,33 +1,79 @@
 package lexer
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"iter"
+	"slices"
 	"strings"
 	"unicode"
+	"unicode/utf8"
+	"unsafe"
 
 	"github.com/siyul-park/minijs/internal/token"
 )
 
 type Lexer struct {
-	source io.Reader
-	buf    []rune
-	pos    int
-	line   int
-	column int
+	source     io.Reader
+	buf        []byte
+	shared     bool
+	base       int
+	first      int
+	pos        int
+	line       int
+	column     int
+	comments   bool
+	whitespace bool
+	braces     []int
+	prev       token.Type
 }
 
-func New(source io.Reader) *Lexer {
-	return &Lexer{
+type Option func(*Lexer)
+
+const (
+	separatorError = "numeric separators are only allowed between digits"
+	chunkSize      = 4096
+)
+
+func WithComments(enabled bool) Option {
+	return func(l *Lexer) {
+		l.comments = enabled
+	}
+}
+
+func WithWhitespace(enabled bool) Option {
+	return func(l *Lexer) {
+		l.whitespace = enabled
+	}
+}
+
+func New(source io.Reader, opts ...Option) *Lexer {
+	l := &Lexer{
 		source: source,
+		first:  1,
 		line:   1,
 		column: 1,
 	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// NewBytes lexes src in place: token literals alias src, so it must not be modified afterwards.
+func NewBytes(src []byte, opts ...Option) *Lexer {
+	l := New(nil, opts...)
+	l.buf = src
+	l.shared = true
+	return l
 }
 
 func (l *Lexer) Next() token.Token {
 	l.hidden()
 
+	start := l.position()
+
 	var tk token.Token
 	switch ch := l.peek(0); ch {
 	case rune(0):
@@ -42,10 +88,25 @@ func (l *Lexer) Next() token.Token {
 		tk = token.New(token.OPEN_PAREN, l.read(1))
 	case ')':
 		tk = token.New(token.CLOSE_PAREN, l.read(1))
+	case '`':
+		l.pop()
+		tk = l.template(token.TEMPLATE, token.TEMPLATE_HEAD)
 	case '{':
+		if len(l.braces) > 0 {
+			l.braces[len(l.braces)-1]++
+		}
 		tk = token.New(token.OPEN_BRACE, l.read(1))
 	case '}':
-		tk = token.New(token.CLOSE_BRACE, l.read(1))
+		if n := len(l.braces); n > 0 && l.braces[n-1] == 0 {
+			l.braces = l.braces[:n-1]
+			l.pop()
+			tk = l.template(token.TEMPLATE_TAIL, token.TEMPLATE_MIDDLE)
+		} else {
+			if n > 0 {
+				l.braces[n-1]--
+			}
+			tk = token.New(token.CLOSE_BRACE, l.read(1))
+		}
 	case ';':
 		tk = token.New(token.SEMICOLON, l.read(1))
 	case ',':
@@ -57,6 +118,8 @@ func (l *Lexer) Next() token.Token {
 			} else {
 				tk = token.New(token.EQUAL, l.read(2))
 			}
+		} else if l.peek(1) == '>' {
+			tk = token.New(token.ARROW, l.read(2))
 		} else {
 			tk = token.New(token.ASSIGN, l.read(1))
 		}
@@ -65,7 +128,11 @@ func (l *Lexer) Next() token.Token {
 	case ':':
 		tk = token.New(token.COLON, l.read(1))
 	case '.':
-		tk = token.New(token.DOT, l.read(1))
+		if decimal(l.peek(1)) {
+			tk = l.number()
+		} else {
+			tk = token.New(token.DOT, l.read(1))
+		}
 	case '~':
 		tk = token.New(token.BIT_NOT, l.read(1))
 	case '!':
@@ -101,7 +168,11 @@ func (l *Lexer) Next() token.Token {
 			tk = token.New(token.MULTIPLY, l.read(1))
 		}
 	case '/':
-		if l.peek(1) == '=' {
+		if l.isComment() {
+			tk = l.comment()
+		} else if l.regexp() {
+			tk = l.regexpLiteral()
+		} else if l.peek(1) == '=' {
 			tk = token.New(token.DIVIDE_ASSIGN, l.read(2))
 		} else {
 			tk = token.New(token.DIVIDE, l.read(1))
@@ -131,6 +202,8 @@ func (l *Lexer) Next() token.Token {
 	case '^':
 		if l.peek(1) == '=' {
 			tk = token.New(token.BIT_XOR_ASSIGN, l.read(2))
+		} else {
+			tk = l.syntaxError(fmt.Sprintf("unexpected character %q", l.read(1)))
 		}
 	case '<':
 		if l.peek(1) == '=' {
@@ -164,213 +237,412 @@ func (l *Lexer) Next() token.Token {
 		}
 	default:
 		if unicode.IsLetter(ch) || ch == '_' || ch == '$' {
-			return l.identifier()
-		} else if unicode.IsDigit(ch) {
+			tk = l.identifier()
+		} else if decimal(ch) {
 			tk = l.number()
+		} else if whitespace(ch) || newline(ch) {
+			tk = l.space()
 		} else {
-			tk = token.New(token.ILLEGAL, l.read(1))
+			tk = l.syntaxError(fmt.Sprintf("unexpected character %q", l.read(1)))
 		}
 	}
 
+	tk.Start = start
+	tk.End = l.position()
+	if tk.Type != token.COMMENT && tk.Type != token.WHITESPACE {
+		l.prev = tk.Type
+	}
 	return tk
 }
 
-func (l *Lexer) number() token.Token {
-	ch := l.peek(0)
-	if ch == '0' && (l.peek(1) == 'x' || l.peek(1) == 'X') {
-		return l.hexInteger()
+func (l *Lexer) Tokens() iter.Seq[token.Token] {
+	return func(yield func(token.Token) bool) {
+		for {
+			tk := l.Next()
+			if tk.Type == token.EOF || !yield(tk) {
+				return
+			}
+		}
 	}
-	if ch == '0' && (l.peek(1) == 'b' || l.peek(1) == 'B') {
-		return l.binaryInteger()
+}
+
+func (l *Lexer) Line(n int) string {
+	start, ok := l.lineStart(n)
+	if !ok {
+		return ""
 	}
-	if ch == '0' {
-		return l.octalInteger()
+
+	end := start
+	for ch, size := l.rune(end); size > 0 && !newline(ch); ch, size = l.rune(end) {
+		end += size
 	}
-	if ch == '.' || unicode.IsDigit(ch) {
-		return l.decimal()
+	return strings.TrimPrefix(string(l.buf[start:end]), "\uFEFF")
+}
+
+// Release drops the buffered source before the line holding pos, keeping memory
+// bounded while lexing a long stream. Line returns "" for released lines.
+func (l *Lexer) Release(pos token.Pos) {
+	if l.shared || pos.Line <= l.first || pos.Offset-l.base > l.pos {
+		return
 	}
-	return l.syntaxError("invalid number format")
+	start, ok := l.lineStart(pos.Line)
+	if !ok || start > l.pos {
+		return
+	}
+
+	l.buf = l.buf[:copy(l.buf, l.buf[start:])]
+	l.pos -= start
+	l.base += start
+	l.first = pos.Line
 }
 
-func (l *Lexer) decimal() token.Token {
-	var builder strings.Builder
+func (l *Lexer) lineStart(n int) (int, bool) {
+	if n < l.first {
+		return 0, false
+	}
+	start := 0
+	for line := l.first; line < n; {
+		ch, size := l.rune(start)
+		if size == 0 {
+			return 0, false
+		}
+		start += size
+		if next, _ := l.rune(start); ch == '\r' && next == '\n' {
+			continue
+		}
+		if newline(ch) {
+			line++
+		}
+	}
+	return start, true
+}
+
+func (l *Lexer) number() token.Token {
+	if l.peek(0) == '0' {
+		switch l.peek(1) {
+		case 'x', 'X':
+			return l.integer("hexadecimal", func(ch rune) bool {
+				return decimal(ch) || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+			})
+		case 'o', 'O':
+			return l.integer("octal", func(ch rune) bool {
+				return ch >= '0' && ch <= '7'
+			})
+		case 'b', 'B':
+			return l.integer("binary", func(ch rune) bool {
+				return ch == '0' || ch == '1'
+			})
+		}
+		if decimal(l.peek(1)) || l.peek(1) == '_' {
+			return l.legacy()
+		}
+	}
+	return l.decimal()
+}
 
-	for unicode.IsDigit(l.peek(0)) {
-		builder.WriteRune(l.pop())
+func (l *Lexer) decimal() token.Token {
+	start := l.pos
+	if !l.digits(decimal) {
+		return l.syntaxError(separatorError)
 	}
+	return l.fraction(start)
+}
 
+func (l *Lexer) fraction(start int) token.Token {
 	if l.peek(0) == '.' {
-		builder.WriteRune(l.pop())
-		if !unicode.IsDigit(l.peek(0)) {
+		l.pop()
+		if l.pos-start == 1 && !decimal(l.peek(0)) {
 			return l.syntaxError("invalid decimal number: missing digits after decimal point")
 		}
-	}
-
-	for unicode.IsDigit(l.peek(0)) {
-		builder.WriteRune(l.pop())
+		if !l.digits(decimal) {
+			return l.syntaxError(separatorError)
+		}
 	}
 
 	if l.peek(0) == 'e' || l.peek(0) == 'E' {
-		builder.WriteRune(l.pop())
+		l.pop()
 		if l.peek(0) == '+' || l.peek(0) == '-' {
-			builder.WriteRune(l.pop())
+			l.pop()
 		}
-		if !unicode.IsDigit(l.peek(0)) {
+		if !decimal(l.peek(0)) {
 			return l.syntaxError("invalid exponent in number")
 		}
-		for unicode.IsDigit(l.peek(0)) {
-			builder.WriteRune(l.pop())
+		if !l.digits(decimal) {
+			return l.syntaxError(separatorError)
 		}
 	}
-
-	literal := builder.String()
-	return token.New(token.NUMBER, literal)
+	return l.numeric(start, "decimal")
 }
 
-func (l *Lexer) hexInteger() token.Token {
-	var builder strings.Builder
-
-	builder.WriteRune(l.pop())
-	builder.WriteRune(l.pop())
+func (l *Lexer) integer(name string, valid func(rune) bool) token.Token {
+	start := l.pos
+	l.pop()
+	l.pop()
 
-	for ch := l.peek(0); (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F'); ch = l.peek(0) {
-		builder.WriteRune(l.pop())
+	if ch := l.peek(0); !valid(ch) {
+		l.pop()
+		if ch == '_' {
+			return l.syntaxError(separatorError)
+		}
+		if decimal(ch) || unicode.IsLetter(ch) {
+			return l.syntaxError(fmt.Sprintf("invalid digit %q in %s literal", ch, name))
+		}
+		return l.syntaxError(fmt.Sprintf("invalid %s literal: missing digits", name))
 	}
-
-	literal := builder.String()
-	return token.New(token.NUMBER, literal)
+	if !l.digits(valid) {
+		return l.syntaxError(separatorError)
+	}
+	return l.numeric(start, name)
 }
 
-func (l *Lexer) binaryInteger() token.Token {
-	var builder strings.Builder
-
-	builder.WriteRune(l.pop())
-	builder.WriteRune(l.pop())
-
-	for l.peek(0) == '0' || l.peek(0) == '1' {
-		builder.WriteRune(l.pop())
+func (l *Lexer) legacy() token.Token {
+	start := l.pos
+	octal := true
+	for ch := l.peek(0); decimal(ch) || ch == '_'; ch = l.peek(0) {
+		if ch == '_' {
+			l.pop()
+			return l.syntaxError(separatorError)
+		}
+		octal = octal && ch <= '7'
+		l.pop()
 	}
-
-	if builder.Len() == 0 {
-		return l.syntaxError("invalid binary literal: no digits")
+	if octal {
+		return l.numeric(start, "octal")
 	}
-
-	literal := builder.String()
-	return token.New(token.NUMBER, literal)
+	return l.fraction(start)
 }
 
-func (l *Lexer) octalInteger() token.Token {
-	var builder strings.Builder
-
-	builder.WriteRune(l.pop())
-	if l.peek(0) == 'o' || l.peek(0) == 'O' {
-		builder.WriteRune(l.pop())
+func (l *Lexer) numeric(start int, name string) token.Token {
+	ch := l.peek(0)
+	if decimal(ch) {
+		l.pop()
+		return l.syntaxError(fmt.Sprintf("invalid digit %q in %s literal", ch, name))
 	}
-
-	for ch := l.peek(0); ch >= '0' && ch <= '7'; ch = l.peek(0) {
-		builder.WriteRune(l.pop())
+	if unicode.IsLetter(ch) || ch == '_' || ch == '$' {
+		return l.syntaxError("identifier starts immediately after numeric literal")
 	}
+	return token.New(token.NUMBER, l.slice(start, l.pos))
+}
 
-	literal := builder.String()
-	return token.New(token.NUMBER, literal)
+func (l *Lexer) digits(valid func(rune) bool) bool {
+	prev := rune(0)
+	for {
+		ch := l.peek(0)
+		if ch == '_' {
+			if prev == rune(0) || !valid(l.peek(1)) {
+				l.pop()
+				return false
+			}
+		} else if !valid(ch) {
+			return true
+		}
+		l.pop()
+		prev = ch
+	}
 }
 
 func (l *Lexer) string() token.Token {
 	quote := l.pop()
 
-	var builder strings.Builder
+	start := l.pos
+	var builder *strings.Builder
 	for {
 		ch := l.peek(0)
-		if ch == rune(0) {
+		if ch == rune(0) || ch == '\r' || ch == '\n' {
 			return l.syntaxError("unterminated string literal")
 		}
 		if ch == quote {
+			end := l.pos
 			l.pop()
-			break
+			if builder == nil {
+				return token.New(token.STRING, l.slice(start, end))
+			}
+			return token.New(token.STRING, builder.String())
 		}
 		if ch == '\\' {
+			builder = l.unescape(builder, start)
 			l.pop()
-			ch = l.peek(0)
-			switch ch {
-			case 'n':
-				builder.WriteRune('\n')
-			case 'r':
-				builder.WriteRune('\r')
-			case 't':
-				builder.WriteRune('\t')
-			case '\\':
-				builder.WriteRune('\\')
-			case '"':
-				builder.WriteRune('"')
-			case '\'':
-				builder.WriteRune('\'')
-			default:
-				builder.WriteRune(ch)
-			}
+			l.escape(builder)
+		} else if builder != nil {
+			builder.WriteRune(l.pop())
+		} else {
 			l.pop()
-		} else if ch == '\r' || ch == '\n' {
-			if l.peek(1) == '\\' {
-				l.pop()
+		}
+	}
+}
+
+func (l *Lexer) template(end, open token.Type) token.Token {
+	start := l.pos
+	var builder *strings.Builder
+	literal := func() string {
+		if builder == nil {
+			return l.slice(start, l.pos)
+		}
+		return builder.String()
+	}
+	for {
+		ch := l.peek(0)
+		switch {
+		case ch == rune(0):
+			return l.syntaxError("unterminated template literal")
+		case ch == '`':
+			tk := token.New(end, literal())
+			l.pop()
+			return tk
+		case ch == '$' && l.peek(1) == '{':
+			tk := token.New(open, literal())
+			l.pop()
+			l.pop()
+			l.braces = append(l.braces, 0)
+			return tk
+		case ch == '\\':
+			builder = l.unescape(builder, start)
+			l.pop()
+			l.escape(builder)
+		case ch == '\r':
+			builder = l.unescape(builder, start)
+			l.pop()
+			if l.peek(0) == '\n' {
 				l.pop()
-				continue
-			} else {
-				builder.WriteRune(ch)
 			}
-		} else {
+			builder.WriteRune('\n')
+		case builder != nil:
 			builder.WriteRune(l.pop())
+		default:
+			l.pop()
 		}
 	}
+}
 
-	literal := builder.String()
-	return token.New(token.STRING, literal)
+func (l *Lexer) regexp() bool {
+	switch l.prev {
+	case token.IDENTIFIER, token.NUMBER, token.STRING, token.TEMPLATE, token.TEMPLATE_TAIL,
+		token.CLOSE_PAREN, token.CLOSE_BRACKET, token.CLOSE_BRACE,
+		token.NULL, token.UNDEFINED, token.TRUE, token.FALSE, token.THIS,
+		token.PLUS_PLUS, token.MINUS_MINUS:
+		return false
+	default:
+		return true
+	}
 }
 
-func (l *Lexer) identifier() token.Token {
-	var builder strings.Builder
+func (l *Lexer) regexpLiteral() token.Token {
+	start := l.pos
+	l.pop()
+
+	class := false
+	for {
+		ch := l.peek(0)
+		if ch == rune(0) || newline(ch) {
+			return l.syntaxError("unterminated regular expression literal")
+		}
+		l.pop()
+		if ch == '\\' {
+			if next := l.peek(0); next == rune(0) || newline(next) {
+				return l.syntaxError("unterminated regular expression literal")
+			}
+			l.pop()
+		} else if ch == '[' {
+			class = true
+		} else if ch == ']' {
+			class = false
+		} else if ch == '/' && !class {
+			break
+		}
+	}
+
+	flags := l.pos
+	for ch := l.peek(0); unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_' || ch == '$'; ch = l.peek(0) {
+		if !strings.ContainsRune("dgimsuyv", ch) || bytes.ContainsRune(l.buf[flags:l.pos], ch) {
+			l.pop()
+			return l.syntaxError(fmt.Sprintf("invalid regular expression flag %q", ch))
+		}
+		l.pop()
+	}
+	return token.New(token.REGEXP, l.slice(start, l.pos))
+}
+
+func (l *Lexer) escape(builder *strings.Builder) {
+	ch := l.peek(0)
+	l.pop()
+
+	switch ch {
+	case 'n':
+		builder.WriteRune('\n')
+	case 'r':
+		builder.WriteRune('\r')
+	case 't':
+		builder.WriteRune('\t')
+	case '\r':
+		if l.peek(0) == '\n' {
+			l.pop()
+		}
+	case '\n', '\u2028', '\u2029':
+	default:
+		builder.WriteRune(ch)
+	}
+}
+
+func (l *Lexer) unescape(builder *strings.Builder, start int) *strings.Builder {
+	if builder == nil {
+		builder = &strings.Builder{}
+		builder.Write(l.buf[start:l.pos])
+	}
+	return builder
+}
 
-	builder.WriteRune(l.pop())
+func (l *Lexer) identifier() token.Token {
+	start := l.pos
+	l.pop()
 
 	for {
 		ch := l.peek(0)
 		if !unicode.IsLetter(ch) && !unicode.IsDigit(ch) && ch != '_' && ch != '$' {
 			break
 		}
-		builder.WriteRune(l.pop())
+		l.pop()
 	}
 
-	literal := builder.String()
+	literal := l.slice(start, l.pos)
 	return token.New(token.TypeOf(literal), literal)
 }
 
 func (l *Lexer) hidden() {
-	l.space()
-	l.comment()
+	if l.pos == 0 && l.peek(0) == '\uFEFF' {
+		l.pos += utf8.RuneLen('\uFEFF')
+	}
+	for {
+		if !l.whitespace {
+			l.space()
+		}
+		if l.comments || !l.isComment() {
+			return
+		}
+		l.comment()
+	}
 }
 
-func (l *Lexer) space() {
-	for unicode.IsSpace(l.peek(0)) {
+func (l *Lexer) space() token.Token {
+	start := l.pos
+	for ch := l.peek(0); whitespace(ch) || newline(ch); ch = l.peek(0) {
 		l.pop()
 	}
+	return token.New(token.WHITESPACE, l.slice(start, l.pos))
 }
 
-func (l *Lexer) comment() {
-	for {
-		ch := l.peek(0)
-		if ch == '/' {
-			if l.peek(1) == '*' {
-				l.multiLineComment()
-			} else if l.peek(1) == '/' {
-				l.singleLineComment()
-			} else {
-				break
-			}
-		} else {
-			break
-		}
+func (l *Lexer) isComment() bool {
+	return l.peek(0) == '/' && (l.peek(1) == '/' || l.peek(1) == '*')
+}
+
+func (l *Lexer) comment() token.Token {
+	if l.peek(1) == '*' {
+		return l.multiLineComment()
 	}
+	return l.singleLineComment()
 }
 
-func (l *Lexer) multiLineComment() {
+func (l *Lexer) multiLineComment() token.Token {
+	start := l.pos
 	l.pop()
 	l.pop()
 
@@ -386,47 +658,63 @@ func (l *Lexer) multiLineComment() {
 		}
 		l.pop()
 	}
+	return token.New(token.COMMENT, l.slice(start, l.pos))
 }
 
-func (l *Lexer) singleLineComment() {
+func (l *Lexer) singleLineComment() token.Token {
+	start := l.pos
 	l.pop()
 	l.pop()
 
 	for {
 		ch := l.peek(0)
-		if ch == '\n' || ch == '\r' || ch == rune(0) {
+		if newline(ch) || ch == rune(0) {
 			break
 		}
 		l.pop()
 	}
+	return token.New(token.COMMENT, l.slice(start, l.pos))
 }
 
 func (l *Lexer) read(n int) string {
-	var result []rune
+	start := l.pos
 	for i := 0; i < n; i++ {
-		result = append(result, l.pop())
+		l.pop()
 	}
-	return string(result)
+	return l.slice(start, l.pos)
+}
+
+func (l *Lexer) slice(start, end int) string {
+	if start == end {
+		return ""
+	}
+	if l.shared {
+		return unsafe.String(&l.buf[start], end-start)
+	}
+	return string(l.buf[start:end])
 }
 
 func (l *Lexer) peek(offset int) rune {
-	if len(l.buf) <= l.pos+offset {
-		if l.fetch(offset) == rune(0) {
+	i := l.pos
+	for ; offset > 0; offset-- {
+		_, size := l.rune(i)
+		if size == 0 {
 			return rune(0)
 		}
+		i += size
 	}
-	return l.buf[l.pos+offset]
+	ch, _ := l.rune(i)
+	return ch
 }
 
 func (l *Lexer) pop() rune {
-	if l.pos >= len(l.buf) {
-		return 0
+	ch, size := l.rune(l.pos)
+	if size == 0 {
+		return rune(0)
 	}
+	l.pos += size
 
-	ch := l.buf[l.pos]
-	l.pos++
-
-	if ch == '\n' {
+	if newline(ch) && (ch != '\r' || l.peek(0) != '\n') {
 		l.line++
 		l.column = 1
 	} else {
@@ -435,20 +723,54 @@ func (l *Lexer) pop() rune {
 	return ch
 }
 
-func (l *Lexer) fetch(offset int) rune {
-	for len(l.buf) == 0 || l.pos+offset >= len(l.buf) {
-		var ch rune
-		_, err := fmt.Fscanf(l.source, "%c", &ch)
-		if err == io.EOF {
-			return rune(0)
-		} else if err != nil {
-			return rune(0)
+func (l *Lexer) rune(i int) (rune, int) {
+	if i >= len(l.buf) {
+		l.fetch(i + 1)
+		if i >= len(l.buf) {
+			return rune(0), 0
 		}
-		l.buf = append(l.buf, ch)
 	}
-	return l.buf[l.pos]
+	if ch := l.buf[i]; ch < utf8.RuneSelf {
+		return rune(ch), 1
+	}
+	if !utf8.FullRune(l.buf[i:]) {
+		l.fetch(i + utf8.UTFMax)
+	}
+	return utf8.DecodeRune(l.buf[i:])
+}
+
+func (l *Lexer) fetch(n int) {
+	for len(l.buf) < n && l.source != nil {
+		l.buf = slices.Grow(l.buf, chunkSize)
+		m, err := l.source.Read(l.buf[len(l.buf):cap(l.buf)])
+		l.buf = l.buf[:len(l.buf)+m]
+		if err != nil {
+			l.source = nil
+		}
+	}
+}
+
+func (l *Lexer) position() token.Pos {
+	return token.Pos{Line: l.line, Column: l.column, Offset: l.base + l.pos}
 }
 
 func (l *Lexer) syntaxError(message string) token.Token {
-	return token.New(token.ILLEGAL, fmt.Sprintf("syntax error at line %d, column %d: %s", l.line, l.column, message))
+	return token.New(token.ILLEGAL, message)
+}
+
+func whitespace(ch rune) bool {
+	switch ch {
+	case '\t', '\v', '\f', ' ', '\u00A0', '\uFEFF':
+		return true
+	default:
+		return unicode.Is(unicode.Zs, ch)
+	}
+}
+
+func decimal(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func newline(ch rune) bool {
+	return ch == '\n' || ch == '\r' || ch == '\u2028' || ch == '\u2029'
 }
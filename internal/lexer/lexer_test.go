@@ -3,6 +3,7 @@ package lexer
 import (
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/siyul-park/minijs/internal/token"
 
@@ -23,6 +24,30 @@ func TestLexer_Next(t *testing.T) {
 		{source: `0o01`, tokens: []token.Token{token.New(token.NUMBER, "0o01")}},
 		{source: `01`, tokens: []token.Token{token.New(token.NUMBER, "01")}},
 		{source: `0b01`, tokens: []token.Token{token.New(token.NUMBER, "0b01")}},
+		{source: `1_000_000`, tokens: []token.Token{token.New(token.NUMBER, "1_000_000")}},
+		{source: `1_0.2_5e1_0`, tokens: []token.Token{token.New(token.NUMBER, "1_0.2_5e1_0")}},
+		{source: `0xFF_FF`, tokens: []token.Token{token.New(token.NUMBER, "0xFF_FF")}},
+		{source: `0o7_7`, tokens: []token.Token{token.New(token.NUMBER, "0o7_7")}},
+		{source: `0b1_0`, tokens: []token.Token{token.New(token.NUMBER, "0b1_0")}},
+		{source: `1__0`, tokens: []token.Token{token.New(token.ILLEGAL, separatorError)}},
+		{source: `1_`, tokens: []token.Token{token.New(token.ILLEGAL, separatorError)}},
+		{source: `1_.5`, tokens: []token.Token{token.New(token.ILLEGAL, separatorError)}},
+		{source: `0x_1`, tokens: []token.Token{token.New(token.ILLEGAL, separatorError)}},
+		{source: `0_1`, tokens: []token.Token{token.New(token.ILLEGAL, separatorError)}},
+		{source: `0.5`, tokens: []token.Token{token.New(token.NUMBER, "0.5")}},
+		{source: `.5`, tokens: []token.Token{token.New(token.NUMBER, ".5")}},
+		{source: `1.`, tokens: []token.Token{token.New(token.NUMBER, "1.")}},
+		{source: `1e+10`, tokens: []token.Token{token.New(token.NUMBER, "1e+10")}},
+		{source: `1E-3`, tokens: []token.Token{token.New(token.NUMBER, "1E-3")}},
+		{source: `0123`, tokens: []token.Token{token.New(token.NUMBER, "0123")}},
+		{source: `089.5`, tokens: []token.Token{token.New(token.NUMBER, "089.5")}},
+		{source: `0X1f`, tokens: []token.Token{token.New(token.NUMBER, "0X1f")}},
+		{source: `0x`, tokens: []token.Token{token.New(token.ILLEGAL, "invalid hexadecimal literal: missing digits")}},
+		{source: `0b12`, tokens: []token.Token{token.New(token.ILLEGAL, "invalid digit '2' in binary literal")}},
+		{source: `0o8`, tokens: []token.Token{token.New(token.ILLEGAL, "invalid digit '8' in octal literal")}},
+		{source: `0xG`, tokens: []token.Token{token.New(token.ILLEGAL, "invalid digit 'G' in hexadecimal literal")}},
+		{source: `1e`, tokens: []token.Token{token.New(token.ILLEGAL, "invalid exponent in number")}},
+		{source: `3in`, tokens: []token.Token{token.New(token.ILLEGAL, "identifier starts immediately after numeric literal")}},
 
 		{source: `"foo"`, tokens: []token.Token{token.New(token.STRING, "foo")}},
 		{source: `'foo''`, tokens: []token.Token{token.New(token.STRING, "foo")}},
@@ -68,6 +93,7 @@ func TestLexer_Next(t *testing.T) {
 		{source: `;`, tokens: []token.Token{token.New(token.SEMICOLON, ";")}},
 		{source: `,`, tokens: []token.Token{token.New(token.COMMA, ",")}},
 		{source: `=`, tokens: []token.Token{token.New(token.ASSIGN, "=")}},
+		{source: `=>`, tokens: []token.Token{token.New(token.ARROW, "=>")}},
 		{source: `?`, tokens: []token.Token{token.New(token.QUESTION, "?")}},
 		{source: `:`, tokens: []token.Token{token.New(token.COLON, ":")}},
 		{source: `.`, tokens: []token.Token{token.New(token.DOT, ".")}},
@@ -78,7 +104,7 @@ func TestLexer_Next(t *testing.T) {
 		{source: `~`, tokens: []token.Token{token.New(token.BIT_NOT, "~")}},
 		{source: `!`, tokens: []token.Token{token.New(token.NOT, "!")}},
 		{source: `*`, tokens: []token.Token{token.New(token.MULTIPLY, "*")}},
-		{source: `/`, tokens: []token.Token{token.New(token.DIVIDE, "/")}},
+		{source: `a /`, tokens: []token.Token{token.New(token.IDENTIFIER, "a"), token.New(token.DIVIDE, "/")}},
 		{source: `%`, tokens: []token.Token{token.New(token.MODULUS, "%")}},
 		{source: `>>`, tokens: []token.Token{token.New(token.RIGHT_SHIFT_ARITHMETIC, ">>")}},
 		{source: `<<`, tokens: []token.Token{token.New(token.LEFT_SHIFT_ARITHMETIC, "<<")}},
@@ -96,7 +122,7 @@ func TestLexer_Next(t *testing.T) {
 		{source: `&&`, tokens: []token.Token{token.New(token.AND, "&&")}},
 		{source: `||`, tokens: []token.Token{token.New(token.OR, "||")}},
 		{source: `*=`, tokens: []token.Token{token.New(token.MULTIPLY_ASSIGN, "*=")}},
-		{source: `/=`, tokens: []token.Token{token.New(token.DIVIDE_ASSIGN, "/=")}},
+		{source: `a /=`, tokens: []token.Token{token.New(token.IDENTIFIER, "a"), token.New(token.DIVIDE_ASSIGN, "/=")}},
 		{source: `%=`, tokens: []token.Token{token.New(token.MODULUS_ASSIGN, "%=")}},
 		{source: `+=`, tokens: []token.Token{token.New(token.PLUS_ASSIGN, "+=")}},
 		{source: `-=`, tokens: []token.Token{token.New(token.MINUS_ASSIGN, "-=")}},
@@ -113,8 +139,516 @@ func TestLexer_Next(t *testing.T) {
 			l := New(strings.NewReader(tt.source))
 			for _, expect := range tt.tokens {
 				actual := l.Next()
-				assert.Equal(t, expect, actual)
+				assert.Equal(t, expect, token.New(actual.Type, actual.Literal))
 			}
 		})
 	}
 }
+
+func TestLexer_Next_Position(t *testing.T) {
+	l := New(strings.NewReader("foo = 1;\n  \"bar\""))
+
+	tests := []struct {
+		start token.Pos
+		end   token.Pos
+	}{
+		{start: token.Pos{Line: 1, Column: 1, Offset: 0}, end: token.Pos{Line: 1, Column: 4, Offset: 3}},
+		{start: token.Pos{Line: 1, Column: 5, Offset: 4}, end: token.Pos{Line: 1, Column: 6, Offset: 5}},
+		{start: token.Pos{Line: 1, Column: 7, Offset: 6}, end: token.Pos{Line: 1, Column: 8, Offset: 7}},
+		{start: token.Pos{Line: 1, Column: 8, Offset: 7}, end: token.Pos{Line: 1, Column: 9, Offset: 8}},
+		{start: token.Pos{Line: 2, Column: 3, Offset: 11}, end: token.Pos{Line: 2, Column: 8, Offset: 16}},
+	}
+
+	for _, tt := range tests {
+		tk := l.Next()
+		assert.Equal(t, tt.start, tk.Start, tk.Literal)
+		assert.Equal(t, tt.end, tk.End, tk.Literal)
+	}
+}
+
+func TestLexer_Next_Offset(t *testing.T) {
+	l := New(strings.NewReader("'é' + 1"))
+
+	tests := []struct {
+		start token.Pos
+		end   token.Pos
+	}{
+		{start: token.Pos{Line: 1, Column: 1, Offset: 0}, end: token.Pos{Line: 1, Column: 4, Offset: 4}},
+		{start: token.Pos{Line: 1, Column: 5, Offset: 5}, end: token.Pos{Line: 1, Column: 6, Offset: 6}},
+		{start: token.Pos{Line: 1, Column: 7, Offset: 7}, end: token.Pos{Line: 1, Column: 8, Offset: 8}},
+	}
+
+	for _, tt := range tests {
+		tk := l.Next()
+		assert.Equal(t, tt.start, tk.Start, tk.Literal)
+		assert.Equal(t, tt.end, tk.End, tk.Literal)
+	}
+}
+
+func TestLexer_Line(t *testing.T) {
+	l := New(strings.NewReader("\uFEFFfoo = 1;\r\nbar = 2;\nbaz\u2028qux"))
+
+	l.Next()
+	assert.Equal(t, "foo = 1;", l.Line(1))
+	assert.Equal(t, "bar = 2;", l.Line(2))
+	assert.Equal(t, "baz", l.Line(3))
+	assert.Equal(t, "qux", l.Line(4))
+	assert.Equal(t, "", l.Line(5))
+}
+
+func TestLexer_Next_Comments(t *testing.T) {
+	tests := []struct {
+		source string
+		tokens []token.Token
+	}{
+		{
+			source: "1 /* a */ + // b\n2",
+			tokens: []token.Token{
+				token.New(token.NUMBER, "1"),
+				token.New(token.COMMENT, "/* a */"),
+				token.New(token.PLUS, "+"),
+				token.New(token.COMMENT, "// b"),
+				token.New(token.NUMBER, "2"),
+				token.New(token.EOF, ""),
+			},
+		},
+		{
+			source: "a / b",
+			tokens: []token.Token{
+				token.New(token.IDENTIFIER, "a"),
+				token.New(token.DIVIDE, "/"),
+				token.New(token.IDENTIFIER, "b"),
+				token.New(token.EOF, ""),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			l := New(strings.NewReader(tt.source), WithComments(true))
+			for _, expect := range tt.tokens {
+				actual := l.Next()
+				assert.Equal(t, expect, token.New(actual.Type, actual.Literal))
+			}
+		})
+	}
+}
+
+func TestLexer_Next_Template(t *testing.T) {
+	tests := []struct {
+		source string
+		tokens []token.Token
+	}{
+		{
+			source: "`foo`",
+			tokens: []token.Token{
+				token.New(token.TEMPLATE, "foo"),
+				token.New(token.EOF, ""),
+			},
+		},
+		{
+			source: "`a${b}c${d + 1}e`",
+			tokens: []token.Token{
+				token.New(token.TEMPLATE_HEAD, "a"),
+				token.New(token.IDENTIFIER, "b"),
+				token.New(token.TEMPLATE_MIDDLE, "c"),
+				token.New(token.IDENTIFIER, "d"),
+				token.New(token.PLUS, "+"),
+				token.New(token.NUMBER, "1"),
+				token.New(token.TEMPLATE_TAIL, "e"),
+				token.New(token.EOF, ""),
+			},
+		},
+		{
+			source: "`${ function() { return `x${y}` } }`",
+			tokens: []token.Token{
+				token.New(token.TEMPLATE_HEAD, ""),
+				token.New(token.FUNCTION, "function"),
+				token.New(token.OPEN_PAREN, "("),
+				token.New(token.CLOSE_PAREN, ")"),
+				token.New(token.OPEN_BRACE, "{"),
+				token.New(token.RETURN, "return"),
+				token.New(token.TEMPLATE_HEAD, "x"),
+				token.New(token.IDENTIFIER, "y"),
+				token.New(token.TEMPLATE_TAIL, ""),
+				token.New(token.CLOSE_BRACE, "}"),
+				token.New(token.TEMPLATE_TAIL, ""),
+				token.New(token.EOF, ""),
+			},
+		},
+		{
+			source: "`a\\`\\${b}\\n\r\nc`",
+			tokens: []token.Token{
+				token.New(token.TEMPLATE, "a`${b}\n\nc"),
+				token.New(token.EOF, ""),
+			},
+		},
+		{
+			source: "`foo",
+			tokens: []token.Token{
+				token.New(token.ILLEGAL, "unterminated template literal"),
+			},
+		},
+		{
+			source: "'foo\nbar'",
+			tokens: []token.Token{
+				token.New(token.ILLEGAL, "unterminated string literal"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			l := New(strings.NewReader(tt.source))
+			for _, expect := range tt.tokens {
+				actual := l.Next()
+				assert.Equal(t, expect, token.New(actual.Type, actual.Literal))
+			}
+		})
+	}
+}
+
+func TestLexer_Next_Regexp(t *testing.T) {
+	tests := []struct {
+		source string
+		tokens []token.Token
+	}{
+		{
+			source: "/ab+c/gi",
+			tokens: []token.Token{
+				token.New(token.REGEXP, "/ab+c/gi"),
+				token.New(token.EOF, ""),
+			},
+		},
+		{
+			source: "a = /[/]\\//.test(b)",
+			tokens: []token.Token{
+				token.New(token.IDENTIFIER, "a"),
+				token.New(token.ASSIGN, "="),
+				token.New(token.REGEXP, "/[/]\\//"),
+				token.New(token.DOT, "."),
+				token.New(token.IDENTIFIER, "test"),
+				token.New(token.OPEN_PAREN, "("),
+				token.New(token.IDENTIFIER, "b"),
+				token.New(token.CLOSE_PAREN, ")"),
+				token.New(token.EOF, ""),
+			},
+		},
+		{
+			source: "a / b / c",
+			tokens: []token.Token{
+				token.New(token.IDENTIFIER, "a"),
+				token.New(token.DIVIDE, "/"),
+				token.New(token.IDENTIFIER, "b"),
+				token.New(token.DIVIDE, "/"),
+				token.New(token.IDENTIFIER, "c"),
+				token.New(token.EOF, ""),
+			},
+		},
+		{
+			source: "(1) /* c */ /=2/ 1",
+			tokens: []token.Token{
+				token.New(token.OPEN_PAREN, "("),
+				token.New(token.NUMBER, "1"),
+				token.New(token.CLOSE_PAREN, ")"),
+				token.New(token.DIVIDE_ASSIGN, "/="),
+				token.New(token.NUMBER, "2"),
+				token.New(token.DIVIDE, "/"),
+				token.New(token.NUMBER, "1"),
+				token.New(token.EOF, ""),
+			},
+		},
+		{
+			source: "return /=/",
+			tokens: []token.Token{
+				token.New(token.RETURN, "return"),
+				token.New(token.REGEXP, "/=/"),
+				token.New(token.EOF, ""),
+			},
+		},
+		{
+			source: "/abc\\n/",
+			tokens: []token.Token{
+				token.New(token.REGEXP, "/abc\\n/"),
+			},
+		},
+		{
+			source: "/abc\n",
+			tokens: []token.Token{
+				token.New(token.ILLEGAL, "unterminated regular expression literal"),
+			},
+		},
+		{
+			source: "/a/gg",
+			tokens: []token.Token{
+				token.New(token.ILLEGAL, "invalid regular expression flag 'g'"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			l := New(strings.NewReader(tt.source), WithComments(true))
+			for _, expect := range tt.tokens {
+				actual := l.Next()
+				if actual.Type == token.COMMENT {
+					actual = l.Next()
+				}
+				assert.Equal(t, expect, token.New(actual.Type, actual.Literal))
+			}
+		})
+	}
+}
+
+func TestLexer_Next_Whitespace(t *testing.T) {
+	tests := []struct {
+		source string
+		tokens []token.Token
+	}{
+		{
+			source: "\uFEFFa",
+			tokens: []token.Token{
+				{Type: token.IDENTIFIER, Literal: "a", Start: token.Pos{Line: 1, Column: 1, Offset: 3}, End: token.Pos{Line: 1, Column: 2, Offset: 4}},
+			},
+		},
+		{
+			source: "a\r\nb\rc\u2028d\u2029e",
+			tokens: []token.Token{
+				{Type: token.IDENTIFIER, Literal: "a", Start: token.Pos{Line: 1, Column: 1, Offset: 0}, End: token.Pos{Line: 1, Column: 2, Offset: 1}},
+				{Type: token.IDENTIFIER, Literal: "b", Start: token.Pos{Line: 2, Column: 1, Offset: 3}, End: token.Pos{Line: 2, Column: 2, Offset: 4}},
+				{Type: token.IDENTIFIER, Literal: "c", Start: token.Pos{Line: 3, Column: 1, Offset: 5}, End: token.Pos{Line: 3, Column: 2, Offset: 6}},
+				{Type: token.IDENTIFIER, Literal: "d", Start: token.Pos{Line: 4, Column: 1, Offset: 9}, End: token.Pos{Line: 4, Column: 2, Offset: 10}},
+				{Type: token.IDENTIFIER, Literal: "e", Start: token.Pos{Line: 5, Column: 1, Offset: 13}, End: token.Pos{Line: 5, Column: 2, Offset: 14}},
+			},
+		},
+		{
+			source: "a\u00A0\u2003\u3000\v\fb",
+			tokens: []token.Token{
+				{Type: token.IDENTIFIER, Literal: "a", Start: token.Pos{Line: 1, Column: 1, Offset: 0}, End: token.Pos{Line: 1, Column: 2, Offset: 1}},
+				{Type: token.IDENTIFIER, Literal: "b", Start: token.Pos{Line: 1, Column: 7, Offset: 11}, End: token.Pos{Line: 1, Column: 8, Offset: 12}},
+			},
+		},
+		{
+			source: "// a\u2028b",
+			tokens: []token.Token{
+				{Type: token.IDENTIFIER, Literal: "b", Start: token.Pos{Line: 2, Column: 1, Offset: 7}, End: token.Pos{Line: 2, Column: 2, Offset: 8}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			l := New(strings.NewReader(tt.source))
+			for _, expect := range tt.tokens {
+				assert.Equal(t, expect, l.Next())
+			}
+		})
+	}
+}
+
+func TestLexer_Next_NextLine(t *testing.T) {
+	l := New(strings.NewReader("a\u0085"))
+	l.Next()
+	assert.Equal(t, token.ILLEGAL, l.Next().Type)
+}
+
+func TestLexer_Tokens(t *testing.T) {
+	tests := []struct {
+		source string
+		opts   []Option
+		tokens []token.Token
+	}{
+		{
+			source: "a = 1; // b",
+			tokens: []token.Token{
+				token.New(token.IDENTIFIER, "a"),
+				token.New(token.ASSIGN, "="),
+				token.New(token.NUMBER, "1"),
+				token.New(token.SEMICOLON, ";"),
+			},
+		},
+		{
+			source: "a = 1; // b",
+			opts:   []Option{WithComments(true), WithWhitespace(true)},
+			tokens: []token.Token{
+				token.New(token.IDENTIFIER, "a"),
+				token.New(token.WHITESPACE, " "),
+				token.New(token.ASSIGN, "="),
+				token.New(token.WHITESPACE, " "),
+				token.New(token.NUMBER, "1"),
+				token.New(token.SEMICOLON, ";"),
+				token.New(token.WHITESPACE, " "),
+				token.New(token.COMMENT, "// b"),
+			},
+		},
+		{
+			source: "a /* b */\n/ c",
+			opts:   []Option{WithWhitespace(true)},
+			tokens: []token.Token{
+				token.New(token.IDENTIFIER, "a"),
+				token.New(token.WHITESPACE, " "),
+				token.New(token.WHITESPACE, "\n"),
+				token.New(token.DIVIDE, "/"),
+				token.New(token.WHITESPACE, " "),
+				token.New(token.IDENTIFIER, "c"),
+			},
+		},
+		{
+			source: "a @ b",
+			tokens: []token.Token{
+				token.New(token.IDENTIFIER, "a"),
+				token.New(token.ILLEGAL, "unexpected character \"@\""),
+				token.New(token.IDENTIFIER, "b"),
+			},
+		},
+		{
+			source: "a ^ b",
+			tokens: []token.Token{
+				token.New(token.IDENTIFIER, "a"),
+				token.New(token.ILLEGAL, "unexpected character \"^\""),
+				token.New(token.IDENTIFIER, "b"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			l := New(strings.NewReader(tt.source), tt.opts...)
+
+			var tokens []token.Token
+			for tk := range l.Tokens() {
+				tokens = append(tokens, token.New(tk.Type, tk.Literal))
+			}
+			assert.Equal(t, tt.tokens, tokens)
+		})
+	}
+}
+
+func TestLexer_Tokens_Break(t *testing.T) {
+	l := New(strings.NewReader("a b c"))
+	for tk := range l.Tokens() {
+		assert.Equal(t, "a", tk.Literal)
+		break
+	}
+	assert.Equal(t, "b", l.Next().Literal)
+}
+
+func TestLexer_Release(t *testing.T) {
+	l := New(strings.NewReader("a\nbb\nc"))
+
+	assert.Equal(t, "a", l.Next().Literal)
+	b := l.Next()
+	l.Release(b.Start)
+
+	assert.Equal(t, "", l.Line(1))
+	assert.Equal(t, "bb", l.Line(2))
+
+	c := l.Next()
+	assert.Equal(t, "c", c.Literal)
+	assert.Equal(t, token.Pos{Line: 3, Column: 1, Offset: 5}, c.Start)
+	assert.Equal(t, "c", l.Line(3))
+}
+
+func TestLexer_Release_Bounded(t *testing.T) {
+	source := strings.Repeat("foo = foo + 1;\n", 10_000)
+	l := New(iotest.HalfReader(strings.NewReader(source)))
+
+	n := 0
+	for tk := range l.Tokens() {
+		l.Release(tk.Start)
+		n++
+	}
+	assert.Equal(t, 60_000, n)
+	assert.LessOrEqual(t, cap(l.buf), 2*chunkSize)
+}
+
+func TestNewBytes(t *testing.T) {
+	tests := []string{
+		"var foo = 1_000 + 0x1F; // comment",
+		"'a\\tb' + \"한글\" + `x${y}\\n${`z`}`",
+		"/* block */ a = /[/]+/gi.test(b) / 2",
+		"\uFEFFfoo\u2028bar\r\nbaz\u00A0qux",
+		"'unterminated",
+	}
+
+	for _, source := range tests {
+		t.Run(source, func(t *testing.T) {
+			expect := New(strings.NewReader(source), WithComments(true), WithWhitespace(true))
+			actual := NewBytes([]byte(source), WithComments(true), WithWhitespace(true))
+			for {
+				tk := expect.Next()
+				assert.Equal(t, tk, actual.Next())
+				if tk.Type == token.EOF || tk.Type == token.ILLEGAL {
+					break
+				}
+			}
+			assert.Equal(t, expect.Line(2), actual.Line(2))
+		})
+	}
+}
+
+func TestLexer_Next_OneByteReader(t *testing.T) {
+	source := "var 변수 = '값\u2028'; // 주석"
+
+	expect := NewBytes([]byte(source), WithComments(true))
+	actual := New(iotest.OneByteReader(strings.NewReader(source)), WithComments(true))
+	for tk := range expect.Tokens() {
+		assert.Equal(t, tk, actual.Next())
+	}
+	assert.Equal(t, token.EOF, actual.Next().Type)
+}
+
+func FuzzLexer_Next(f *testing.F) {
+	for _, source := range []string{
+		"var foo = 1_000 + 0x1F * 2.5e-3; // comment",
+		"'a\\n' + \"b\\u0041\" + `c${d}e`",
+		"a = /re[/]/gi.test(b) /* block */",
+		"\uFEFF\u00A0foo\u2028bar",
+		"0b 0x_1 1__0 08.5 'unterminated",
+	} {
+		f.Add(source)
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		expect := NewBytes([]byte(source), WithComments(true), WithWhitespace(true))
+		actual := New(iotest.OneByteReader(strings.NewReader(source)), WithComments(true), WithWhitespace(true))
+		for tk := range expect.Tokens() {
+			assert.Equal(t, tk, actual.Next())
+		}
+		assert.Equal(t, token.EOF, actual.Next().Type)
+	})
+}
+
+func BenchmarkLexer_Next(b *testing.B) {
+	source := strings.Repeat(strings.Join([]string{
+		"// fibonacci",
+		"function fib(n) {",
+		"	if (n < 2) { return n; }",
+		"	return fib(n - 1) + fib(n - 2);",
+		"}",
+		"var result = fib(0x10) * 1_000 / 2.5e3;",
+		"var message = 'result: ' + result + \"\\t\" + `${result} items`;",
+		"",
+	}, "\n"), 100)
+
+	b.Run("Reader", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(source)))
+		for i := 0; i < b.N; i++ {
+			l := New(strings.NewReader(source))
+			for range l.Tokens() {
+			}
+		}
+	})
+
+	b.Run("Bytes", func(b *testing.B) {
+		src := []byte(source)
+		b.ReportAllocs()
+		b.SetBytes(int64(len(src)))
+		for i := 0; i < b.N; i++ {
+			l := NewBytes(src)
+			for range l.Tokens() {
+			}
+		}
+	})
+}
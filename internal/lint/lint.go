@@ -0,0 +1,287 @@
+package lint
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/siyul-park/minijs/internal/ast"
+	"github.com/siyul-park/minijs/internal/lexer"
+	"github.com/siyul-park/minijs/internal/parser"
+	"github.com/siyul-park/minijs/internal/token"
+)
+
+// Rule names a check that reports issues.
+type Rule string
+
+const (
+	// RuleUnused reports variables and functions whose value is never read.
+	RuleUnused Rule = "unused-variable"
+	// RuleUseBeforeDefine reports variables read above their declaration in
+	// the same function, where they are still undefined.
+	RuleUseBeforeDefine Rule = "use-before-define"
+	// RuleImplicitGlobal reports assignments to undeclared variables, which
+	// create globals.
+	RuleImplicitGlobal Rule = "implicit-global"
+	// RuleCoercion reports operators that implicitly convert an operand of
+	// another type, such as subtracting from a string.
+	RuleCoercion Rule = "suspicious-coercion"
+)
+
+// Issue is a problem found in a program.
+type Issue struct {
+	Rule    Rule      `json:"rule"`
+	Message string    `json:"message"`
+	Pos     token.Pos `json:"pos"`
+}
+
+type binding struct {
+	node *ast.IdentifierLiteral
+	kind string
+	read bool
+}
+
+type scope struct {
+	outer    *scope
+	bindings map[string]*binding
+}
+
+type linter struct {
+	scopes       []*scope
+	declarations map[*ast.IdentifierLiteral]bool
+	declared     map[*ast.FunctionLiteral]bool
+	issues       []Issue
+}
+
+const (
+	kindVariable  = "variable"
+	kindFunction  = "function"
+	kindParameter = "parameter"
+	kindName      = "name"
+)
+
+// Source parses src and lints the program.
+func Source(src []byte) ([]Issue, error) {
+	program, err := parser.New(lexer.NewBytes(src)).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return Program(program), nil
+}
+
+// Program returns the issues in program ordered by position.
+func Program(program *ast.Program) []Issue {
+	l := &linter{
+		declarations: map[*ast.IdentifierLiteral]bool{},
+		declared:     map[*ast.FunctionLiteral]bool{},
+	}
+
+	var scopes []*scope
+	s := l.declare(nil, program.Statements)
+	scopes = append(scopes, s)
+	l.scopes = []*scope{s}
+
+	ast.Apply(program, func(c *ast.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.FunctionLiteral:
+			var body []ast.Statement
+			if n.Body != nil {
+				body = n.Body.Statements
+			}
+			s := l.declare(l.scope(), body)
+			for _, param := range n.Parameters {
+				l.bind(s, param, kindParameter)
+			}
+			if n.Name != nil && !l.declared[n] {
+				l.bind(s, n.Name, kindName)
+			}
+			scopes = append(scopes, s)
+			l.scopes = append(l.scopes, s)
+		case *ast.IdentifierLiteral:
+			l.identifier(c, n)
+		case *ast.InfixExpression:
+			l.infix(n)
+		case *ast.PrefixExpression:
+			l.prefix(n)
+		}
+		return true
+	}, func(c *ast.Cursor) bool {
+		if _, ok := c.Node().(*ast.FunctionLiteral); ok {
+			l.scopes = l.scopes[:len(l.scopes)-1]
+		}
+		return true
+	})
+
+	for _, s := range scopes {
+		for _, b := range s.bindings {
+			if !b.read && (b.kind == kindVariable || b.kind == kindFunction) {
+				l.report(RuleUnused, b.node.Pos(), "'%s' is declared but its value is never read", b.node.Value)
+			}
+		}
+	}
+
+	slices.SortStableFunc(l.issues, func(a, b Issue) int {
+		return cmp.Or(cmp.Compare(a.Pos.Offset, b.Pos.Offset), strings.Compare(string(a.Rule), string(b.Rule)))
+	})
+	return l.issues
+}
+
+// String formats the issue as "line:column: message (rule)".
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.Pos, i.Message, i.Rule)
+}
+
+// declare returns a scope inside outer with the variables and functions that
+// body declares, which are visible from its start.
+func (l *linter) declare(outer *scope, body []ast.Statement) *scope {
+	s := &scope{outer: outer, bindings: map[string]*binding{}}
+	pre := func(c *ast.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.VariableStatement:
+			for _, assign := range n.Right {
+				if ident, ok := assign.Left.(*ast.IdentifierLiteral); ok {
+					l.bind(s, ident, kindVariable)
+				}
+			}
+		case *ast.FunctionDeclaration:
+			if n.Function != nil && n.Function.Name != nil {
+				l.bind(s, n.Function.Name, kindFunction)
+				l.declared[n.Function] = true
+			}
+			return false
+		case *ast.FunctionLiteral:
+			return false
+		}
+		return true
+	}
+	for _, stmt := range body {
+		ast.Apply(stmt, pre, nil)
+	}
+	return s
+}
+
+func (l *linter) bind(s *scope, ident *ast.IdentifierLiteral, kind string) {
+	l.declarations[ident] = true
+	if _, ok := s.bindings[ident.Value]; !ok {
+		s.bindings[ident.Value] = &binding{node: ident, kind: kind}
+	}
+}
+
+func (l *linter) scope() *scope {
+	return l.scopes[len(l.scopes)-1]
+}
+
+func (l *linter) resolve(name string) (*binding, *scope) {
+	for s := l.scope(); s != nil; s = s.outer {
+		if b, ok := s.bindings[name]; ok {
+			return b, s
+		}
+	}
+	return nil, nil
+}
+
+func (l *linter) identifier(c *ast.Cursor, ident *ast.IdentifierLiteral) {
+	if l.declarations[ident] {
+		return
+	}
+	if _, ok := c.Parent().(*ast.MemberExpression); ok && c.Name() == "Property" {
+		return
+	}
+
+	b, s := l.resolve(ident.Value)
+	if _, ok := c.Parent().(*ast.AssignmentExpression); ok && c.Name() == "Left" {
+		if b == nil {
+			l.report(RuleImplicitGlobal, ident.Pos(), "assignment to undeclared variable '%s' creates a global", ident.Value)
+		}
+		return
+	}
+	if b == nil {
+		return
+	}
+	b.read = true
+	if s == l.scope() && b.kind == kindVariable && ident.Pos().Offset < b.node.Pos().Offset {
+		l.report(RuleUseBeforeDefine, ident.Pos(), "'%s' is read before it is declared", ident.Value)
+	}
+}
+
+func (l *linter) infix(node *ast.InfixExpression) {
+	left, right := typeOf(node.Left), typeOf(node.Right)
+	op := node.Token.Literal
+
+	if node.Token.Type == token.PLUS {
+		switch {
+		case left == "string" && converts(right):
+			l.report(RuleCoercion, node.Token.Start, "'%s' converts the %s operand to a string", op, right)
+		case right == "string" && converts(left):
+			l.report(RuleCoercion, node.Token.Start, "'%s' converts the %s operand to a string", op, left)
+		case left != "string" && right != "string" && converts(left) && left != "number":
+			l.report(RuleCoercion, node.Token.Start, "'%s' converts the %s operand to a number", op, left)
+		case left != "string" && right != "string" && converts(right) && right != "number":
+			l.report(RuleCoercion, node.Token.Start, "'%s' converts the %s operand to a number", op, right)
+		}
+		return
+	}
+
+	for _, typ := range []string{left, right} {
+		if typ != "" && typ != "number" {
+			l.report(RuleCoercion, node.Token.Start, "'%s' converts the %s operand to a number", op, typ)
+			return
+		}
+	}
+}
+
+func (l *linter) prefix(node *ast.PrefixExpression) {
+	typ := typeOf(node.Right)
+	if typ == "" || typ == "number" {
+		return
+	}
+	if lit, ok := node.Right.(*ast.StringLiteral); ok {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(lit.Value), 64); err == nil {
+			return
+		}
+	}
+	l.report(RuleCoercion, node.Pos(), "'%s' converts the %s operand to a number", node.Token.Literal, typ)
+}
+
+func (l *linter) report(rule Rule, pos token.Pos, format string, args ...any) {
+	l.issues = append(l.issues, Issue{Rule: rule, Message: fmt.Sprintf(format, args...), Pos: pos})
+}
+
+// typeOf returns the type that expr always has, or an empty string if it
+// depends on values only known when the program runs.
+func typeOf(expr ast.Expression) string {
+	switch n := expr.(type) {
+	case *ast.StringLiteral:
+		return "string"
+	case *ast.NumberLiteral:
+		return "number"
+	case *ast.BoolLiteral:
+		return "boolean"
+	case *ast.NullLiteral:
+		return "null"
+	case *ast.UndefinedLiteral:
+		return "undefined"
+	case *ast.PrefixExpression:
+		return "number"
+	case *ast.InfixExpression:
+		if n.Token.Type != token.PLUS {
+			return "number"
+		}
+		left, right := typeOf(n.Left), typeOf(n.Right)
+		if left == "string" || right == "string" {
+			return "string"
+		}
+		if left != "" && right != "" {
+			return "number"
+		}
+	}
+	return ""
+}
+
+// converts reports whether a value of typ is implicitly converted when used
+// with an operand of another type.
+func converts(typ string) bool {
+	return typ != "" && typ != "string"
+}
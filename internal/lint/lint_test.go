@@ -0,0 +1,88 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		source string
+		expect []string
+	}{
+		{
+			source: "var a = 1; a",
+			expect: nil,
+		},
+		{
+			source: "var a = 1",
+			expect: []string{"1:5: 'a' is declared but its value is never read (unused-variable)"},
+		},
+		{
+			source: "var a = 1; a = 2",
+			expect: []string{"1:5: 'a' is declared but its value is never read (unused-variable)"},
+		},
+		{
+			source: "function f(x) { return 1 }",
+			expect: []string{"1:10: 'f' is declared but its value is never read (unused-variable)"},
+		},
+		{
+			source: "f(); function f() {}",
+			expect: nil,
+		},
+		{
+			source: "a; var a = 1",
+			expect: []string{"1:1: 'a' is read before it is declared (use-before-define)"},
+		},
+		{
+			source: "function f() { return a } var a = 1; f()",
+			expect: nil,
+		},
+		{
+			source: "b = 1",
+			expect: []string{"1:1: assignment to undeclared variable 'b' creates a global (implicit-global)"},
+		},
+		{
+			source: "function f() { b = 1 } f()",
+			expect: []string{"1:16: assignment to undeclared variable 'b' creates a global (implicit-global)"},
+		},
+		{
+			source: "var o = print; o.b = 1",
+			expect: nil,
+		},
+		{
+			source: `"a" - 1`,
+			expect: []string{"1:5: '-' converts the string operand to a number (suspicious-coercion)"},
+		},
+		{
+			source: `"a" + 1`,
+			expect: []string{"1:5: '+' converts the number operand to a string (suspicious-coercion)"},
+		},
+		{
+			source: `1 + true`,
+			expect: []string{"1:3: '+' converts the boolean operand to a number (suspicious-coercion)"},
+		},
+		{
+			source: `+"a"`,
+			expect: []string{"1:1: '+' converts the string operand to a number (suspicious-coercion)"},
+		},
+		{
+			source: `+"3" * 2 + "a" + "b"`,
+			expect: []string{"1:10: '+' converts the number operand to a string (suspicious-coercion)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			issues, err := Source([]byte(tt.source))
+			assert.NoError(t, err)
+
+			var actual []string
+			for _, issue := range issues {
+				actual = append(actual, issue.String())
+			}
+			assert.Equal(t, tt.expect, actual)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"github.com/siyul-park/minijs/internal/ast"
+	"github.com/siyul-park/minijs/internal/token"
+)
+
+func attach(comments map[ast.Node]*ast.Comments, parent ast.Node, statements []ast.Statement, c *ast.Comment) {
+	group := func(n ast.Node) *ast.Comments {
+		g, ok := comments[n]
+		if !ok {
+			g = &ast.Comments{}
+			comments[n] = g
+		}
+		return g
+	}
+
+	for i, stmt := range statements {
+		if c.End().Offset <= stmt.Pos().Offset {
+			if i > 0 && statements[i-1].End().Line == c.Pos().Line {
+				g := group(statements[i-1])
+				g.Trailing = append(g.Trailing, c)
+			} else {
+				g := group(stmt)
+				g.Leading = append(g.Leading, c)
+			}
+			return
+		}
+		if c.Pos().Offset < stmt.End().Offset {
+			if body := children(stmt); body != nil && body.Pos().Offset <= c.Pos().Offset {
+				attach(comments, body, body.Statements, c)
+			} else {
+				g := group(stmt)
+				g.Leading = append(g.Leading, c)
+			}
+			return
+		}
+	}
+
+	var n ast.Node = parent
+	if len(statements) > 0 {
+		n = statements[len(statements)-1]
+	}
+	g := group(n)
+	g.Trailing = append(g.Trailing, c)
+}
+
+func attachTrivia(trivia map[ast.Node]*ast.Trivia, parent ast.Node, statements []ast.Statement, tk token.Token) {
+	for _, stmt := range statements {
+		t := trivia[stmt]
+		if tk.End.Offset <= t.Start.Offset {
+			t.Leading = append(t.Leading, tk)
+			return
+		}
+		if tk.Start.Offset < t.End.Offset {
+			if body := children(stmt); body != nil && body.Pos().Offset < tk.Start.Offset && tk.Start.Offset < body.Rbrace.Offset {
+				attachTrivia(trivia, body, body.Statements, tk)
+			}
+			return
+		}
+	}
+
+	t, ok := trivia[parent]
+	if !ok {
+		t = &ast.Trivia{}
+		trivia[parent] = t
+	}
+	t.Trailing = append(t.Trailing, tk)
+}
+
+func children(stmt ast.Statement) *ast.BlockStatement {
+	switch stmt := stmt.(type) {
+	case *ast.BlockStatement:
+		return stmt
+	case *ast.FunctionDeclaration:
+		return stmt.Function.Body
+	default:
+		return nil
+	}
+}
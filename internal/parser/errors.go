@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/siyul-park/minijs/internal/token"
+)
+
+type SyntaxError struct {
+	Pos     token.Pos
+	Message string
+	Source  string
+}
+
+func (e *SyntaxError) Error() string {
+	msg := fmt.Sprintf("syntax error at %s: %s", e.Pos, e.Message)
+	if e.Source == "" {
+		return msg
+	}
+
+	var b strings.Builder
+	b.WriteString(msg)
+	b.WriteString("\n")
+	b.WriteString(e.Source)
+	b.WriteString("\n")
+	for i, ch := range []rune(e.Source) {
+		if i >= e.Pos.Column-1 {
+			break
+		}
+		if ch == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	b.WriteString("^")
+	return b.String()
+}
+
+func describe(tk token.Token) string {
+	switch tk.Type {
+	case token.EOF:
+		return "end of input"
+	case token.IDENTIFIER, token.NUMBER, token.STRING:
+		return fmt.Sprintf("%s %q", strings.ToLower(string(tk.Type)), tk.Literal)
+	default:
+		return fmt.Sprintf("%q", tk.Literal)
+	}
+}
+
+func kind(typ token.Type) string {
+	switch typ {
+	case token.IDENTIFIER, token.NUMBER, token.STRING:
+		return strings.ToLower(string(typ))
+	default:
+		return fmt.Sprintf("%q", string(typ))
+	}
+}
@@ -1,7 +1,11 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"iter"
+	"maps"
+	"math/big"
 	"strconv"
 	"strings"
 
@@ -11,12 +15,17 @@ import (
 )
 
 type Parser struct {
-	lexer  *lexer.Lexer
-	tokens [3]token.Token
-	prefix map[token.Type]func() (ast.Expression, error)
-	infix  map[token.Type]func(ast.Expression) (ast.Expression, error)
+	lexer    *lexer.Lexer
+	tokens   [3]token.Token
+	comments []*ast.Comment
+	trivia   map[ast.Node]*ast.Trivia
+	hidden   []token.Token
+	prefix   map[token.Type]func() (ast.Expression, error)
+	infix    map[token.Type]func(ast.Expression) (ast.Expression, error)
 }
 
+type Option func(*Parser)
+
 const (
 	PREV = iota
 	CURR
@@ -36,24 +45,48 @@ const (
 )
 
 var precedences = map[token.Type]int{
-	token.ASSIGN:     ASSIGN,
-	token.PLUS:       SUM,
-	token.MINUS:      SUM,
-	token.MULTIPLY:   PRODUCT,
-	token.DIVIDE:     PRODUCT,
-	token.MODULUS:    MODULUS,
-	token.OPEN_PAREN: MODULUS,
-}
-
-func New(lexer *lexer.Lexer) *Parser {
-	p := &Parser{
-		lexer: lexer,
-		tokens: [3]token.Token{
-			token.New(token.EOF, ""),
-			lexer.Next(),
-			lexer.Next(),
-		},
+	token.ASSIGN:       ASSIGN,
+	token.PLUS:         SUM,
+	token.MINUS:        SUM,
+	token.MULTIPLY:     PRODUCT,
+	token.DIVIDE:       PRODUCT,
+	token.MODULUS:      MODULUS,
+	token.OPEN_PAREN:   CALL,
+	token.DOT:          CALL,
+	token.OPEN_BRACKET: CALL,
+}
+
+// Precedence returns the binding power of typ as an infix operator, or LOWEST if it is not one.
+func Precedence(typ token.Type) int {
+	if precedence, ok := precedences[typ]; ok {
+		return precedence
 	}
+	return LOWEST
+}
+
+// Precedences returns a copy of the infix operator precedence table.
+func Precedences() map[token.Type]int {
+	return maps.Clone(precedences)
+}
+
+// WithTrivia keeps the whitespace and comment tokens emitted by the lexer in
+// Program.Trivia, so a lexer built with lexer.WithWhitespace and
+// lexer.WithComments yields a tree that can be printed back with its layout.
+func WithTrivia(enabled bool) Option {
+	return func(p *Parser) {
+		p.trivia = nil
+		if enabled {
+			p.trivia = map[ast.Node]*ast.Trivia{}
+		}
+	}
+}
+
+func New(lexer *lexer.Lexer, opts ...Option) *Parser {
+	p := &Parser{lexer: lexer}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.tokens = [3]token.Token{token.New(token.EOF, ""), p.next(), p.next()}
 	p.prefix = map[token.Type]func() (ast.Expression, error){
 		token.NULL:       p.nullLiteral,
 		token.UNDEFINED:  p.undefinedLiteral,
@@ -65,28 +98,92 @@ func New(lexer *lexer.Lexer) *Parser {
 		token.PLUS:       p.prefixExpression,
 		token.MINUS:      p.prefixExpression,
 		token.OPEN_PAREN: p.groupedExpression,
+		token.FUNCTION:   p.functionLiteral,
 	}
 	p.infix = map[token.Type]func(ast.Expression) (ast.Expression, error){
-		token.PLUS:     p.infixExpression,
-		token.MINUS:    p.infixExpression,
-		token.MULTIPLY: p.infixExpression,
-		token.DIVIDE:   p.infixExpression,
-		token.MODULUS:  p.infixExpression,
-		token.ASSIGN:   p.assignmentExpression,
+		token.PLUS:         p.infixExpression,
+		token.MINUS:        p.infixExpression,
+		token.MULTIPLY:     p.infixExpression,
+		token.DIVIDE:       p.infixExpression,
+		token.MODULUS:      p.infixExpression,
+		token.ASSIGN:       p.assignmentExpression,
+		token.OPEN_PAREN:   p.callExpression,
+		token.DOT:          p.memberExpression,
+		token.OPEN_BRACKET: p.indexExpression,
 	}
 	return p
 }
 
 func (p *Parser) Parse() (*ast.Program, error) {
+	statements, err := p.statements(token.EOF, true)
+	if err != nil {
+		return nil, err
+	}
+
+	program := ast.NewProgram(statements...)
+	if len(p.comments) > 0 {
+		program.Comments = map[ast.Node]*ast.Comments{}
+		for _, c := range p.comments {
+			attach(program.Comments, program, program.Statements, c)
+		}
+	}
+	if p.trivia != nil {
+		program.Trivia = p.trivia
+		for _, tk := range p.hidden {
+			attachTrivia(program.Trivia, program, program.Statements, tk)
+		}
+	}
+	return program, nil
+}
+
+// Statements parses the program one statement at a time and releases the source
+// behind each yielded statement, so memory stays proportional to a statement
+// rather than the whole program. Comments and trivia are not collected in this
+// mode.
+func (p *Parser) Statements() iter.Seq2[ast.Statement, error] {
+	return func(yield func(ast.Statement, error) bool) {
+		prologue := true
+		for p.peek(CURR).Type != token.EOF {
+			stmt, err := p.sourceElement(prologue)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			_, prologue = stmt.(*ast.Directive)
+
+			p.comments = p.comments[:0]
+			p.hidden = p.hidden[:0]
+			p.lexer.Release(p.peek(CURR).Start)
+			if !yield(stmt, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (p *Parser) statements(end token.Type, prologue bool) ([]ast.Statement, error) {
 	var statements []ast.Statement
-	for p.peek(CURR).Type != token.EOF {
-		stmt, err := p.statement()
+	for p.peek(CURR).Type != end {
+		start := p.peek(CURR).Start
+		stmt, err := p.sourceElement(prologue)
 		if err != nil {
 			return nil, err
 		}
+		_, prologue = stmt.(*ast.Directive)
 		statements = append(statements, stmt)
+
+		if p.trivia != nil {
+			p.trivia[stmt] = &ast.Trivia{Start: start, End: p.peek(PREV).End}
+		}
+	}
+	return statements, nil
+}
+
+func (p *Parser) sourceElement(prologue bool) (ast.Statement, error) {
+	if prologue && p.isDirective() {
+		return p.directive()
 	}
-	return ast.NewProgram(statements...), nil
+	return p.statement()
 }
 
 func (p *Parser) statement() (ast.Statement, error) {
@@ -97,6 +194,10 @@ func (p *Parser) statement() (ast.Statement, error) {
 		return p.blockStatement()
 	case token.VAR:
 		return p.variableStatement()
+	case token.FUNCTION:
+		return p.functionDeclaration()
+	case token.RETURN:
+		return p.returnStatement()
 	default:
 		return p.expressionStatement()
 	}
@@ -105,7 +206,7 @@ func (p *Parser) statement() (ast.Statement, error) {
 func (p *Parser) expression(precedence int) (ast.Expression, error) {
 	prefix, ok := p.prefix[p.peek(CURR).Type]
 	if !ok {
-		return nil, fmt.Errorf("no prefix expression function for %s", p.peek(CURR).Type)
+		return nil, p.expected("expression")
 	}
 
 	left, err := prefix()
@@ -154,7 +255,7 @@ func (p *Parser) numberLiteral() (ast.Expression, error) {
 	curr := p.peek(CURR)
 	p.pop()
 
-	lit := curr.Literal
+	lit := strings.ReplaceAll(curr.Literal, "_", "")
 	base := 10
 	if strings.HasPrefix(lit, "0b") || strings.HasPrefix(lit, "0B") {
 		base = 2
@@ -165,21 +266,24 @@ func (p *Parser) numberLiteral() (ast.Expression, error) {
 	} else if strings.HasPrefix(lit, "0x") || strings.HasPrefix(lit, "0X") {
 		base = 16
 		lit = lit[2:]
+	} else if len(lit) > 1 && lit[0] == '0' && strings.Trim(lit, "01234567") == "" {
+		base = 8
+		lit = lit[1:]
 	}
 
 	var value float64
 	if base == 10 {
 		parsedValue, err := strconv.ParseFloat(lit, 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid number literal: %s", curr.Literal)
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
+			return nil, p.errorf(curr.Start, "invalid number literal %s", curr.Literal)
 		}
 		value = parsedValue
 	} else {
-		parsedValue, err := strconv.ParseInt(lit, base, 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid %d-based literal: %s", base, curr.Literal)
+		parsedValue, ok := new(big.Int).SetString(lit, base)
+		if !ok {
+			return nil, p.errorf(curr.Start, "invalid %d-based literal %s", base, curr.Literal)
 		}
-		value = float64(parsedValue)
+		value, _ = new(big.Float).SetInt(parsedValue).Float64()
 	}
 
 	return ast.NewNumberLiteral(curr, value), nil
@@ -188,28 +292,83 @@ func (p *Parser) numberLiteral() (ast.Expression, error) {
 func (p *Parser) identifierLiteral() (ast.Expression, error) {
 	curr := p.peek(CURR)
 	p.pop()
-	return ast.NewIdentifierLiteral(curr, curr.Literal), nil
+
+	ident := ast.NewIdentifierLiteral(curr, curr.Literal)
+	if p.peek(CURR).Type == token.ARROW {
+		return p.arrowFunction([]ast.Expression{ident})
+	}
+	return ident, nil
+}
+
+func (p *Parser) functionLiteral() (ast.Expression, error) {
+	curr := p.peek(CURR)
+	p.pop()
+
+	var name *ast.IdentifierLiteral
+	if p.peek(CURR).Type == token.IDENTIFIER {
+		name = ast.NewIdentifierLiteral(p.peek(CURR), p.peek(CURR).Literal)
+		p.pop()
+	}
+
+	if err := p.expect(token.OPEN_PAREN); err != nil {
+		return nil, err
+	}
+	var params []*ast.IdentifierLiteral
+	for p.peek(CURR).Type != token.CLOSE_PAREN {
+		if p.peek(CURR).Type != token.IDENTIFIER {
+			return nil, p.expected(kind(token.IDENTIFIER))
+		}
+		params = append(params, ast.NewIdentifierLiteral(p.peek(CURR), p.peek(CURR).Literal))
+		p.pop()
+
+		if p.peek(CURR).Type != token.COMMA {
+			break
+		}
+		p.pop()
+	}
+	if err := p.expect(token.CLOSE_PAREN); err != nil {
+		return nil, err
+	}
+
+	if p.peek(CURR).Type != token.OPEN_BRACE {
+		return nil, p.expected(kind(token.OPEN_BRACE))
+	}
+	body, err := p.block(true)
+	if err != nil {
+		return nil, err
+	}
+	return ast.NewFunctionLiteral(curr, name, params, body), nil
 }
 
 func (p *Parser) emptyStatement() (ast.Statement, error) {
+	curr := p.peek(CURR)
 	p.pop()
-	return ast.NewEmptyStatement(), nil
+
+	stmt := ast.NewEmptyStatement()
+	stmt.Semicolon = curr.Start
+	return stmt, nil
 }
 
 func (p *Parser) blockStatement() (ast.Statement, error) {
+	return p.block(false)
+}
+
+func (p *Parser) block(prologue bool) (*ast.BlockStatement, error) {
+	lbrace := p.peek(CURR)
 	p.pop()
 
-	var statements []ast.Statement
-	for p.peek(CURR).Type != token.CLOSE_BRACE {
-		stmt, err := p.statement()
-		if err != nil {
-			return nil, err
-		}
-		statements = append(statements, stmt)
+	statements, err := p.statements(token.CLOSE_BRACE, prologue)
+	if err != nil {
+		return nil, err
 	}
 
+	rbrace := p.peek(CURR)
 	p.pop()
-	return ast.NewBlockStatement(statements...), nil
+
+	stmt := ast.NewBlockStatement(statements...)
+	stmt.Lbrace = lbrace.Start
+	stmt.Rbrace = rbrace.Start
+	return stmt, nil
 }
 
 func (p *Parser) expressionStatement() (ast.Statement, error) {
@@ -223,6 +382,28 @@ func (p *Parser) expressionStatement() (ast.Statement, error) {
 	return ast.NewExpressionStatement(exp), nil
 }
 
+func (p *Parser) isDirective() bool {
+	curr, next := p.peek(CURR), p.peek(NEXT)
+	if curr.Type != token.STRING {
+		return false
+	}
+	switch next.Type {
+	case token.SEMICOLON, token.CLOSE_BRACE, token.EOF:
+		return true
+	default:
+		return next.Start.Line > curr.End.Line && Precedence(next.Type) == LOWEST
+	}
+}
+
+func (p *Parser) directive() (ast.Statement, error) {
+	curr := p.peek(CURR)
+	p.pop()
+	if p.peek(CURR).Type == token.SEMICOLON {
+		p.pop()
+	}
+	return ast.NewDirective(ast.NewStringLiteral(curr, curr.Literal)), nil
+}
+
 func (p *Parser) variableStatement() (ast.Statement, error) {
 	curr := p.peek(CURR)
 	p.pop()
@@ -235,7 +416,7 @@ func (p *Parser) variableStatement() (ast.Statement, error) {
 		}
 		right, ok := exp.(*ast.AssignmentExpression)
 		if !ok {
-			return nil, fmt.Errorf("expected assignment expressions, got %s", p.peek(CURR).Literal)
+			return nil, p.errorf(exp.Pos(), "expected assignment expression")
 		}
 		expressions = append(expressions, right)
 
@@ -244,9 +425,44 @@ func (p *Parser) variableStatement() (ast.Statement, error) {
 		}
 		p.pop()
 	}
+	if p.peek(CURR).Type == token.SEMICOLON {
+		p.pop()
+	}
 	return ast.NewVariableStatement(curr, expressions...), nil
 }
 
+func (p *Parser) functionDeclaration() (ast.Statement, error) {
+	if p.peek(NEXT).Type != token.IDENTIFIER {
+		p.pop()
+		return nil, p.expected(kind(token.IDENTIFIER))
+	}
+	exp, err := p.functionLiteral()
+	if err != nil {
+		return nil, err
+	}
+	return ast.NewFunctionDeclaration(exp.(*ast.FunctionLiteral)), nil
+}
+
+func (p *Parser) returnStatement() (ast.Statement, error) {
+	curr := p.peek(CURR)
+	p.pop()
+
+	var value ast.Expression
+	switch p.peek(CURR).Type {
+	case token.SEMICOLON, token.CLOSE_BRACE, token.EOF:
+	default:
+		exp, err := p.expression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+		value = exp
+	}
+	if p.peek(CURR).Type == token.SEMICOLON {
+		p.pop()
+	}
+	return ast.NewReturnStatement(curr, value), nil
+}
+
 func (p *Parser) prefixExpression() (ast.Expression, error) {
 	curr := p.peek(CURR)
 	p.pop()
@@ -270,18 +486,129 @@ func (p *Parser) infixExpression(left ast.Expression) (ast.Expression, error) {
 	return ast.NewInfixExpression(curr, left, right), nil
 }
 
+// groupedExpression parses a parenthesized expression as a cover grammar: the
+// comma-separated contents are kept until the closing paren, and an arrow that
+// follows reinterprets them as a parameter list.
 func (p *Parser) groupedExpression() (ast.Expression, error) {
 	p.pop()
-	n, err := p.expression(LOWEST)
-	if err != nil {
+
+	var items []ast.Expression
+	var comma token.Token
+	for p.peek(CURR).Type != token.CLOSE_PAREN {
+		exp, err := p.expression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, exp)
+
+		if p.peek(CURR).Type != token.COMMA {
+			break
+		}
+		if comma.Type == "" {
+			comma = p.peek(CURR)
+		}
+		p.pop()
+	}
+
+	rparen := p.peek(CURR)
+	if err := p.expect(token.CLOSE_PAREN); err != nil {
+		return nil, err
+	}
+	if p.peek(CURR).Type == token.ARROW {
+		return p.arrowFunction(items)
+	}
+
+	if comma.Type != "" {
+		return nil, p.errorf(comma.Start, "expected %s, got %s", kind(token.CLOSE_PAREN), describe(comma))
+	}
+	if len(items) == 0 {
+		return nil, p.errorf(rparen.Start, "expected expression, got %s", describe(rparen))
+	}
+	return items[0], nil
+}
+
+func (p *Parser) arrowFunction(items []ast.Expression) (ast.Expression, error) {
+	if _, err := p.parameters(items); err != nil {
 		return nil, err
 	}
+	return nil, p.errorf(p.peek(CURR).Start, "arrow functions are not supported")
+}
 
-	if p.peek(CURR).Type != token.CLOSE_PAREN {
-		return nil, fmt.Errorf("expected next token to be %s, got %s instead", token.CLOSE_PAREN, p.peek(CURR).Type)
+func (p *Parser) parameters(items []ast.Expression) ([]*ast.IdentifierLiteral, error) {
+	params := make([]*ast.IdentifierLiteral, 0, len(items))
+	for _, item := range items {
+		param, ok := item.(*ast.IdentifierLiteral)
+		if !ok {
+			return nil, p.errorf(item.Pos(), "invalid arrow function parameter")
+		}
+		for _, prev := range params {
+			if prev.Value == param.Value {
+				return nil, p.errorf(param.Pos(), "duplicate parameter name %q", param.Value)
+			}
+		}
+		params = append(params, param)
 	}
+	return params, nil
+}
+
+func (p *Parser) callExpression(left ast.Expression) (ast.Expression, error) {
+	curr := p.peek(CURR)
 	p.pop()
-	return n, nil
+
+	var args []ast.Expression
+	for p.peek(CURR).Type != token.CLOSE_PAREN {
+		arg, err := p.expression(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		if p.peek(CURR).Type != token.COMMA {
+			break
+		}
+		p.pop()
+	}
+
+	rparen := p.peek(CURR)
+	if err := p.expect(token.CLOSE_PAREN); err != nil {
+		return nil, err
+	}
+
+	exp := ast.NewCallExpression(curr, left, args...)
+	exp.Rparen = rparen.Start
+	return exp, nil
+}
+
+func (p *Parser) memberExpression(left ast.Expression) (ast.Expression, error) {
+	curr := p.peek(CURR)
+	p.pop()
+
+	name := p.peek(CURR)
+	if name.Type != token.IDENTIFIER && !token.IsKeyword(name.Type) {
+		return nil, p.expected("property name")
+	}
+	p.pop()
+
+	return ast.NewMemberExpression(curr, left, ast.NewIdentifierLiteral(name, name.Literal)), nil
+}
+
+func (p *Parser) indexExpression(left ast.Expression) (ast.Expression, error) {
+	curr := p.peek(CURR)
+	p.pop()
+
+	index, err := p.expression(LOWEST)
+	if err != nil {
+		return nil, err
+	}
+
+	rbracket := p.peek(CURR)
+	if err := p.expect(token.CLOSE_BRACKET); err != nil {
+		return nil, err
+	}
+
+	exp := ast.NewIndexExpression(curr, left, index)
+	exp.Rbracket = rbracket.Start
+	return exp, nil
 }
 
 func (p *Parser) assignmentExpression(left ast.Expression) (ast.Expression, error) {
@@ -295,12 +622,32 @@ func (p *Parser) assignmentExpression(left ast.Expression) (ast.Expression, erro
 	return ast.NewAssignmentExpression(curr, left, right), nil
 }
 
-func (p *Parser) precedence(i int) int {
-	peek := p.peek(i)
-	if precedence, ok := precedences[peek.Type]; ok {
-		return precedence
+func (p *Parser) expect(typ token.Type) error {
+	if p.peek(CURR).Type != typ {
+		return p.expected(kind(typ))
 	}
-	return LOWEST
+	p.pop()
+	return nil
+}
+
+func (p *Parser) expected(what string) error {
+	curr := p.peek(CURR)
+	if curr.Type == token.ILLEGAL {
+		return p.errorf(curr.Start, "%s", curr.Literal)
+	}
+	return p.errorf(curr.Start, "expected %s, got %s", what, describe(curr))
+}
+
+func (p *Parser) errorf(pos token.Pos, format string, args ...any) error {
+	return &SyntaxError{
+		Pos:     pos,
+		Message: fmt.Sprintf(format, args...),
+		Source:  p.lexer.Line(pos.Line),
+	}
+}
+
+func (p *Parser) precedence(i int) int {
+	return Precedence(p.peek(i).Type)
 }
 
 func (p *Parser) peek(i int) token.Token {
@@ -313,5 +660,24 @@ func (p *Parser) peek(i int) token.Token {
 func (p *Parser) pop() {
 	p.tokens[PREV] = p.tokens[CURR]
 	p.tokens[CURR] = p.tokens[NEXT]
-	p.tokens[NEXT] = p.lexer.Next()
+	p.tokens[NEXT] = p.next()
+}
+
+func (p *Parser) next() token.Token {
+	for {
+		tk := p.lexer.Next()
+		switch tk.Type {
+		case token.WHITESPACE:
+			if p.trivia != nil {
+				p.hidden = append(p.hidden, tk)
+			}
+		case token.COMMENT:
+			if p.trivia != nil {
+				p.hidden = append(p.hidden, tk)
+			}
+			p.comments = append(p.comments, ast.NewComment(tk))
+		default:
+			return tk
+		}
+	}
 }
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"math"
 	"strings"
 	"testing"
 
@@ -106,6 +107,78 @@ func TestParser_Parse(t *testing.T) {
 				),
 			),
 		},
+		{
+			"1_000.5",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewNumberLiteral(token.New(token.NUMBER, "1_000.5"), 1000.5),
+				),
+			),
+		},
+		{
+			"0xFF_FF",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewNumberLiteral(token.New(token.NUMBER, "0xFF_FF"), 0xFFFF),
+				),
+			),
+		},
+		{
+			"0123",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewNumberLiteral(token.New(token.NUMBER, "0123"), 83),
+				),
+			),
+		},
+		{
+			"089.5",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewNumberLiteral(token.New(token.NUMBER, "089.5"), 89.5),
+				),
+			),
+		},
+		{
+			"1e+10",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewNumberLiteral(token.New(token.NUMBER, "1e+10"), 1e+10),
+				),
+			),
+		},
+		{
+			"1E-3",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewNumberLiteral(token.New(token.NUMBER, "1E-3"), 1e-3),
+				),
+			),
+		},
+		{
+			"1e999",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewNumberLiteral(token.New(token.NUMBER, "1e999"), math.Inf(1)),
+				),
+			),
+		},
+		{
+			"0x1FFFFFFFFFFFFF",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewNumberLiteral(token.New(token.NUMBER, "0x1FFFFFFFFFFFFF"), 0x1FFFFFFFFFFFFF),
+				),
+			),
+		},
+		{
+			"0xFFFFFFFFFFFFFFFFF",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewNumberLiteral(token.New(token.NUMBER, "0xFFFFFFFFFFFFFFFFF"), 0xFFFFFFFFFFFFFFFFF),
+				),
+			),
+		},
 		{
 			"true",
 			ast.NewProgram(
@@ -125,11 +198,64 @@ func TestParser_Parse(t *testing.T) {
 		{
 			`"hello"`,
 			ast.NewProgram(
+				ast.NewDirective(
+					ast.NewStringLiteral(token.New(token.STRING, "hello"), "hello"),
+				),
+			),
+		},
+		{
+			`"use strict"; "hello" + a`,
+			ast.NewProgram(
+				ast.NewDirective(
+					ast.NewStringLiteral(token.New(token.STRING, "use strict"), "use strict"),
+				),
+				ast.NewExpressionStatement(
+					ast.NewInfixExpression(
+						token.New(token.PLUS, "+"),
+						ast.NewStringLiteral(token.New(token.STRING, "hello"), "hello"),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "a"), "a"),
+					),
+				),
+			),
+		},
+		{
+			`a; "hello"`,
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "a"), "a"),
+				),
 				ast.NewExpressionStatement(
 					ast.NewStringLiteral(token.New(token.STRING, "hello"), "hello"),
 				),
 			),
 		},
+		{
+			`function f() { "use strict" }`,
+			ast.NewProgram(
+				ast.NewFunctionDeclaration(
+					ast.NewFunctionLiteral(
+						token.New(token.FUNCTION, "function"),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "f"), "f"),
+						nil,
+						ast.NewBlockStatement(
+							ast.NewDirective(
+								ast.NewStringLiteral(token.New(token.STRING, "use strict"), "use strict"),
+							),
+						),
+					),
+				),
+			),
+		},
+		{
+			`{ "hello" }`,
+			ast.NewProgram(
+				ast.NewBlockStatement(
+					ast.NewExpressionStatement(
+						ast.NewStringLiteral(token.New(token.STRING, "hello"), "hello"),
+					),
+				),
+			),
+		},
 		{
 			"-1",
 			ast.NewProgram(
@@ -228,6 +354,120 @@ func TestParser_Parse(t *testing.T) {
 				),
 			),
 		},
+		{
+			"function add(a, b) { return a + b; }",
+			ast.NewProgram(
+				ast.NewFunctionDeclaration(
+					ast.NewFunctionLiteral(
+						token.New(token.FUNCTION, "function"),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "add"), "add"),
+						[]*ast.IdentifierLiteral{
+							ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "a"), "a"),
+							ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "b"), "b"),
+						},
+						ast.NewBlockStatement(
+							ast.NewReturnStatement(
+								token.New(token.RETURN, "return"),
+								ast.NewInfixExpression(
+									token.New(token.PLUS, "+"),
+									ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "a"), "a"),
+									ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "b"), "b"),
+								),
+							),
+						),
+					),
+				),
+			),
+		},
+		{
+			"var f = function() { return; }",
+			ast.NewProgram(
+				ast.NewVariableStatement(
+					token.New(token.VAR, "var"),
+					ast.NewAssignmentExpression(
+						token.New(token.ASSIGN, "="),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "f"), "f"),
+						ast.NewFunctionLiteral(
+							token.New(token.FUNCTION, "function"),
+							nil,
+							nil,
+							ast.NewBlockStatement(
+								ast.NewReturnStatement(token.New(token.RETURN, "return"), nil),
+							),
+						),
+					),
+				),
+			),
+		},
+		{
+			"f(a, 1 + 2)",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewCallExpression(
+						token.New(token.OPEN_PAREN, "("),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "f"), "f"),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "a"), "a"),
+						ast.NewInfixExpression(
+							token.New(token.PLUS, "+"),
+							ast.NewNumberLiteral(token.New(token.NUMBER, "1"), 1),
+							ast.NewNumberLiteral(token.New(token.NUMBER, "2"), 2),
+						),
+					),
+				),
+			),
+		},
+		{
+			"user.name.length",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewMemberExpression(
+						token.New(token.DOT, "."),
+						ast.NewMemberExpression(
+							token.New(token.DOT, "."),
+							ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "user"), "user"),
+							ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "name"), "name"),
+						),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "length"), "length"),
+					),
+				),
+			),
+		},
+		{
+			"a[i + 1].get(0)",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewCallExpression(
+						token.New(token.OPEN_PAREN, "("),
+						ast.NewMemberExpression(
+							token.New(token.DOT, "."),
+							ast.NewIndexExpression(
+								token.New(token.OPEN_BRACKET, "["),
+								ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "a"), "a"),
+								ast.NewInfixExpression(
+									token.New(token.PLUS, "+"),
+									ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "i"), "i"),
+									ast.NewNumberLiteral(token.New(token.NUMBER, "1"), 1),
+								),
+							),
+							ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "get"), "get"),
+						),
+						ast.NewNumberLiteral(token.New(token.NUMBER, "0"), 0),
+					),
+				),
+			),
+		},
+		{
+			"a.var",
+			ast.NewProgram(
+				ast.NewExpressionStatement(
+					ast.NewMemberExpression(
+						token.New(token.DOT, "."),
+						ast.NewIdentifierLiteral(token.New(token.IDENTIFIER, "a"), "a"),
+						ast.NewIdentifierLiteral(token.New(token.VAR, "var"), "var"),
+					),
+				),
+			),
+		},
 	}
 
 	for _, tt := range tests {
@@ -236,7 +476,296 @@ func TestParser_Parse(t *testing.T) {
 			p := New(l)
 			program, err := p.Parse()
 			assert.NoError(t, err)
-			assert.Equal(t, tt.program, program)
+			assert.True(t, ast.Equal(tt.program, program), ast.Diff(tt.program, program))
 		})
 	}
 }
+
+func TestParser_Parse_Position(t *testing.T) {
+	l := lexer.New(strings.NewReader("{\n  a + 1;\n}"))
+	p := New(l)
+
+	program, err := p.Parse()
+	assert.NoError(t, err)
+
+	block := program.Statements[0].(*ast.BlockStatement)
+	assert.Equal(t, token.Pos{Line: 1, Column: 1, Offset: 0}, block.Pos())
+	assert.Equal(t, token.Pos{Line: 3, Column: 2, Offset: 12}, block.End())
+
+	stmt := block.Statements[0]
+	assert.Equal(t, token.Pos{Line: 2, Column: 3, Offset: 4}, stmt.Pos())
+	assert.Equal(t, token.Pos{Line: 2, Column: 8, Offset: 9}, stmt.End())
+}
+
+func TestParser_Parse_Error(t *testing.T) {
+	tests := []struct {
+		source string
+		err    string
+	}{
+		{
+			source: "foo(1;",
+			err:    "syntax error at 1:6: expected \")\", got \";\"\nfoo(1;\n     ^",
+		},
+		{
+			source: "var a = 1;\nvar b;",
+			err:    "syntax error at 2:5: expected assignment expression\nvar b;\n    ^",
+		},
+		{
+			source: "function (a) {}",
+			err:    "syntax error at 1:10: expected identifier, got \"(\"\nfunction (a) {}\n         ^",
+		},
+		{
+			source: "\t1 + ;",
+			err:    "syntax error at 1:6: expected expression, got \";\"\n\t1 + ;\n\t    ^",
+		},
+		{
+			source: "'foo",
+			err:    "syntax error at 1:1: unterminated string literal\n'foo\n^",
+		},
+		{
+			source: "f(1,,)",
+			err:    "syntax error at 1:5: expected expression, got \",\"\nf(1,,)\n    ^",
+		},
+		{
+			source: "var a = 1,;",
+			err:    "syntax error at 1:11: expected expression, got \";\"\nvar a = 1,;\n          ^",
+		},
+		{
+			source: "(a, b) => a + b",
+			err:    "syntax error at 1:8: arrow functions are not supported\n(a, b) => a + b\n       ^",
+		},
+		{
+			source: "a => a",
+			err:    "syntax error at 1:3: arrow functions are not supported\na => a\n  ^",
+		},
+		{
+			source: "a.;",
+			err:    "syntax error at 1:3: expected property name, got \";\"\na.;\n  ^",
+		},
+		{
+			source: "a[1",
+			err:    "syntax error at 1:4: expected \"]\", got end of input\na[1\n   ^",
+		},
+		{
+			source: "() => {}",
+			err:    "syntax error at 1:4: arrow functions are not supported\n() => {}\n   ^",
+		},
+		{
+			source: "(a, 1 + b) => a",
+			err:    "syntax error at 1:5: invalid arrow function parameter\n(a, 1 + b) => a\n    ^",
+		},
+		{
+			source: "(a, b, a) => a",
+			err:    "syntax error at 1:8: duplicate parameter name \"a\"\n(a, b, a) => a\n       ^",
+		},
+		{
+			source: "(a, b)",
+			err:    "syntax error at 1:3: expected \")\", got \",\"\n(a, b)\n  ^",
+		},
+		{
+			source: "();",
+			err:    "syntax error at 1:2: expected expression, got \")\"\n();\n ^",
+		},
+		{
+			source: "(1 + 2",
+			err:    "syntax error at 1:7: expected \")\", got end of input\n(1 + 2\n      ^",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			l := lexer.New(strings.NewReader(tt.source))
+			p := New(l)
+
+			_, err := p.Parse()
+			var e *SyntaxError
+			assert.ErrorAs(t, err, &e)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestParser_Parse_TrailingComma(t *testing.T) {
+	tests := []struct {
+		source string
+		expect string
+	}{
+		{source: "f(1, 2,)", expect: "f(1, 2)"},
+		{source: "f(\n\t1,\n\t2,\n)", expect: "f(1, 2)"},
+		{source: "function f(a, b,) {}", expect: "function f(a, b) {}"},
+		{source: "(function(a,) { return a })(1,)", expect: "(function(a) { return a })(1)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			actual, err := New(lexer.New(strings.NewReader(tt.source))).Parse()
+			assert.NoError(t, err)
+
+			expect, err := New(lexer.New(strings.NewReader(tt.expect))).Parse()
+			assert.NoError(t, err)
+			assert.Equal(t, expect.String(), actual.String())
+		})
+	}
+}
+
+func TestParser_Statements(t *testing.T) {
+	source := "'use strict'\n// comment\nvar a = 1;\nfunction f(b) { return a + b }\nf(2)"
+
+	expect, err := New(lexer.New(strings.NewReader(source))).Parse()
+	assert.NoError(t, err)
+
+	var actual []ast.Statement
+	for stmt, err := range New(lexer.New(strings.NewReader(source))).Statements() {
+		assert.NoError(t, err)
+		actual = append(actual, stmt)
+	}
+	assert.Equal(t, expect.String(), ast.NewProgram(actual...).String())
+	assert.IsType(t, &ast.Directive{}, actual[0])
+	assert.IsType(t, &ast.ExpressionStatement{}, actual[3])
+}
+
+func TestParser_Statements_Error(t *testing.T) {
+	source := strings.Repeat("a = 1;\n", 100) + "b = ;"
+
+	var errs []error
+	count := 0
+	for stmt, err := range New(lexer.New(strings.NewReader(source))).Statements() {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		assert.NotNil(t, stmt)
+		count++
+	}
+	assert.Equal(t, 100, count)
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "syntax error at 101:5: expected expression, got \";\"\nb = ;\n    ^")
+}
+
+func TestParser_Parse_Trivia(t *testing.T) {
+	source := "// head\n(a) ;\n{\n  b\n  // tail\n}\n"
+	l := lexer.New(strings.NewReader(source), lexer.WithComments(true), lexer.WithWhitespace(true))
+
+	program, err := New(l, WithTrivia(true)).Parse()
+	assert.NoError(t, err)
+
+	literals := func(tokens []token.Token) []string {
+		var literals []string
+		for _, tk := range tokens {
+			literals = append(literals, tk.Literal)
+		}
+		return literals
+	}
+
+	first := program.Trivia[program.Statements[0]]
+	assert.Equal(t, []string{"// head", "\n"}, literals(first.Leading))
+	assert.Equal(t, "(a) ;", source[first.Start.Offset:first.End.Offset])
+
+	block := program.Statements[1].(*ast.BlockStatement)
+	assert.Equal(t, []string{"\n"}, literals(program.Trivia[block].Leading))
+	assert.Equal(t, []string{"\n  ", "\n  ", "// tail", "\n"}, append(literals(program.Trivia[block.Statements[0]].Leading), literals(program.Trivia[block].Trailing)...))
+	assert.Equal(t, []string{"\n"}, literals(program.Trivia[program].Trailing))
+}
+
+func TestParser_Parse_Comments(t *testing.T) {
+	source := "// leading\na = 1; // trailing\nfunction f() {\n  /* inner */\n  return a;\n}\n{\n  // dangling\n}\n// end"
+
+	l := lexer.New(strings.NewReader(source), lexer.WithComments(true))
+	p := New(l)
+
+	program, err := p.Parse()
+	assert.NoError(t, err)
+	assert.Len(t, program.Statements, 3)
+
+	text := func(comments []*ast.Comment) []string {
+		var texts []string
+		for _, c := range comments {
+			texts = append(texts, c.String())
+		}
+		return texts
+	}
+
+	assign := program.Statements[0]
+	assert.Equal(t, []string{"// leading"}, text(program.Comments[assign].Leading))
+	assert.Equal(t, []string{"// trailing"}, text(program.Comments[assign].Trailing))
+
+	fn := program.Statements[1].(*ast.FunctionDeclaration)
+	ret := fn.Function.Body.Statements[0]
+	assert.Equal(t, []string{"/* inner */"}, text(program.Comments[ret].Leading))
+
+	block := program.Statements[2]
+	assert.Equal(t, []string{"// dangling", "// end"}, text(program.Comments[block].Trailing))
+}
+
+func TestParser_Parse_WithoutComments(t *testing.T) {
+	l := lexer.New(strings.NewReader("1 /* a */ + /* b */ 2 // c"))
+	p := New(l)
+
+	program, err := p.Parse()
+	assert.NoError(t, err)
+	assert.Nil(t, program.Comments)
+	assert.Len(t, program.Statements, 1)
+}
+
+func TestParser_Parse_Whitespace(t *testing.T) {
+	l := lexer.New(strings.NewReader("a = 1 +\n  2;"), lexer.WithWhitespace(true))
+	p := New(l)
+
+	program, err := p.Parse()
+	assert.NoError(t, err)
+	assert.Equal(t, "a=(1+2);", strings.TrimSpace(program.String()))
+}
+
+func TestPrecedence(t *testing.T) {
+	tests := []struct {
+		typ        token.Type
+		precedence int
+	}{
+		{typ: token.ASSIGN, precedence: ASSIGN},
+		{typ: token.PLUS, precedence: SUM},
+		{typ: token.MULTIPLY, precedence: PRODUCT},
+		{typ: token.OPEN_PAREN, precedence: CALL},
+		{typ: token.DOT, precedence: CALL},
+		{typ: token.OPEN_BRACKET, precedence: CALL},
+		{typ: token.IDENTIFIER, precedence: LOWEST},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.typ), func(t *testing.T) {
+			assert.Equal(t, tt.precedence, Precedence(tt.typ))
+		})
+	}
+}
+
+func TestPrecedences(t *testing.T) {
+	precedences := Precedences()
+	for typ, precedence := range precedences {
+		assert.Equal(t, precedence, Precedence(typ))
+	}
+
+	precedences[token.PLUS] = HIGHEST
+	assert.Equal(t, SUM, Precedence(token.PLUS))
+
+	for _, typ := range token.Keywords() {
+		assert.True(t, token.IsKeyword(typ))
+		assert.Equal(t, typ, token.TypeOf(string(typ)))
+	}
+	assert.False(t, token.IsKeyword(token.PLUS))
+}
+
+func FuzzParse(f *testing.F) {
+	for _, source := range []string{
+		"var foo = 1 + 2 * 3;",
+		"function add(a, b) { return a + b; } add(1, 2)",
+		"var s = 'a\\n' + `b${c}d` + /re[/]/g.source; // comment",
+		"a = b ? c : d, e[0].f(g)",
+		"0x_1 1__0 08.5 'unterminated",
+	} {
+		f.Add(source)
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		_, _ = New(lexer.NewBytes([]byte(source))).Parse()
+		_, _ = New(lexer.NewBytes([]byte(source), lexer.WithComments(true))).Parse()
+	})
+}
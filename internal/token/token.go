@@ -1,19 +1,40 @@
 package token
 
+import (
+	"fmt"
+	"slices"
+)
+
 type Type string
 
 type Token struct {
-	Type    Type
-	Literal string
+	Type    Type   `json:"type"`
+	Literal string `json:"literal"`
+	Start   Pos    `json:"start"`
+	End     Pos    `json:"end"`
+}
+
+type Pos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
 }
 
 const (
-	ILLEGAL Type = "ILLEGAL"
-	EOF     Type = "EOF"
+	ILLEGAL    Type = "ILLEGAL"
+	EOF        Type = "EOF"
+	COMMENT    Type = "COMMENT"
+	WHITESPACE Type = "WHITESPACE"
 
 	NUMBER     Type = "NUMBER"
 	STRING     Type = "STRING"
 	IDENTIFIER Type = "IDENTIFIER"
+	REGEXP     Type = "REGEXP"
+
+	TEMPLATE        Type = "TEMPLATE"
+	TEMPLATE_HEAD   Type = "TEMPLATE_HEAD"
+	TEMPLATE_MIDDLE Type = "TEMPLATE_MIDDLE"
+	TEMPLATE_TAIL   Type = "TEMPLATE_TAIL"
 
 	NULL      Type = "null"
 	UNDEFINED Type = "undefined"
@@ -56,6 +77,7 @@ const (
 	SEMICOLON                     Type = ";"
 	COMMA                         Type = ","
 	ASSIGN                        Type = "="
+	ARROW                         Type = "=>"
 	QUESTION                      Type = "?"
 	COLON                         Type = ":"
 	DOT                           Type = "."
@@ -84,7 +106,7 @@ const (
 	AND                           Type = "&&"
 	OR                            Type = "||"
 	MULTIPLY_ASSIGN               Type = "*="
-	DIVIDE_ASSIGN                 Type = "%="
+	DIVIDE_ASSIGN                 Type = "/="
 	MODULUS_ASSIGN                Type = "%="
 	PLUS_ASSIGN                   Type = "+="
 	MINUS_ASSIGN                  Type = "-="
@@ -96,13 +118,16 @@ const (
 	BIT_XOR_ASSIGN                Type = "^="
 )
 
-var reserved = []Type{
+var keywords = []Type{
 	NULL, UNDEFINED, TRUE, FALSE,
 	BREAK, DO, INSTANCEOF, TYPEOF, CASE, ELSE, NEW, VAR, CATCH,
 	FINALLY, RETURN, VOID, CONTINUE, FOR, SWITCH, WHILE, DEBUGGER,
 	FUNCTION, THIS, WITH, DEFAULT, IF, THROW, DELETE, IN, TRY,
+}
+
+var operators = []Type{
 	OPEN_BRACKET, CLOSE_BRACKET, OPEN_PAREN, CLOSE_PAREN,
-	OPEN_BRACE, CLOSE_BRACE, SEMICOLON, COMMA, ASSIGN, QUESTION,
+	OPEN_BRACE, CLOSE_BRACE, SEMICOLON, COMMA, ASSIGN, ARROW, QUESTION,
 	COLON, DOT, PLUS, MINUS, PLUS_PLUS, MINUS_MINUS, BIT_NOT, NOT,
 	MULTIPLY, DIVIDE, MODULUS, RIGHT_SHIFT_ARITHMETIC,
 	LEFT_SHIFT_ARITHMETIC, RIGHT_SHIFT_LOGICAL, LESS_THAN,
@@ -118,11 +143,21 @@ var reserved = []Type{
 var types = map[string]Type{}
 
 func init() {
-	for _, t := range reserved {
+	for _, t := range slices.Concat(keywords, operators) {
 		types[string(t)] = t
 	}
 }
 
+// Keywords returns the reserved words of the grammar in declaration order.
+func Keywords() []Type {
+	return slices.Clone(keywords)
+}
+
+// IsKeyword reports whether typ is a reserved word.
+func IsKeyword(typ Type) bool {
+	return slices.Contains(keywords, typ)
+}
+
 func TypeOf(literal string) Type {
 	typ, ok := types[literal]
 	if !ok {
@@ -138,3 +173,11 @@ func New(typ Type, literal string) Token {
 func (t Token) String() string {
 	return t.Literal
 }
+
+func (p Pos) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
@@ -0,0 +1,309 @@
+package minijs
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/siyul-park/minijs/internal/ast"
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/siyul-park/minijs/internal/compiler"
+	"github.com/siyul-park/minijs/internal/interpreter"
+	"github.com/siyul-park/minijs/internal/lexer"
+	"github.com/siyul-park/minijs/internal/parser"
+)
+
+// Resolver locates the modules scripts load with require. Resolve maps the name
+// passed to require from the module with id from, or "" for the main script, to
+// the id of the required module, and Load returns that module's source. Modules
+// are compiled and run once per VM and cached by id.
+type Resolver interface {
+	Resolve(from, name string) (string, error)
+	Load(id string) ([]byte, error)
+}
+
+// module is a required script. Its global variables and functions are its
+// exports, and it keeps its own global variables apart from the scripts that
+// require it.
+type module struct {
+	id      string
+	exports map[string]int
+	globals []Value
+	opts    []interpreter.Option
+	mu      sync.Mutex
+}
+
+// namespace is the value require returns for a module.
+type namespace struct {
+	module *module
+}
+
+var _ interpreter.Object = (*namespace)(nil)
+
+// require loads the modules named by the require calls of the module with id
+// from, given the chain of modules being loaded that led to it.
+func (vm *VM) require(from string, chain []string, names []string) (map[string]*module, error) {
+	modules := map[string]*module{}
+	for _, name := range names {
+		if _, ok := modules[name]; ok {
+			continue
+		}
+		m, err := vm.load(from, chain, name)
+		if err != nil {
+			return nil, err
+		}
+		modules[name] = m
+	}
+	return modules, nil
+}
+
+func (vm *VM) load(from string, chain []string, name string) (*module, error) {
+	id, err := vm.resolver.Resolve(from, name)
+	if err != nil {
+		return nil, &CompileError{Kind: KindModule, Message: fmt.Sprintf("cannot find module %q", name), err: fmt.Errorf("cannot find module %q: %w", name, err)}
+	}
+	if m, ok := vm.modules[id]; ok {
+		return m, nil
+	}
+	if slices.Contains(chain, id) {
+		return nil, compileErrorf(KindModule, nil, "import cycle: %s", strings.Join(append(chain, id), " -> "))
+	}
+
+	src, err := vm.resolver.Load(id)
+	if err != nil {
+		return nil, &CompileError{Kind: KindModule, Message: fmt.Sprintf("cannot load module %q", id), err: fmt.Errorf("cannot load module %q: %w", id, err)}
+	}
+
+	var code bytecode.Bytecode
+	var requires map[string]*module
+	if bytes.HasPrefix(src, bytecode.Magic[:]) {
+		if err := code.UnmarshalBinary(src); err != nil {
+			return nil, fmt.Errorf("%s: %w", id, compileErrorf(KindModule, nil, "%v", err))
+		}
+		if err := code.LoadDebug(); err != nil {
+			return nil, fmt.Errorf("%s: %w", id, compileErrorf(KindModule, nil, "%v", err))
+		}
+		names, err := calls(code)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+		if requires, err = vm.require(id, append(chain, id), names); err != nil {
+			return nil, err
+		}
+	} else {
+		program, err := parser.New(lexer.NewBytes(src)).Parse()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", id, syntaxError(err))
+		}
+		names, err := imports(program)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+		if requires, err = vm.require(id, append(chain, id), names); err != nil {
+			return nil, err
+		}
+
+		c := compiler.New(
+			compiler.WithSession(compiler.NewSession()),
+			compiler.WithOptimization(compiler.O1),
+			compiler.WithDebugInfo(true),
+			compiler.WithHosts(vm.names()...),
+		)
+		if code, err = c.Compile(program); err != nil {
+			return nil, fmt.Errorf("%s: %w", id, compileError(err))
+		}
+	}
+	if err := interpreter.Verify(code); err != nil {
+		return nil, fmt.Errorf("%s: %w", id, compileError(err))
+	}
+
+	m := &module{id: id, exports: map[string]int{}, opts: vm.options(requires)}
+	size := 0
+	for _, sym := range code.Symbols {
+		m.exports[sym.Name] = sym.Index
+		size = max(size, sym.Index+1)
+	}
+
+	i := interpreter.New(m.opts...)
+	if err := i.Execute(code); err != nil {
+		return nil, fmt.Errorf("%s: %w", id, runtimeError(err))
+	}
+	m.globals = make([]Value, size)
+	for idx := range m.globals {
+		m.globals[idx], _ = i.Global(idx)
+	}
+
+	if vm.modules == nil {
+		vm.modules = map[string]*module{}
+	}
+	vm.modules[id] = m
+	return m, nil
+}
+
+// imports returns the names program passes to require.
+func imports(program *ast.Program) ([]string, error) {
+	var names []string
+	var err error
+	ast.Apply(program, func(c *ast.Cursor) bool {
+		call, ok := c.Node().(*ast.CallExpression)
+		if !ok || err != nil {
+			return err == nil
+		}
+		if ident, ok := call.Function.(*ast.IdentifierLiteral); !ok || ident.Value != "require" {
+			return true
+		}
+		if len(call.Arguments) != 1 {
+			err = compileErrorf(KindCompile, call, "require expects one argument")
+			return false
+		}
+		name, ok := call.Arguments[0].(*ast.StringLiteral)
+		if !ok {
+			err = compileErrorf(KindCompile, call, "require expects a string literal")
+			return false
+		}
+		names = append(names, name.Value)
+		return true
+	}, nil)
+	return names, err
+}
+
+// calls returns the names compiled code passes to require, which must be
+// loaded by str.load right before the call.
+func calls(code bytecode.Bytecode) ([]string, error) {
+	var names []string
+	var prev bytecode.Instruction
+	for _, inst := range code.Iter() {
+		if inst.Opcode() == bytecode.CALLHOST {
+			operands := inst.Operands()
+			if name, _ := code.Constants[operands[0]].(bytecode.String); name == "require" {
+				if operands[1] != 1 || prev == nil || prev.Opcode() != bytecode.STRLOAD {
+					return nil, compileErrorf(KindCompile, nil, "require expects a string literal")
+				}
+				names = append(names, string(code.Constants[prev.Operands()[0]].(bytecode.String)))
+			}
+		}
+		prev = inst
+	}
+	for _, fn := range code.Functions {
+		more, err := calls(fn.Code)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, more...)
+	}
+	return names, nil
+}
+
+// names returns the host functions compiled code may call.
+func (vm *VM) names() []string {
+	var names []string
+	for name := range vm.hosts {
+		if vm.allows(name) {
+			names = append(names, name)
+		}
+	}
+	if vm.resolver != nil && vm.allows("require") {
+		names = append(names, "require")
+	}
+	return names
+}
+
+// options binds the host functions set on vm and, when a resolver is set, a
+// require that returns the given modules, under the limits of the policy.
+func (vm *VM) options(modules map[string]*module) []interpreter.Option {
+	var opts []interpreter.Option
+	for name, host := range vm.hosts {
+		if vm.allows(name) {
+			opts = append(opts, interpreter.WithHost(name, host))
+		}
+	}
+	if vm.policy != nil {
+		opts = append(opts, interpreter.WithLimits(vm.limits()))
+	}
+	if vm.resolver != nil && vm.allows("require") {
+		opts = append(opts, interpreter.WithHost("require", func(args []interpreter.Value) (interpreter.Value, error) {
+			var name string
+			if len(args) > 0 {
+				name = string(interpreter.ToString(args[0]))
+			}
+			m, ok := modules[name]
+			if !ok {
+				return nil, fmt.Errorf("require: module %q was not loaded", name)
+			}
+			return &namespace{module: m}, nil
+		}))
+	}
+	return opts
+}
+
+// export returns val as the scripts requiring m see it. Functions are wrapped
+// so that they run against the global variables of m.
+func (m *module) export(val Value) Value {
+	fn, ok := val.(interpreter.Function)
+	if !ok {
+		return val
+	}
+	return interpreter.Host(func(args []interpreter.Value) (interpreter.Value, error) {
+		i := interpreter.New(m.opts...)
+		m.mu.Lock()
+		for idx, v := range m.globals {
+			if v != nil {
+				if err := i.SetGlobal(idx, v); err != nil {
+					m.mu.Unlock()
+					return nil, fmt.Errorf("%s: %w", m.id, err)
+				}
+			}
+		}
+		m.mu.Unlock()
+
+		val, err := i.Apply(fn, args...)
+
+		m.mu.Lock()
+		for idx := range m.globals {
+			if v, ok := i.Global(idx); ok {
+				m.globals[idx] = v
+			}
+		}
+		m.mu.Unlock()
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.id, err)
+		}
+		return m.export(val), nil
+	})
+}
+
+func (n *namespace) Type() interpreter.Type {
+	return interpreter.OBJECT
+}
+
+func (n *namespace) Interface() any {
+	out := make(map[string]any, len(n.module.exports))
+	for name := range n.module.exports {
+		val, _ := n.Get(name)
+		out[name] = val.Interface()
+	}
+	return out
+}
+
+func (n *namespace) Get(key string) (interpreter.Value, error) {
+	m := n.module
+	idx, ok := m.exports[key]
+	if !ok {
+		return interpreter.Undefined{}, nil
+	}
+
+	m.mu.Lock()
+	val := m.globals[idx]
+	m.mu.Unlock()
+	if val == nil {
+		return interpreter.Undefined{}, nil
+	}
+	return m.export(val), nil
+}
+
+func (n *namespace) String() string {
+	return "[object Module]"
+}
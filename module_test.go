@@ -0,0 +1,125 @@
+package minijs_test
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/siyul-park/minijs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type resolver struct {
+	sources map[string]string
+	loads   map[string]int
+}
+
+func (r *resolver) Resolve(from, name string) (string, error) {
+	if strings.HasPrefix(name, "./") {
+		name = path.Join(path.Dir(from), name)
+	}
+	if _, ok := r.sources[name]; !ok {
+		return "", fmt.Errorf("no such module")
+	}
+	return name, nil
+}
+
+func (r *resolver) Load(id string) ([]byte, error) {
+	r.loads[id]++
+	return []byte(r.sources[id]), nil
+}
+
+func TestVM_SetResolver(t *testing.T) {
+	sources := map[string]string{
+		"math.js": `
+			var pi = 3;
+			function square(x) { return x * x }
+			function area(r) { return pi * square(r) }
+		`,
+		"counter.js": `
+			var count = 0;
+			function next() { count = count + 1; return count }
+		`,
+		"lib/index.js": `var util = require("./util.js"); var name = util.name`,
+		"lib/util.js":  `var name = "util"`,
+	}
+
+	tests := []struct {
+		source string
+		expect any
+	}{
+		{source: `require("math.js").pi`, expect: int32(3)},
+		{source: `var m = require("math.js"); m.area(2)`, expect: float64(12)},
+		{source: `var c = require("counter.js"); c.next(); c.next(); c.count`, expect: int32(2)},
+		{source: `require("lib/index.js").name`, expect: "util"},
+		{source: `require("math.js").missing`, expect: nil},
+		{source: `var square = 1; require("math.js").square(3)`, expect: float64(9)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			vm := minijs.New()
+			vm.SetResolver(&resolver{sources: sources, loads: map[string]int{}})
+
+			actual, err := vm.Eval(tt.source)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, actual)
+		})
+	}
+}
+
+func TestVM_SetResolver_Cache(t *testing.T) {
+	r := &resolver{
+		sources: map[string]string{
+			"a.js": `var b = require("b.js")`,
+			"b.js": `var value = 1`,
+		},
+		loads: map[string]int{},
+	}
+
+	vm := minijs.New()
+	vm.SetResolver(r)
+
+	_, err := vm.Eval(`require("a.js"); require("b.js")`)
+	assert.NoError(t, err)
+
+	actual, err := vm.Eval(`require("b.js").value`)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), actual)
+	assert.Equal(t, map[string]int{"a.js": 1, "b.js": 1}, r.loads)
+}
+
+func TestVM_SetResolver_Error(t *testing.T) {
+	sources := map[string]string{
+		"a.js":      `var b = require("b.js")`,
+		"b.js":      `var a = require("a.js")`,
+		"self.js":   `require("self.js")`,
+		"broken.js": `var = 1`,
+		"throws.js": `undefined()`,
+	}
+
+	tests := []struct {
+		source string
+		expect string
+	}{
+		{source: `require("a.js")`, expect: "import cycle: a.js -> b.js -> a.js"},
+		{source: `require("self.js")`, expect: "import cycle: self.js -> self.js"},
+		{source: `require("missing.js")`, expect: `cannot find module "missing.js"`},
+		{source: `require("broken.js")`, expect: "broken.js: "},
+		{source: `require("throws.js")`, expect: "throws.js: "},
+		{source: `var name = "a.js"; require(name)`, expect: "require expects a string literal"},
+		{source: `require()`, expect: "require expects one argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			vm := minijs.New()
+			vm.SetResolver(&resolver{sources: sources, loads: map[string]int{}})
+
+			_, err := vm.Eval(tt.source)
+			assert.ErrorContains(t, err, tt.expect)
+		})
+	}
+}
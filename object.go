@@ -0,0 +1,113 @@
+package minijs
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/siyul-park/minijs/internal/interpreter"
+)
+
+// object exposes a Go struct, pointer to struct, or map with string keys to
+// scripts. Struct fields are named by their `minijs` tag when present, and a
+// tag of "-" hides the field. Exported methods are callable by their Go name.
+type object struct {
+	value reflect.Value
+}
+
+// array exposes a Go slice or array to scripts by index and length.
+type array struct {
+	value reflect.Value
+}
+
+var _ interpreter.Object = (*object)(nil)
+var _ interpreter.Array = (*array)(nil)
+
+func (o *object) Type() interpreter.Type {
+	return interpreter.OBJECT
+}
+
+func (o *object) Interface() any {
+	if !o.value.CanInterface() {
+		return nil
+	}
+	return o.value.Interface()
+}
+
+func (o *object) Get(key string) (interpreter.Value, error) {
+	v := o.value
+	if v.Kind() == reflect.Map {
+		k := reflect.ValueOf(key).Convert(v.Type().Key())
+		if elem := v.MapIndex(k); elem.IsValid() {
+			return toValue(elem)
+		}
+		return interpreter.Undefined{}, nil
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if m := v.MethodByName(key); m.IsValid() {
+			return bind(key, m.Interface())
+		}
+		v = v.Elem()
+	} else if v.CanAddr() {
+		if m := v.Addr().MethodByName(key); m.IsValid() {
+			return bind(key, m.Interface())
+		}
+	} else if m := v.MethodByName(key); m.IsValid() {
+		return bind(key, m.Interface())
+	}
+
+	for _, field := range reflect.VisibleFields(v.Type()) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("minijs"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+		if name == key {
+			f, err := v.FieldByIndexErr(field.Index)
+			if err != nil {
+				return interpreter.Null{}, nil
+			}
+			return toValue(f)
+		}
+	}
+	return interpreter.Undefined{}, nil
+}
+
+func (o *object) String() string {
+	return "[object Object]"
+}
+
+func (a *array) Type() interpreter.Type {
+	return interpreter.OBJECT
+}
+
+func (a *array) Interface() any {
+	if !a.value.CanInterface() {
+		return nil
+	}
+	return a.value.Interface()
+}
+
+func (a *array) Get(key string) (interpreter.Value, error) {
+	if key == "length" {
+		return interpreter.Int32(a.value.Len()), nil
+	}
+	idx, err := strconv.Atoi(key)
+	if err != nil || idx < 0 || idx >= a.value.Len() || strconv.Itoa(idx) != key {
+		return interpreter.Undefined{}, nil
+	}
+	return toValue(a.value.Index(idx))
+}
+
+func (a *array) Len() int {
+	return a.value.Len()
+}
+
+func (a *array) String() string {
+	return "[object Array]"
+}
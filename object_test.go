@@ -0,0 +1,106 @@
+package minijs_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/siyul-park/minijs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	City string `minijs:"city"`
+}
+
+type user struct {
+	address
+	ID       int    `minijs:"id"`
+	Name     string `minijs:"name"`
+	Password string `minijs:"-"`
+	Tags     []string
+	Friend   *user
+	Scores   map[string]float64
+}
+
+func (u *user) Greet(greeting string) string {
+	return fmt.Sprintf("%s, %s", greeting, u.Name)
+}
+
+func (u user) Upper() string {
+	return fmt.Sprintf("%s!", u.Name)
+}
+
+func TestVM_Set_Object(t *testing.T) {
+	alice := &user{
+		address:  address{City: "Seoul"},
+		ID:       1,
+		Name:     "alice",
+		Password: "secret",
+		Tags:     []string{"admin", "dev"},
+		Friend:   &user{Name: "bob"},
+		Scores:   map[string]float64{"math": 90.5},
+	}
+
+	vm := minijs.New()
+	assert.NoError(t, vm.Set("getUser", func() *user { return alice }))
+	assert.NoError(t, vm.Set("getCopy", func() user { return *alice }))
+	assert.NoError(t, vm.Set("getMatrix", func() [][]int { return [][]int{{1, 2}, {3, 4}} }))
+	assert.NoError(t, vm.Set("getConfig", func() map[string]any {
+		return map[string]any{"debug": true, "retries": 3, "nested": map[string]any{"level": "info"}}
+	}))
+	assert.NoError(t, vm.Set("name", func(u *user) string { return u.Name }))
+
+	tests := []struct {
+		source string
+		expect any
+	}{
+		{source: `getUser().id`, expect: int32(1)},
+		{source: `getUser().name`, expect: "alice"},
+		{source: `getUser().city`, expect: "Seoul"},
+		{source: `getUser().Password`, expect: nil},
+		{source: `getUser().password`, expect: nil},
+		{source: `getUser().ID`, expect: nil},
+		{source: `getUser().Tags[1]`, expect: "dev"},
+		{source: `getUser().Tags.length`, expect: int32(2)},
+		{source: `getUser().Tags[2]`, expect: nil},
+		{source: `getUser().Friend.name`, expect: "bob"},
+		{source: `getUser().Friend.Friend`, expect: nil},
+		{source: `getUser().Scores.math`, expect: 90.5},
+		{source: `getUser().Scores["math"] + 1`, expect: 91.5},
+		{source: `getUser().Greet("hi")`, expect: "hi, alice"},
+		{source: `getUser().Upper()`, expect: "alice!"},
+		{source: `getCopy().Greet("hey")`, expect: "hey, alice"},
+		{source: `var f = getUser().Greet; f("yo")`, expect: "yo, alice"},
+		{source: `getMatrix()[1][0]`, expect: int32(3)},
+		{source: `getConfig().nested.level`, expect: "info"},
+		{source: `getConfig().retries * 2`, expect: float64(6)},
+		{source: `getConfig().missing`, expect: nil},
+		{source: `name(getUser().Friend)`, expect: "bob"},
+		{source: `getUser()`, expect: alice},
+		{source: `"" + getUser()`, expect: "[object Object]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			actual, err := vm.Eval(tt.source)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, actual)
+		})
+	}
+}
+
+func TestVM_Set_Object_Error(t *testing.T) {
+	vm := minijs.New()
+	assert.NoError(t, vm.Set("getUser", func() *user { return &user{} }))
+	assert.NoError(t, vm.Set("name", func(u *user) string { return u.Name }))
+
+	_, err := vm.Eval("getUser().Friend.name")
+	assert.ErrorContains(t, err, `cannot read property "name" of null`)
+
+	_, err = vm.Eval("undefined.x")
+	assert.Error(t, err)
+
+	_, err = vm.Eval(`name("bob")`)
+	assert.ErrorContains(t, err, "argument 1")
+}
@@ -0,0 +1,103 @@
+package minijs
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/siyul-park/minijs/internal/interpreter"
+)
+
+// Policy restricts what scripts run on a VM can reach so that untrusted code
+// runs against a locked-down surface. The zero Policy exposes none of the
+// globals set with SetGlobal and none of the functions set with Set, and sets
+// no size or work limits. Methods of Go values in exposed globals stay callable.
+type Policy struct {
+	// Globals names the global variables set with SetGlobal that scripts may
+	// read and assign. Others read as undefined and keep their value.
+	Globals []string
+	// Hosts names the functions set with Set that scripts may call. Naming
+	// "require" lets scripts load modules when a resolver is set.
+	Hosts []string
+	// MaxStringLength caps the length in bytes of the strings scripts build or
+	// receive. Zero means no limit.
+	MaxStringLength int
+	// MaxArrayLength caps the length of the Go slices and arrays scripts
+	// receive. Zero means no limit.
+	MaxArrayLength int
+	// MaxCallDepth caps how deeply script function calls nest. Zero means
+	// the default of 10000.
+	MaxCallDepth int
+	// MaxInstructions caps the number of bytecode instructions a single run
+	// executes, so that scripts cannot run forever. Zero means no limit.
+	MaxInstructions int
+}
+
+// SetPolicy restricts the scripts compiled on vm from now on to p, or lifts
+// the restrictions if p is nil. Modules already loaded are loaded again under
+// the new policy.
+func (vm *VM) SetPolicy(p *Policy) {
+	vm.policy = nil
+	if p != nil {
+		policy := *p
+		vm.policy = &policy
+	}
+	vm.modules = nil
+}
+
+// allows reports whether scripts may call the host function name.
+func (vm *VM) allows(name string) bool {
+	return vm.policy == nil || slices.Contains(vm.policy.Hosts, name)
+}
+
+// hidden returns the global slots set with SetGlobal that the policy keeps
+// from scripts.
+func (vm *VM) hidden() map[int]bool {
+	if vm.policy == nil {
+		return nil
+	}
+	hidden := map[int]bool{}
+	for name := range vm.presets {
+		if slices.Contains(vm.policy.Globals, name) {
+			continue
+		}
+		if sym, ok := vm.session.SymbolTable().Resolve(name); ok {
+			hidden[sym.Index] = true
+		}
+	}
+	return hidden
+}
+
+// limits returns the size and work limits of the policy.
+func (vm *VM) limits() interpreter.Limits {
+	if vm.policy == nil {
+		return interpreter.Limits{}
+	}
+	return interpreter.Limits{
+		MaxStringLength: vm.policy.MaxStringLength,
+		MaxArrayLength:  vm.policy.MaxArrayLength,
+		MaxCallDepth:    vm.policy.MaxCallDepth,
+		MaxInstructions: vm.policy.MaxInstructions,
+	}
+}
+
+// exposed returns the global variables scripts start with under the policy.
+func (vm *VM) exposed() ([]Value, error) {
+	globals := slices.Clone(vm.globals)
+	for idx := range vm.hidden() {
+		if idx < len(globals) {
+			globals[idx] = nil
+		}
+	}
+
+	limits := vm.limits()
+	for name := range vm.presets {
+		sym, ok := vm.session.SymbolTable().Resolve(name)
+		if !ok || sym.Index >= len(globals) || globals[sym.Index] == nil {
+			continue
+		}
+		if err := limits.Check(globals[sym.Index]); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return globals, nil
+}
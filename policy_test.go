@@ -0,0 +1,115 @@
+package minijs_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/siyul-park/minijs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVM_SetPolicy(t *testing.T) {
+	setup := func(t *testing.T) *minijs.VM {
+		vm := minijs.New()
+		assert.NoError(t, vm.SetGlobal("name", "alice"))
+		assert.NoError(t, vm.SetGlobal("secret", "hunter2"))
+		assert.NoError(t, vm.SetGlobal("items", []int{1, 2, 3}))
+		assert.NoError(t, vm.Set("greet", func(name string) string { return "hi " + name }))
+		assert.NoError(t, vm.Set("exec", func(cmd string) string { return cmd }))
+		assert.NoError(t, vm.Set("repeat", func(s string, n int) string { return strings.Repeat(s, n) }))
+		vm.SetResolver(minijs.NewFSResolver(fstest.MapFS{
+			"util.js": {Data: []byte(`var value = 1`)},
+		}))
+		vm.SetPolicy(&minijs.Policy{
+			Globals:         []string{"name"},
+			Hosts:           []string{"greet", "repeat"},
+			MaxStringLength: 8,
+			MaxArrayLength:  2,
+		})
+		return vm
+	}
+
+	tests := []struct {
+		source string
+		expect any
+		err    error
+	}{
+		{source: `greet(name)`, expect: "hi alice"},
+		{source: `secret`, expect: nil},
+		{source: `var secret = 1; secret`, expect: int32(1)},
+		{source: `repeat("ab", 4)`, expect: "abababab"},
+		{source: `repeat("ab", 5)`, err: minijs.ErrLimitExceeded},
+		{source: `name + name`, err: minijs.ErrLimitExceeded},
+		{source: `exec("ls")`, err: &minijs.CompileError{Kind: minijs.KindCompile}},
+		{source: `require("util.js")`, err: &minijs.CompileError{Kind: minijs.KindCompile}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			actual, err := setup(t).Eval(tt.source)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, actual)
+			}
+		})
+	}
+}
+
+func TestVM_SetPolicy_Globals(t *testing.T) {
+	vm := minijs.New()
+	assert.NoError(t, vm.SetGlobal("secret", "hunter2"))
+	assert.NoError(t, vm.SetGlobal("items", []int{1, 2, 3}))
+
+	vm.SetPolicy(&minijs.Policy{})
+	_, err := vm.Eval(`secret = "leaked"`)
+	assert.NoError(t, err)
+
+	val, ok := vm.GetGlobal("secret")
+	assert.True(t, ok)
+	var secret string
+	assert.NoError(t, minijs.ToGo(val, &secret))
+	assert.Equal(t, "hunter2", secret)
+
+	vm.SetPolicy(&minijs.Policy{Globals: []string{"items"}, MaxArrayLength: 2})
+	_, err = vm.Eval(`items.length`)
+	assert.ErrorIs(t, err, minijs.ErrLimitExceeded)
+
+	vm.SetPolicy(nil)
+	actual, err := vm.Eval(`items.length`)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), actual)
+}
+
+func TestVM_SetPolicy_MaxCallDepth(t *testing.T) {
+	vm := minijs.New()
+	vm.SetPolicy(&minijs.Policy{MaxCallDepth: 2})
+
+	actual, err := vm.Eval(`function a() { return b() } function b() { return 1 } a()`)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), actual)
+
+	_, err = vm.Eval(`function c() { return a() } c()`)
+	assert.ErrorIs(t, err, minijs.ErrLimitExceeded)
+	assert.ErrorIs(t, err, &minijs.RuntimeError{Kind: minijs.KindLimit})
+}
+
+func TestVM_SetPolicy_MaxInstructions(t *testing.T) {
+	vm := minijs.New()
+	assert.NoError(t, vm.SetGlobal("n", 1))
+	vm.SetPolicy(&minijs.Policy{Globals: []string{"n"}, MaxInstructions: 20})
+
+	actual, err := vm.Eval(`n + n`)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), actual)
+
+	_, err = vm.Eval(`function f() { return f() } f()`)
+	assert.ErrorIs(t, err, minijs.ErrLimitExceeded)
+
+	var e *minijs.RuntimeError
+	assert.ErrorAs(t, err, &e)
+	assert.Contains(t, e.Message, "instructions")
+}
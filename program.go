@@ -0,0 +1,84 @@
+package minijs
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/siyul-park/minijs/internal/interpreter"
+)
+
+// Program is a compiled script. It is immutable, and Execute may be called from
+// several goroutines at once since each run gets its own interpreter state.
+type Program struct {
+	code    bytecode.Bytecode
+	pool    *interpreter.Pool
+	globals []Value
+}
+
+// Eval runs src and returns the value of its last expression statement
+// converted to Go as ToGo does for an *any: nil for undefined and null, a bool,
+// int32, float64, or string, or the Go value behind an object.
+func Eval(src string) (any, error) {
+	return RunReader(strings.NewReader(src))
+}
+
+// RunReader is like Eval but reads the script from r.
+func RunReader(r io.Reader) (any, error) {
+	p, err := CompileReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return p.Execute()
+}
+
+// Compile parses and compiles src once so that it can be executed many times.
+func Compile(src string) (*Program, error) {
+	return CompileReader(strings.NewReader(src))
+}
+
+// CompileReader is like Compile but reads the script from r.
+func CompileReader(r io.Reader) (*Program, error) {
+	return New().CompileReader(r)
+}
+
+// Execute runs the program on a fresh interpreter state and returns its result
+// as Eval does.
+func (p *Program) Execute() (any, error) {
+	i := p.pool.Get()
+	defer p.pool.Put(i)
+	return p.run(i)
+}
+
+// MarshalBinary encodes the compiled program, which FSResolver can then load as
+// a module from a file with the ".mjsc" extension.
+func (p *Program) MarshalBinary() ([]byte, error) {
+	return p.code.MarshalBinary()
+}
+
+func (p *Program) run(i *interpreter.Interpreter) (any, error) {
+	for idx, val := range p.globals {
+		if val != nil {
+			if err := i.SetGlobal(idx, val); err != nil {
+				return nil, runtimeError(err)
+			}
+		}
+	}
+	if err := i.Execute(p.code); err != nil {
+		return nil, runtimeError(err)
+	}
+
+	val, err := i.Pop()
+	if errors.Is(err, interpreter.ErrStackUnderflow) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := ToGo(val, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,86 @@
+package minijs_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/siyul-park/minijs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		source string
+		expect any
+	}{
+		{source: `"hello, " + "world"`, expect: "hello, world"},
+		{source: `1 + 2`, expect: int32(3)},
+		{source: `1 / 2`, expect: 0.5},
+		{source: `function add(a, b) { return a + b } add(1, 2)`, expect: int32(3)},
+		{source: `var a = true; a`, expect: true},
+		{source: `null`, expect: nil},
+		{source: `var a = 1`, expect: nil},
+		{source: ``, expect: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			actual, err := minijs.Eval(tt.source)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, actual)
+		})
+	}
+}
+
+func TestEval_Error(t *testing.T) {
+	_, err := minijs.Eval("foo(1;")
+	assert.Error(t, err)
+
+	_, err = minijs.Eval("return 1")
+	assert.Error(t, err)
+
+	_, err = minijs.Eval("undefined()")
+	assert.Error(t, err)
+}
+
+func TestRunReader(t *testing.T) {
+	actual, err := minijs.RunReader(strings.NewReader("var a = 2; a * 3"))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(6), actual)
+}
+
+func TestCompile(t *testing.T) {
+	p, err := minijs.Compile("var n = 0; function inc() { n = n + 1; return n } inc(); inc()")
+	assert.NoError(t, err)
+
+	for range 3 {
+		actual, err := p.Execute()
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), actual)
+	}
+
+	_, err = minijs.Compile("foo(1;")
+	assert.Error(t, err)
+}
+
+func TestProgram_Execute_Concurrent(t *testing.T) {
+	p, err := minijs.Compile("var a = 'x'; function f(b) { a = a + b; return a } f(1) + f(2)")
+	assert.NoError(t, err)
+	expect := "x1x12"
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				actual, err := p.Execute()
+				assert.NoError(t, err)
+				assert.Equal(t, expect, actual)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -2,113 +2,338 @@ package minijs
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/siyul-park/minijs/internal/bytecode"
+	"github.com/siyul-park/minijs/internal/ast"
 	"github.com/siyul-park/minijs/internal/compiler"
 	"github.com/siyul-park/minijs/internal/interpreter"
 	"github.com/siyul-park/minijs/internal/lexer"
 	"github.com/siyul-park/minijs/internal/parser"
+	"github.com/siyul-park/minijs/internal/token"
 )
 
 type REPLOption struct {
 	PrintBytecode bool
+	Strict        bool
+	// History is a file that keeps entered lines across sessions.
+	History string
+	// Color highlights input typed at a terminal.
+	Color bool
 }
 
 type REPL struct {
 	prompt        string
 	printBytecode bool
+	strict        bool
+	historyFile   string
+	color         bool
+	history       []string
+	session       *compiler.Session
+	compiler      *compiler.Compiler
+	interpreter   *interpreter.Interpreter
 }
 
+// commands lists the meta commands with their usage and description.
+var commands = [][2]string{
+	{":ast <source>", "print the syntax tree of source"},
+	{":bytecode <source>", "print the bytecode of source without running it"},
+	{":time <source>", "run source and print how long it took"},
+	{":reset", "forget all variables and start a new session"},
+	{":help", "print this list"},
+}
+
+// maxHistory is the number of lines loaded from the history file.
+const maxHistory = 1000
+
+const (
+	colorReset   = "\x1b[0m"
+	colorKeyword = "\x1b[35m"
+	colorString  = "\x1b[32m"
+	colorNumber  = "\x1b[33m"
+	colorComment = "\x1b[90m"
+)
+
 func NewREPL(prompt string, opts ...REPLOption) *REPL {
 	repl := &REPL{prompt: prompt}
 
 	for _, opt := range opts {
 		repl.printBytecode = opt.PrintBytecode
+		repl.strict = opt.Strict
+		repl.historyFile = opt.History
+		repl.color = opt.Color
 	}
 
+	repl.Reset()
 	return repl
 }
 
+// Start reads and evaluates lines from reader until it ends. When reader is a
+// terminal, lines are edited in place with history on the arrow keys and
+// completion on tab. Lines that start with a colon are meta commands; ":help"
+// lists them.
 func (r *REPL) Start(reader io.Reader, writer io.Writer) error {
-	scanner := bufio.NewScanner(reader)
-
-	c := compiler.New()
-	i := interpreter.New()
+	if err := r.loadHistory(); err != nil {
+		return err
+	}
 
-	for {
+	scanner := bufio.NewScanner(reader)
+	read := func() (string, error) {
 		if r.prompt != "" {
 			if _, err := fmt.Fprint(writer, r.prompt); err != nil {
-				return err
+				return "", err
 			}
 		}
-
 		if !scanner.Scan() {
 			if err := scanner.Err(); err != nil {
-				return fmt.Errorf("failed to read input: %w", err)
+				return "", fmt.Errorf("failed to read input: %w", err)
 			}
-			break
+			return "", io.EOF
 		}
-
-		line := scanner.Text()
-
-		l := lexer.New(strings.NewReader(line))
-		p := parser.New(l)
-
-		program, err := p.Parse()
-		if err != nil {
-			if err := r.error(writer, err); err != nil {
-				return err
+		return scanner.Text(), nil
+	}
+	if f, ok := reader.(*os.File); ok {
+		if restore, err := makeRaw(f.Fd()); err == nil {
+			defer restore()
+			e := &editor{
+				in:       bufio.NewReader(f),
+				out:      writer,
+				prompt:   r.prompt,
+				history:  r.History,
+				complete: r.Complete,
+				color:    r.color,
 			}
-			continue
+			read = e.readLine
 		}
+	}
 
-		code, err := c.Compile(program)
+	for {
+		line, err := read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
 		if err != nil {
-			if err := r.error(writer, err); err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) != "" {
+			if err := r.record(line); err != nil {
 				return err
 			}
-			continue
 		}
+		if err := r.eval(line, writer); err != nil {
+			return err
+		}
+	}
 
-		if r.printBytecode {
-			if _, err := fmt.Fprintln(writer, code.String()); err != nil {
-				return err
-			}
+	return nil
+}
+
+// Reset forgets the variables defined so far and starts a new session. The
+// history is kept.
+func (r *REPL) Reset() {
+	r.session = compiler.NewSession()
+	r.compiler = r.compile(r.session)
+	r.interpreter = interpreter.New()
+}
+
+// History returns the lines entered so far, oldest first, including those
+// loaded from the history file.
+func (r *REPL) History() []string {
+	return slices.Clone(r.history)
+}
+
+// Complete returns the keywords and the variables defined in the session that
+// start with prefix, sorted.
+func (r *REPL) Complete(prefix string) []string {
+	var candidates []string
+	for _, keyword := range token.Keywords() {
+		if strings.HasPrefix(string(keyword), prefix) {
+			candidates = append(candidates, string(keyword))
+		}
+	}
+	for _, name := range r.session.SymbolTable().Names() {
+		if strings.HasPrefix(name, prefix) && !slices.Contains(candidates, name) {
+			candidates = append(candidates, name)
 		}
+	}
+	slices.Sort(candidates)
+	return candidates
+}
 
-		var insts []bytecode.Instruction
-		for offset := 0; offset < len(code.Instructions); {
-			inst, size := code.Fetch(offset)
-			insts = append(insts, inst)
-			offset += size
+// Highlight colors the keywords, strings, numbers, and comments of src with
+// ANSI escape codes, leaving the rest of the text as it is.
+func Highlight(src string) string {
+	l := lexer.NewBytes([]byte(src), lexer.WithComments(true), lexer.WithWhitespace(true))
+
+	var out strings.Builder
+	offset := 0
+	for tk := range l.Tokens() {
+		if tk.Start.Offset < offset || tk.End.Offset > len(src) {
+			break
 		}
-		if len(insts) > 0 {
-			if insts[len(insts)-1].Opcode() == bytecode.POP {
-				insts = insts[:len(insts)-1]
-			}
+		out.WriteString(src[offset:tk.Start.Offset])
+		text := src[tk.Start.Offset:tk.End.Offset]
+		offset = tk.End.Offset
 
-			code.Instructions = nil
-			code.Emit(insts...)
+		color := ""
+		switch {
+		case token.IsKeyword(tk.Type):
+			color = colorKeyword
+		case tk.Type == token.STRING:
+			color = colorString
+		case tk.Type == token.NUMBER:
+			color = colorNumber
+		case tk.Type == token.COMMENT:
+			color = colorComment
+		}
+		if color == "" {
+			out.WriteString(text)
+		} else {
+			out.WriteString(color + text + colorReset)
 		}
+	}
+	out.WriteString(src[offset:])
+	return out.String()
+}
+
+// eval runs a line of input, which is either a meta command or source.
+func (r *REPL) eval(line string, writer io.Writer) error {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if !strings.HasPrefix(name, ":") {
+		return r.run(line, writer)
+	}
+	arg = strings.TrimSpace(arg)
 
-		if err := i.Execute(code); err != nil {
-			if err := r.error(writer, err); err != nil {
+	switch name {
+	case ":ast":
+		program, err := parser.New(lexer.New(strings.NewReader(arg))).Parse()
+		if err != nil {
+			return r.error(writer, err)
+		}
+		return ast.Fprint(writer, program)
+	case ":bytecode":
+		program, err := parser.New(lexer.New(strings.NewReader(arg))).Parse()
+		if err != nil {
+			return r.error(writer, err)
+		}
+		code, err := r.compile(r.session.Clone()).Compile(program)
+		if err != nil {
+			return r.error(writer, err)
+		}
+		_, err = fmt.Fprintln(writer, code.String())
+		return err
+	case ":time":
+		start := time.Now()
+		if err := r.run(arg, writer); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(writer, "time: %s\n", time.Since(start))
+		return err
+	case ":reset":
+		r.Reset()
+		return nil
+	case ":help":
+		for _, cmd := range commands {
+			if _, err := fmt.Fprintf(writer, "%-20s %s\n", cmd[0], cmd[1]); err != nil {
 				return err
 			}
-			continue
 		}
+		return nil
+	default:
+		_, err := fmt.Fprintf(writer, "unknown command %s, type :help to list commands\n", name)
+		return err
+	}
+}
 
-		if _, err := fmt.Fprintln(writer, i.Pop()); err != nil {
+// run compiles and runs source in the session and prints its value. Errors in
+// the source are printed; only errors writing to writer are returned.
+func (r *REPL) run(source string, writer io.Writer) error {
+	program, err := parser.New(lexer.New(strings.NewReader(source))).Parse()
+	if err != nil {
+		return r.error(writer, err)
+	}
+
+	code, err := r.compiler.Compile(program)
+	if err != nil {
+		return r.error(writer, err)
+	}
+
+	if r.printBytecode {
+		if _, err := fmt.Fprintln(writer, code.String()); err != nil {
 			return err
 		}
 	}
 
+	if err := r.interpreter.Execute(code); err != nil {
+		return r.error(writer, err)
+	}
+
+	val, err := r.interpreter.Pop()
+	if errors.Is(err, interpreter.ErrStackUnderflow) {
+		return nil
+	}
+	_, err = fmt.Fprintln(writer, val)
+	return err
+}
+
+func (r *REPL) compile(session *compiler.Session) *compiler.Compiler {
+	return compiler.New(
+		compiler.WithSession(session),
+		compiler.WithOptimization(compiler.O1),
+		compiler.WithCompletionValue(true),
+		compiler.WithDebugInfo(true),
+		compiler.WithStrict(r.strict),
+	)
+}
+
+func (r *REPL) loadHistory() error {
+	if r.historyFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(r.historyFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > maxHistory {
+		lines = lines[len(lines)-maxHistory:]
+	}
+	r.history = nil
+	for _, line := range lines {
+		if line != "" {
+			r.history = append(r.history, line)
+		}
+	}
 	return nil
 }
 
+func (r *REPL) record(line string) error {
+	if n := len(r.history); n > 0 && r.history[n-1] == line {
+		return nil
+	}
+	r.history = append(r.history, line)
+	if r.historyFile == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(r.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	defer f.Close()
+	_, err = fmt.Fprintln(f, line)
+	return err
+}
+
 func (r *REPL) error(writer io.Writer, err error) error {
 	_, err = fmt.Fprintln(writer, err)
 	return err
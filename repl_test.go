@@ -2,6 +2,10 @@ package minijs_test
 
 import (
 	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/siyul-park/minijs"
@@ -19,3 +23,100 @@ func TestREPL_Start(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "\"hello, world\"\n", output.String())
 }
+
+func TestREPL_History(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history")
+
+	r := minijs.NewREPL("", minijs.REPLOption{History: file})
+	err := r.Start(strings.NewReader("var a = 1\n\na\na\n"), io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"var a = 1", "a"}, r.History())
+
+	r = minijs.NewREPL("", minijs.REPLOption{History: file})
+	err = r.Start(strings.NewReader("a + 1\n"), io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"var a = 1", "a", "a + 1"}, r.History())
+
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "var a = 1\na\na + 1\n", string(data))
+}
+
+func TestREPL_Complete(t *testing.T) {
+	r := minijs.NewREPL("")
+	err := r.Start(strings.NewReader("var total = 1\nfunction toggle() {}\n"), io.Discard)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"toggle", "total"}, r.Complete("to"))
+	assert.Equal(t, []string{"total"}, r.Complete("tot"))
+	assert.Equal(t, []string{"var", "void"}, r.Complete("v"))
+	assert.Empty(t, r.Complete("zzz"))
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		source string
+		expect string
+	}{
+		{
+			source: `var a = "x" + 1; // done`,
+			expect: "\x1b[35mvar\x1b[0m a = \x1b[32m\"x\"\x1b[0m + \x1b[33m1\x1b[0m; \x1b[90m// done\x1b[0m",
+		},
+		{source: `a +`, expect: `a +`},
+		{source: `"open`, expect: `"open`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			assert.Equal(t, tt.expect, minijs.Highlight(tt.source))
+		})
+	}
+}
+
+func TestREPL_Commands(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{
+			input:  ":ast 1 + a",
+			expect: "Program\n  Statements[0]: ExpressionStatement\n    Expression: InfixExpression +\n      Left: NumberLiteral 1\n      Right: IdentifierLiteral \"a\"\n",
+		},
+		{
+			input:  "var a = 1\n:bytecode var b = a",
+			expect: "section .text:\n\tslot.load 0x0\n\tslot.store 0x1\n",
+		},
+		{
+			input:  ":bytecode var b = 1\nb",
+			expect: "undefined identifier: b",
+		},
+		{
+			input:  "var a = 1\n:reset\na",
+			expect: "undefined identifier: a",
+		},
+		{
+			input:  ":help",
+			expect: ":reset",
+		},
+		{
+			input:  ":nope",
+			expect: "unknown command :nope, type :help to list commands\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var output bytes.Buffer
+			err := minijs.NewREPL("").Start(strings.NewReader(tt.input), &output)
+			assert.NoError(t, err)
+			assert.Contains(t, output.String(), tt.expect)
+		})
+	}
+}
+
+func TestREPL_Commands_Time(t *testing.T) {
+	var output bytes.Buffer
+	err := minijs.NewREPL("").Start(strings.NewReader(":time 1 + 2"), &output)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^3\ntime: \S+\n$`, output.String())
+}
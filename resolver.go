@@ -0,0 +1,113 @@
+package minijs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FSResolver is a Resolver that loads modules from a file system. Names that
+// start with "./" or "../" are relative to the requiring module and other names
+// are relative to the root of the file system. A name may omit the ".js"
+// extension of a source file or the ".mjsc" extension of a compiled one, and may
+// name a package directory, which loads the file named by the "main" field of
+// its package.json or else its index file. Loaded files are cached until they
+// change.
+type FSResolver struct {
+	fsys  fs.FS
+	files map[string]file
+	mu    sync.Mutex
+}
+
+type file struct {
+	data    []byte
+	size    int64
+	modTime time.Time
+}
+
+var _ Resolver = (*FSResolver)(nil)
+
+var extensions = []string{".js", ".mjsc"}
+
+func NewFSResolver(fsys fs.FS) *FSResolver {
+	return &FSResolver{fsys: fsys, files: map[string]file{}}
+}
+
+// Resolve returns the path in the file system of the module name required from
+// the module at path from.
+func (r *FSResolver) Resolve(from, name string) (string, error) {
+	p := strings.TrimPrefix(name, "/")
+	if strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../") {
+		p = path.Join(path.Dir(from), name)
+	}
+	p = path.Clean(p)
+	if !fs.ValidPath(p) {
+		return "", fmt.Errorf("%w: %s", fs.ErrInvalid, name)
+	}
+
+	if id, ok := r.file(p); ok {
+		return id, nil
+	}
+	if info, err := fs.Stat(r.fsys, p); err == nil && info.IsDir() {
+		if data, err := fs.ReadFile(r.fsys, path.Join(p, "package.json")); err == nil {
+			var pkg struct {
+				Main string `json:"main"`
+			}
+			if err := json.Unmarshal(data, &pkg); err != nil {
+				return "", fmt.Errorf("%s: %w", path.Join(p, "package.json"), err)
+			}
+			if pkg.Main != "" {
+				if id, ok := r.file(path.Join(p, pkg.Main)); ok {
+					return id, nil
+				}
+			}
+		}
+		if id, ok := r.file(path.Join(p, "index")); ok {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", fs.ErrNotExist, name)
+}
+
+// Load returns the contents of the file at id, reading it again only if its
+// size or modification time changed since it was last loaded.
+func (r *FSResolver) Load(id string) ([]byte, error) {
+	info, err := fs.Stat(r.fsys, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	f, ok := r.files[id]
+	r.mu.Unlock()
+	if ok && f.size == info.Size() && f.modTime.Equal(info.ModTime()) {
+		return f.data, nil
+	}
+
+	data, err := fs.ReadFile(r.fsys, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.files[id] = file{data: data, size: info.Size(), modTime: info.ModTime()}
+	r.mu.Unlock()
+	return data, nil
+}
+
+func (r *FSResolver) file(p string) (string, bool) {
+	candidates := []string{p}
+	for _, ext := range extensions {
+		candidates = append(candidates, p+ext)
+	}
+	for _, candidate := range candidates {
+		if info, err := fs.Stat(r.fsys, candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,106 @@
+package minijs_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/siyul-park/minijs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFSResolver_Resolve(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.js":                {Data: []byte(``)},
+		"lib/util.js":            {Data: []byte(``)},
+		"lib/compiled.mjsc":      {Data: []byte(``)},
+		"pkg/package.json":       {Data: []byte(`{"main": "src/entry"}`)},
+		"pkg/src/entry.js":       {Data: []byte(``)},
+		"plain/index.js":         {Data: []byte(``)},
+		"broken/package.json":    {Data: []byte(`{`)},
+		"fallback/package.json":  {Data: []byte(`{}`)},
+		"fallback/index.mjsc":    {Data: []byte(``)},
+		"lib/nested/deep/mod.js": {Data: []byte(``)},
+	}
+
+	tests := []struct {
+		from   string
+		name   string
+		expect string
+		err    error
+	}{
+		{from: "", name: "main.js", expect: "main.js"},
+		{from: "", name: "./main", expect: "main.js"},
+		{from: "", name: "/lib/util", expect: "lib/util.js"},
+		{from: "lib/util.js", name: "./compiled", expect: "lib/compiled.mjsc"},
+		{from: "lib/nested/deep/mod.js", name: "../../util", expect: "lib/util.js"},
+		{from: "lib/util.js", name: "pkg", expect: "pkg/src/entry.js"},
+		{from: "", name: "plain", expect: "plain/index.js"},
+		{from: "", name: "fallback", expect: "fallback/index.mjsc"},
+		{from: "", name: "missing", err: fs.ErrNotExist},
+		{from: "", name: "../outside", err: fs.ErrInvalid},
+	}
+
+	r := minijs.NewFSResolver(fsys)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := r.Resolve(tt.from, tt.name)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, actual)
+		})
+	}
+
+	_, err := r.Resolve("", "broken")
+	assert.ErrorContains(t, err, "broken/package.json")
+}
+
+func TestFSResolver_Load(t *testing.T) {
+	fsys := fstest.MapFS{
+		"mod.js": {Data: []byte(`var a = 1`), ModTime: time.Unix(1, 0)},
+	}
+	r := minijs.NewFSResolver(fsys)
+
+	data, err := r.Load("mod.js")
+	assert.NoError(t, err)
+	assert.Equal(t, `var a = 1`, string(data))
+
+	fsys["mod.js"].Data[8] = '2'
+	data, err = r.Load("mod.js")
+	assert.NoError(t, err)
+	assert.Equal(t, `var a = 1`, string(data))
+
+	fsys["mod.js"] = &fstest.MapFile{Data: []byte(`var a = 3`), ModTime: time.Unix(2, 0)}
+	data, err = r.Load("mod.js")
+	assert.NoError(t, err)
+	assert.Equal(t, `var a = 3`, string(data))
+
+	_, err = r.Load("missing.js")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestFSResolver_Compiled(t *testing.T) {
+	build := minijs.New()
+	build.SetResolver(minijs.NewFSResolver(fstest.MapFS{
+		"util.js": {Data: []byte(`var base = 10`)},
+	}))
+	p, err := build.Compile(`var util = require("./util"); function add(x) { return util.base + x }`)
+	assert.NoError(t, err)
+	data, err := p.MarshalBinary()
+	assert.NoError(t, err)
+
+	vm := minijs.New()
+	vm.SetResolver(minijs.NewFSResolver(fstest.MapFS{
+		"lib/math.mjsc": {Data: data},
+		"lib/util.js":   {Data: []byte(`var base = 20`)},
+	}))
+
+	actual, err := vm.Eval(`require("./lib/math").add(1)`)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(21), actual)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package minijs
+
+import "errors"
+
+// makeRaw is only supported on Linux; elsewhere the REPL reads whole lines.
+func makeRaw(uintptr) (func(), error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,257 @@
+package minijs
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/siyul-park/minijs/internal/compiler"
+	"github.com/siyul-park/minijs/internal/interpreter"
+	"github.com/siyul-park/minijs/internal/lexer"
+	"github.com/siyul-park/minijs/internal/parser"
+	"github.com/siyul-park/minijs/internal/token"
+)
+
+// VM compiles and runs scripts against a set of Go functions registered with
+// Set and global variables shared across runs. Programs keep the functions and
+// globals that were set when they were compiled. A VM must not be used from
+// several goroutines at once; compile a Program for that.
+type VM struct {
+	hosts    map[string]interpreter.Host
+	session  *compiler.Session
+	globals  []Value
+	resolver Resolver
+	modules  map[string]*module
+	policy   *Policy
+	presets  map[string]bool
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func New() *VM {
+	return &VM{
+		hosts:   map[string]interpreter.Host{},
+		session: compiler.NewSession(),
+		presets: map[string]bool{},
+	}
+}
+
+// Set makes fn callable from scripts as name. Arguments are converted to the
+// parameter types of fn, missing ones are zero, and extra ones are dropped. fn
+// may return nothing, a value, an error, or a value and an error; a non-nil
+// error aborts the script and is returned from Execute.
+func (vm *VM) Set(name string, fn any) error {
+	if !identifier(name) {
+		return fmt.Errorf("invalid function name %q", name)
+	}
+	host, err := bind(name, fn)
+	if err != nil {
+		return err
+	}
+	vm.hosts[name] = host
+	return nil
+}
+
+// SetGlobal converts v with FromGo and assigns it to the global variable name,
+// which scripts run on vm afterwards can read and reassign.
+func (vm *VM) SetGlobal(name string, v any) error {
+	if !identifier(name) {
+		return fmt.Errorf("invalid global name %q", name)
+	}
+	val, err := FromGo(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	symbols := vm.session.SymbolTable()
+	sym, ok := symbols.Resolve(name)
+	if !ok {
+		sym = symbols.Define(name)
+	}
+	sym.Type = interpreter.ANY
+
+	if len(vm.globals) <= sym.Index {
+		vm.globals = slices.Grow(vm.globals, sym.Index+1-len(vm.globals))[:sym.Index+1]
+	}
+	vm.globals[sym.Index] = val
+	vm.presets[name] = true
+	return nil
+}
+
+// GetGlobal returns the value of the global variable name as left by the last
+// script run with Eval or RunReader, or false if it has none. Use ToGo to decode
+// it.
+func (vm *VM) GetGlobal(name string) (Value, bool) {
+	sym, ok := vm.session.SymbolTable().Resolve(name)
+	if !ok || sym.Index >= len(vm.globals) || vm.globals[sym.Index] == nil {
+		return nil, false
+	}
+	return vm.globals[sym.Index], true
+}
+
+// SetResolver lets scripts run on vm load modules with require("name"), which
+// returns an object holding the top-level variables and functions of the module
+// r resolves name to. The name must be a string literal: required modules are
+// loaded and run when the requiring script is compiled, each in its own global
+// scope, and a require that leads back to a module still loading is an error.
+func (vm *VM) SetResolver(r Resolver) {
+	vm.resolver = r
+	vm.modules = nil
+}
+
+// Eval is like the package-level Eval but runs src on vm. Global variables the
+// script assigns stay visible to GetGlobal and to later scripts.
+func (vm *VM) Eval(src string) (any, error) {
+	return vm.RunReader(strings.NewReader(src))
+}
+
+// RunReader is like Eval but reads the script from r.
+func (vm *VM) RunReader(r io.Reader) (any, error) {
+	p, err := vm.CompileReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	i := p.pool.Get()
+	defer p.pool.Put(i)
+
+	val, err := p.run(i)
+	hidden := vm.hidden()
+	for idx := range vm.session.SymbolTable().Size() {
+		if v, ok := i.Global(idx); ok && !hidden[idx] {
+			if len(vm.globals) <= idx {
+				vm.globals = slices.Grow(vm.globals, idx+1-len(vm.globals))[:idx+1]
+			}
+			vm.globals[idx] = v
+		}
+	}
+	return val, err
+}
+
+// Compile is like the package-level Compile but binds the functions set on vm.
+func (vm *VM) Compile(src string) (*Program, error) {
+	return vm.CompileReader(strings.NewReader(src))
+}
+
+// CompileReader is like Compile but reads the script from r.
+func (vm *VM) CompileReader(r io.Reader) (*Program, error) {
+	program, err := parser.New(lexer.New(r)).Parse()
+	if err != nil {
+		return nil, syntaxError(err)
+	}
+
+	var modules map[string]*module
+	if vm.resolver != nil && vm.allows("require") {
+		names, err := imports(program)
+		if err != nil {
+			return nil, err
+		}
+		if modules, err = vm.require("", nil, names); err != nil {
+			return nil, err
+		}
+	}
+
+	c := compiler.New(
+		compiler.WithSession(vm.session),
+		compiler.WithOptimization(compiler.O1),
+		compiler.WithDebugInfo(true),
+		compiler.WithCompletionValue(true),
+		compiler.WithHosts(vm.names()...),
+	)
+	code, err := c.Compile(program)
+	if err != nil {
+		return nil, compileError(err)
+	}
+	if err := interpreter.Verify(code); err != nil {
+		return nil, compileError(err)
+	}
+
+	globals, err := vm.exposed()
+	if err != nil {
+		return nil, err
+	}
+	return &Program{code: code, pool: interpreter.NewPool(vm.options(modules)...), globals: globals}, nil
+}
+
+func identifier(name string) bool {
+	l := lexer.NewBytes([]byte(name))
+	tk := l.Next()
+	return tk.Type == token.IDENTIFIER && tk.Literal == name && l.Next().Type == token.EOF
+}
+
+func bind(name string, f any) (interpreter.Host, error) {
+	fn := reflect.ValueOf(f)
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return nil, fmt.Errorf("%s: %T is not a function", name, f)
+	}
+	typ := fn.Type()
+	for i := 0; i < typ.NumIn(); i++ {
+		in := typ.In(i)
+		if typ.IsVariadic() && i == typ.NumIn()-1 {
+			in = in.Elem()
+		}
+		if !convertible(in) || in.Kind() == reflect.Func {
+			return nil, fmt.Errorf("%s: unsupported parameter type %v", name, in)
+		}
+	}
+	switch typ.NumOut() {
+	case 0:
+	case 1:
+		if out := typ.Out(0); out != errorType && !convertible(out) {
+			return nil, fmt.Errorf("%s: unsupported result type %v", name, out)
+		}
+	case 2:
+		if out := typ.Out(0); !convertible(out) {
+			return nil, fmt.Errorf("%s: unsupported result type %v", name, out)
+		}
+		if typ.Out(1) != errorType {
+			return nil, fmt.Errorf("%s: second result must be error, got %v", name, typ.Out(1))
+		}
+	default:
+		return nil, fmt.Errorf("%s: too many results", name)
+	}
+
+	return func(args []interpreter.Value) (val interpreter.Value, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &hostError{name: name, err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+
+		n := typ.NumIn()
+		if typ.IsVariadic() {
+			n = max(n-1, len(args))
+		}
+		in := make([]reflect.Value, n)
+		for i := range in {
+			t := typ.In(min(i, typ.NumIn()-1))
+			if typ.IsVariadic() && i >= typ.NumIn()-1 {
+				t = t.Elem()
+			}
+			if i >= len(args) {
+				in[i] = reflect.Zero(t)
+				continue
+			}
+			if in[i], err = fromValue(args[i], t); err != nil {
+				return nil, fmt.Errorf("%s: argument %d: %w", name, i+1, err)
+			}
+		}
+
+		out := fn.Call(in)
+		if len(out) > 0 && out[len(out)-1].Type() == errorType {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return nil, &hostError{name: name, err: err}
+			}
+			out = out[:len(out)-1]
+		}
+		if len(out) == 0 {
+			return interpreter.Undefined{}, nil
+		}
+		if val, err = toValue(out[0]); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return val, nil
+	}, nil
+}
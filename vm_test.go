@@ -0,0 +1,173 @@
+package minijs_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/siyul-park/minijs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVM_Set(t *testing.T) {
+	setup := func(t *testing.T) *minijs.VM {
+		vm := minijs.New()
+		assert.NoError(t, vm.Set("fetchUser", func(id int) (string, error) {
+			return fmt.Sprintf("user-%d", id), nil
+		}))
+		assert.NoError(t, vm.Set("add", func(a, b float64) float64 { return a + b }))
+		assert.NoError(t, vm.Set("sum", func(nums ...int) int {
+			total := 0
+			for _, n := range nums {
+				total += n
+			}
+			return total
+		}))
+		assert.NoError(t, vm.Set("join", func(sep string, parts ...string) string {
+			return strings.Join(parts, sep)
+		}))
+		assert.NoError(t, vm.Set("not", func(b bool) bool { return !b }))
+		assert.NoError(t, vm.Set("identity", func(v any) any { return v }))
+		assert.NoError(t, vm.Set("noop", func() {}))
+		return vm
+	}
+
+	tests := []struct {
+		source string
+		expect any
+	}{
+		{source: `fetchUser(1)`, expect: "user-1"},
+		{source: `fetchUser("7")`, expect: "user-7"},
+		{source: `add(1, 0.5)`, expect: 1.5},
+		{source: `sum(1, 2, 3)`, expect: int32(6)},
+		{source: `sum()`, expect: int32(0)},
+		{source: `join("-", "a", 1, true)`, expect: "a-1-true"},
+		{source: `not(0)`, expect: true},
+		{source: `identity("x")`, expect: "x"},
+		{source: `identity()`, expect: nil},
+		{source: `noop()`, expect: nil},
+		{source: `function f(id) { return fetchUser(id + 1) } f(1)`, expect: "user-2"},
+		{source: `function fetchUser(id) { return id } fetchUser(1)`, expect: int32(1)},
+		{source: `fetchUser(1, 2, 3)`, expect: "user-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			actual, err := setup(t).Eval(tt.source)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, actual)
+		})
+	}
+}
+
+func TestVM_Set_Invalid(t *testing.T) {
+	vm := minijs.New()
+	assert.Error(t, vm.Set("f", 1))
+	assert.Error(t, vm.Set("f", nil))
+	assert.Error(t, vm.Set("f", (func())(nil)))
+	assert.Error(t, vm.Set("var", func() {}))
+	assert.Error(t, vm.Set("a b", func() {}))
+	assert.Error(t, vm.Set("f", func(chan int) {}))
+	assert.Error(t, vm.Set("f", func() (int, int) { return 0, 0 }))
+	assert.Error(t, vm.Set("f", func() chan int { return nil }))
+}
+
+func TestVM_Set_Error(t *testing.T) {
+	errNotFound := errors.New("not found")
+
+	vm := minijs.New()
+	assert.NoError(t, vm.Set("fetchUser", func(id int) (string, error) {
+		return "", errNotFound
+	}))
+	assert.NoError(t, vm.Set("explode", func() { panic("boom") }))
+
+	_, err := vm.Eval("fetchUser(1)")
+	assert.ErrorIs(t, err, errNotFound)
+	assert.ErrorContains(t, err, "fetchUser")
+
+	_, err = vm.Eval("fetchUser(1.5)")
+	assert.ErrorContains(t, err, "argument 1")
+
+	_, err = vm.Eval("explode()")
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestVM_Compile(t *testing.T) {
+	calls := 0
+	vm := minijs.New()
+	assert.NoError(t, vm.Set("count", func() int {
+		calls++
+		return calls
+	}))
+
+	p, err := vm.Compile("count(); count()")
+	assert.NoError(t, err)
+
+	actual, err := p.Execute()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), actual)
+
+	_, err = minijs.Eval("count()")
+	assert.Error(t, err)
+}
+
+func TestVM_SetGlobal(t *testing.T) {
+	vm := minijs.New()
+	assert.NoError(t, vm.SetGlobal("config", map[string]any{"retries": 3, "name": "job"}))
+	assert.NoError(t, vm.SetGlobal("limit", 10))
+
+	actual, err := vm.Eval(`config.name + ":" + config.retries`)
+	assert.NoError(t, err)
+	assert.Equal(t, "job:3", actual)
+
+	actual, err = vm.Eval(`function f() { return limit + 1 } f()`)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(11), actual)
+
+	_, err = vm.Eval(`var result = limit * 2; var unused = 1; label = "done"`)
+	assert.NoError(t, err)
+
+	result, ok := vm.GetGlobal("result")
+	assert.True(t, ok)
+
+	var n int
+	assert.NoError(t, minijs.ToGo(result, &n))
+	assert.Equal(t, 20, n)
+
+	label, ok := vm.GetGlobal("label")
+	assert.True(t, ok)
+	assert.Equal(t, "done", label.Interface())
+
+	assert.NoError(t, vm.SetGlobal("limit", "x"))
+	actual, err = vm.Eval(`limit + result`)
+	assert.NoError(t, err)
+	assert.Equal(t, "x20", actual)
+
+	_, ok = vm.GetGlobal("missing")
+	assert.False(t, ok)
+
+	assert.Error(t, vm.SetGlobal("var", 1))
+	assert.Error(t, vm.SetGlobal("ch", make(chan int)))
+}
+
+func TestVM_SetGlobal_Program(t *testing.T) {
+	vm := minijs.New()
+	assert.NoError(t, vm.SetGlobal("base", 1))
+
+	p, err := vm.Compile(`base = base + 1; base`)
+	assert.NoError(t, err)
+
+	assert.NoError(t, vm.SetGlobal("base", 100))
+
+	for range 2 {
+		actual, err := p.Execute()
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), actual)
+	}
+
+	base, ok := vm.GetGlobal("base")
+	assert.True(t, ok)
+	assert.Equal(t, int32(100), base.Interface())
+}